@@ -1,21 +1,28 @@
 package main
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
 	casbinpgadapter "github.com/cychiuae/casbin-pg-adapter"
+	"github.com/jmoiron/sqlx"
 	"github.com/unanet/eve/internal/config"
 	"github.com/unanet/eve/pkg/s3"
 	"github.com/unanet/eve/pkg/scm"
+	"github.com/unanet/eve/pkg/storage"
 	"go.uber.org/zap"
 
 	"github.com/unanet/eve/internal/api"
 	"github.com/unanet/eve/internal/data"
 	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/events"
+	"github.com/unanet/eve/internal/service/pipelines"
 	"github.com/unanet/eve/internal/service/plans"
 	"github.com/unanet/eve/internal/service/releases"
+	"github.com/unanet/eve/internal/service/webhooks"
 	"github.com/unanet/eve/pkg/artifactory"
 	"github.com/unanet/eve/pkg/queue"
 	"github.com/unanet/go/pkg/identity"
@@ -25,12 +32,65 @@ import (
 func GetPolicyModel() model.Model {
 	m := model.NewModel()
 	m.AddDef("r", "r", "sub, obj, act")
-	m.AddDef("p", "p", "sub, obj, act")
-	m.AddDef("e", "e", "some(where (p.eft == allow))")
+	m.AddDef("p", "p", "sub, obj, act, eft")
+	m.AddDef("e", "e", "some(where (p.eft == allow)) && !some(where (p.eft == deny))")
 	m.AddDef("m", "m", "r.sub == p.sub && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && (r.act == p.act || p.act == \"*\")")
 	return m
 }
 
+// getQueue returns the api queue and the provider used to reach the scheduler queues for the configured backend
+func getQueue(cfg config.Config, awsSession *session.Session, db *sqlx.DB) (queue.Queue, queue.Provider) {
+	qConfig := queue.Config{
+		MaxNumberOfMessage: cfg.ApiQMaxNumberOfMessage,
+		QueueURL:           cfg.ApiQUrl,
+		WaitTimeSecond:     cfg.ApiQWaitTimeSecond,
+		VisibilityTimeout:  cfg.ApiQVisibilityTimeout,
+	}
+
+	switch queue.Backend(cfg.QueueBackend) {
+	case queue.BackendSQS:
+		return queue.NewQ(awsSession, qConfig), queue.SQSProvider(awsSession)
+	case queue.BackendMemory:
+		broker := queue.NewMemBroker()
+		return broker.Queue(qConfig), queue.MemProvider(broker)
+	case queue.BackendPostgres:
+		return queue.NewPgQ(db, qConfig), queue.PgProvider(db)
+	default:
+		log.Logger.Panic("Unknown Queue Backend", zap.String("backend", cfg.QueueBackend))
+		return nil, nil
+	}
+}
+
+// getPlanStore returns the store used to hand plans off to the scheduler, plans are always downloadable
+// from every backend that's configured so the backend can be switched while deployments are in flight
+func getPlanStore(cfg config.Config, awsSession *session.Session, db *sqlx.DB) *storage.Store {
+	downloaders := map[storage.Backend]storage.Downloader{
+		storage.BackendFileSystem: storage.NewFileSystem(cfg.PlanStorageDir),
+		storage.BackendPostgres:   storage.NewPostgres(db),
+	}
+
+	var s3Storage *storage.S3
+	if cfg.S3Bucket != "" {
+		s3Storage = storage.NewS3(s3.NewUploader(awsSession, s3.Config{Bucket: cfg.S3Bucket}), s3.NewDownloader(awsSession))
+		downloaders[storage.BackendS3] = s3Storage
+	}
+
+	var uploader storage.Uploader
+	switch storage.Backend(cfg.PlanStorage) {
+	case storage.BackendS3:
+		if s3Storage == nil {
+			log.Logger.Panic("S3_BUCKET is required when using s3 plan storage")
+		}
+		uploader = s3Storage
+	case storage.BackendFileSystem, storage.BackendPostgres:
+		uploader = downloaders[storage.Backend(cfg.PlanStorage)].(storage.Uploader)
+	default:
+		log.Logger.Panic("Unknown Plan Storage Backend", zap.String("backend", cfg.PlanStorage))
+	}
+
+	return storage.NewStore(uploader, cfg.PlanInlineLimit, downloaders)
+}
+
 func main() {
 	dbConfig := config.GetDBConfig()
 	// Try to get a DB Connection
@@ -61,21 +121,32 @@ func main() {
 	if err != nil {
 		log.Logger.Panic("Failed to create AWS Session", zap.Error(err))
 	}
-	apiQueue := queue.NewQ(awsSession, queue.Config{
-		MaxNumberOfMessage: cfg.ApiQMaxNumberOfMessage,
-		QueueURL:           cfg.ApiQUrl,
-		WaitTimeSecond:     cfg.ApiQWaitTimeSecond,
-		VisibilityTimeout:  cfg.ApiQVisibilityTimeout,
-	})
+	apiQueue, queueProvider := getQueue(cfg, awsSession, db)
+	planStore := getPlanStore(cfg, awsSession, db)
 
 	repo := data.NewRepo(db)
 	artifactoryClient := artifactory.NewClient(cfg.ArtifactoryConfig)
-	deploymentPlanGenerator := plans.NewPlanGenerator(repo, artifactoryClient, apiQueue)
-	crudManager := crud.NewManager(repo)
+	eventBus := events.NewBus()
+	dispatcher := webhooks.NewDispatcher(repo, cfg.HttpCallbackTimeout, cfg.WebhookSecret)
+	eventBus.Handle(dispatcher.Publish)
+	deploymentPlanGenerator := plans.NewPlanGenerator(repo, artifactoryClient, apiQueue, eventBus)
+	crudManager := crud.NewManager(repo, planStore)
 	scmClient := scm.New()
 	releaseSvc := releases.NewReleaseSvc(repo, artifactoryClient, scmClient, crudManager)
+	pipelineRunner := pipelines.NewRunner(repo, deploymentPlanGenerator, releaseSvc, cfg.CronTimeout)
 
-	controllers, err := api.InitializeControllers(deploymentPlanGenerator, crudManager, releaseSvc)
+	apiWorker := queue.NewWorker("eve-api", apiQueue, queueProvider, cfg.ApiQWorkerTimeout)
+	deploymentQueue := plans.NewQueue(
+		apiWorker,
+		repo,
+		crudManager,
+		planStore,
+		planStore,
+		dispatcher,
+		eventBus,
+	)
+
+	controllers, err := api.InitializeControllers(deploymentPlanGenerator, crudManager, releaseSvc, eventBus, dispatcher, pipelineRunner, deploymentQueue)
 	if err != nil {
 		log.Logger.Panic("Unable to Initialize the Controllers")
 	}
@@ -110,23 +181,34 @@ func main() {
 		log.Logger.Panic("Failed to Create Api App", zap.Error(err))
 	}
 
-	deploymentQueue := plans.NewQueue(
-		queue.NewWorker("eve-api", apiQueue, cfg.ApiQWorkerTimeout),
-		repo,
-		crudManager,
-		s3.NewUploader(awsSession, s3.Config{Bucket: cfg.S3Bucket}),
-		s3.NewDownloader(awsSession),
-		plans.NewCallback(cfg.HttpCallbackTimeout),
-	)
-
 	cron := plans.NewDeploymentCron(repo, deploymentPlanGenerator, cfg.CronTimeout)
+	maxDeploymentTimeout := plans.MaxDeploymentTimeout(time.Duration(cfg.ApiQVisibilityTimeout) * time.Second)
+	if cfg.DeploymentTimeout > maxDeploymentTimeout {
+		log.Logger.Panic("DEPLOYMENT_TIMEOUT has to be at least a minute shorter than API_Q_VISIBILITY_TIMEOUT",
+			zap.Duration("deployment_timeout", cfg.DeploymentTimeout),
+			zap.Duration("max_deployment_timeout", maxDeploymentTimeout),
+		)
+	}
+	reaper := plans.NewDeploymentReaper(repo, apiWorker, dispatcher, eventBus, cfg.DeploymentTimeout, maxDeploymentTimeout, cfg.CronTimeout)
+	verifier := plans.NewRolloutVerifier(repo, deploymentPlanGenerator, cfg.CronTimeout)
+	sequencer := plans.NewDeploymentSequencer(repo, deploymentPlanGenerator, eventBus, cfg.CronTimeout)
 	if !cfg.LocalDev {
 		cron.Start()
+		reaper.Start()
+		verifier.Start()
+		sequencer.Start()
+		pipelineRunner.Start()
+		dispatcher.Start()
 		deploymentQueue.Start()
 	}
 
 	apiServer.Start(func() {
 		cron.Stop()
+		reaper.Stop()
+		verifier.Stop()
+		sequencer.Stop()
+		pipelineRunner.Stop()
+		dispatcher.Stop()
 		deploymentQueue.Stop()
 	})
 }
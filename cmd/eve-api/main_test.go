@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func TestGetPolicyModel(t *testing.T) {
+	enforcer, err := casbin.NewEnforcer(GetPolicyModel())
+	if err != nil {
+		t.Fatalf("NewEnforcer() error = %v", err)
+	}
+
+	policies := [][]interface{}{
+		{"user", "/*", "GET", "allow"},
+		{"service", "/*", "*", "allow"},
+		{"admin", "/*", "*", "allow"},
+		{"user", "/deployment-plans/freeze-override", "POST", "deny"},
+		{"service", "/deployment-plans/freeze-override", "POST", "deny"},
+	}
+	for _, p := range policies {
+		if _, err := enforcer.AddPolicy(p...); err != nil {
+			t.Fatalf("AddPolicy() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sub  string
+		obj  string
+		act  string
+		want bool
+	}{
+		{name: "user reads", sub: "user", obj: "/deployments", act: "GET", want: true},
+		{name: "user writes", sub: "user", obj: "/deployment-plans", act: "POST", want: false},
+		{name: "service queues a plan", sub: "service", obj: "/deployment-plans", act: "POST", want: true},
+		{name: "service overrides a freeze", sub: "service", obj: "/deployment-plans/freeze-override", act: "POST", want: false},
+		{name: "user overrides a freeze", sub: "user", obj: "/deployment-plans/freeze-override", act: "POST", want: false},
+		{name: "admin overrides a freeze", sub: "admin", obj: "/deployment-plans/freeze-override", act: "POST", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := enforcer.Enforce(tt.sub, tt.obj, tt.act)
+			if err != nil {
+				t.Fatalf("Enforce() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Enforce() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,4 +23,5 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/unanet/go v1.7.16
 	go.uber.org/zap v1.18.1
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
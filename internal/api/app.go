@@ -12,6 +12,7 @@ import (
 	"github.com/casbin/casbin/v2"
 	"github.com/golang-jwt/jwt"
 	"github.com/unanet/eve/internal/config"
+	"github.com/unanet/eve/internal/service"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
@@ -167,7 +168,7 @@ func (a *Api) authenticationMiddleware() func(http.Handler) http.Handler {
 			ctx := r.Context()
 			// Admin token, you shall PASS!!!
 			if jwtauth.TokenFromHeader(r) == a.adminToken {
-				next.ServeHTTP(w, r.WithContext(ctx))
+				next.ServeHTTP(w, r.WithContext(service.WithAuditUser(ctx, service.AdminAuditUser)))
 				return
 			}
 
@@ -195,6 +196,7 @@ func (a *Api) authenticationMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
+			ctx = service.WithAuditUser(ctx, extractUser(claims))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 		return http.HandlerFunc(hfn)
@@ -232,6 +234,16 @@ func extractRole(ctx context.Context, claims jwt.MapClaims) string {
 	return "unknown"
 }
 
+// extractUser is who the claims identify, it's recorded in the audit trail
+func extractUser(claims jwt.MapClaims) string {
+	for _, claim := range []string{"preferred_username", "email", "sub"} {
+		if user, ok := claims[claim].(string); ok && user != "" {
+			return user
+		}
+	}
+	return service.UnknownAuditUser
+}
+
 func checkArrayForRoles(ctx context.Context, strings []interface{}) (bool, string) {
 	if contains(strings, "admin") {
 		middleware.Log(ctx).Debug("incoming claim contains admin role")
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/unanet/eve/internal/service"
+)
+
+func TestExtractUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   string
+	}{
+		{
+			name:   "preferred username",
+			claims: jwt.MapClaims{"preferred_username": "jdoe", "email": "jdoe@example.com", "sub": "1234"},
+			want:   "jdoe",
+		},
+		{
+			name:   "email",
+			claims: jwt.MapClaims{"preferred_username": "", "email": "jdoe@example.com", "sub": "1234"},
+			want:   "jdoe@example.com",
+		},
+		{
+			name:   "subject",
+			claims: jwt.MapClaims{"sub": "1234"},
+			want:   "1234",
+		},
+		{
+			name:   "unknown",
+			claims: jwt.MapClaims{},
+			want:   "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the user is recorded with the changes the request makes through the context
+			ctx := service.WithAuditUser(context.Background(), extractUser(tt.claims))
+			if got := service.AuditRequestFromContext(ctx).User; got != tt.want {
+				t.Errorf("extractUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+)
+
+type AuditController struct {
+	manager *crud.Manager
+}
+
+func NewAuditController(manager *crud.Manager) *AuditController {
+	return &AuditController{
+		manager: manager,
+	}
+}
+
+func (c AuditController) Setup(r *Routers) {
+	r.Auth.Get("/audit", c.audit)
+}
+
+func (c AuditController) audit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := eve.AuditQuery{
+		Entity:    query.Get("entity"),
+		EntityID:  query.Get("entity_id"),
+		User:      query.Get("user"),
+		Action:    query.Get("action"),
+		RequestID: query.Get("request_id"),
+		Cursor:    query.Get("cursor"),
+	}
+
+	var err error
+	if q.From, err = parseTimeParam(r, "from"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if q.To, err = parseTimeParam(r, "to"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid limit, required int value"))
+			return
+		}
+	}
+
+	page, err := c.manager.Audit(r.Context(), q)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, page)
+}
@@ -2,30 +2,42 @@ package api
 
 import (
 	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/events"
+	"github.com/unanet/eve/internal/service/pipelines"
 	"github.com/unanet/eve/internal/service/plans"
 	"github.com/unanet/eve/internal/service/releases"
+	"github.com/unanet/eve/internal/service/webhooks"
 )
 
 func InitializeControllers(
 	deploymentPlanGenerator *plans.PlanGenerator,
 	manager *crud.Manager,
 	releaseSvc *releases.ReleaseSvc,
+	eventBus *events.Bus,
+	dispatcher *webhooks.Dispatcher,
+	runner *pipelines.Runner,
+	deploymentQueue *plans.Queue,
 ) ([]Controller, error) {
 	return []Controller{
 		NewPingController(),
 		NewArtifactController(manager),
+		NewAuditController(manager),
 		NewClusterController(manager),
 		NewDefinitionsController(manager),
-		NewDeploymentPlansController(deploymentPlanGenerator),
-		NewDeploymentsController(manager),
+		NewDeploymentPlansController(deploymentPlanGenerator, deploymentQueue),
+		NewDeploymentsController(manager, deploymentPlanGenerator, eventBus),
 		NewDeploymentsCronController(manager),
 		NewEnvironmentController(manager),
 		NewReleaseController(releaseSvc),
+		NewRolloutsController(manager, deploymentPlanGenerator),
 		NewFeedController(manager),
+		NewFreezeWindowsController(manager),
 		NewJobController(manager),
 		NewEnvironmentFeedMapController(manager),
 		NewMetadataController(manager),
 		NewNamespaceController(manager),
+		NewPipelinesController(manager, runner),
 		NewServiceController(manager),
+		NewWebhooksController(manager, dispatcher),
 	}, nil
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	goErrors "errors"
 	"net/http"
 	"strconv"
 
@@ -36,6 +37,11 @@ func (c DefinitionsController) Setup(r *Routers) {
 	r.Auth.Delete("/definitions/{definition}", c.deleteDefinition)
 	r.Auth.Get("/definitions/{definition}", c.getDefinition)
 
+	r.Auth.Get("/definitions/{definition}/history", c.definitionHistory)
+	r.Auth.Get("/definitions/{definition}/history/diff", c.diffDefinitionHistory)
+	r.Auth.Post("/definitions/{definition}/restore", c.restoreDefinition)
+	r.Auth.Post("/definitions/impact", c.definitionImpact)
+
 	r.Auth.Put("/definitions/{definition}/service-maps", c.upsertDefinitionServiceMap)
 	r.Auth.Delete("/definitions/{definition}/service-maps/{description}", c.deleteServiceDefinitionMap)
 	r.Auth.Get("/definitions/{definition}/service-maps", c.getServiceDefinitionMapsByDefinitionID)
@@ -69,7 +75,7 @@ func (c DefinitionsController) upsertDefinition(w http.ResponseWriter, r *http.R
 
 	err := c.manager.CreateDefinition(r.Context(), &m)
 	if err != nil {
-		render.Respond(w, r, err)
+		respondDefinitionError(w, r, err)
 		return
 	}
 
@@ -86,7 +92,7 @@ func (c DefinitionsController) upsertMergeDefinition(w http.ResponseWriter, r *h
 
 	err := c.manager.UpsertMergeDefinition(r.Context(), &m)
 	if err != nil {
-		render.Respond(w, r, err)
+		respondDefinitionError(w, r, err)
 		return
 	}
 
@@ -144,6 +150,111 @@ func (c DefinitionsController) getDefinition(w http.ResponseWriter, r *http.Requ
 	render.Respond(w, r, definition)
 }
 
+func (c DefinitionsController) definitionHistory(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "definition")
+	intID, err := strconv.Atoi(definitionID)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid definition route parameter, required int value"))
+		return
+	}
+
+	q := eve.DefinitionHistoryQuery{
+		DefinitionID: intID,
+		Cursor:       r.URL.Query().Get("cursor"),
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid limit, required int value"))
+			return
+		}
+	}
+
+	page, err := c.manager.DefinitionHistory(r.Context(), q)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if page.NextCursor != "" {
+		w.Header().Set(nextCursorHeader, page.NextCursor)
+	}
+	render.Respond(w, r, page.History)
+}
+
+// diffDefinitionHistory compares the from revision with the to revision, or the current data when to isn't supplied
+func (c DefinitionsController) diffDefinitionHistory(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "definition")
+	intID, err := strconv.Atoi(definitionID)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid definition route parameter, required int value"))
+		return
+	}
+
+	fromRevision, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid from query parameter, required int value"))
+		return
+	}
+
+	var toRevision int
+	if to := r.URL.Query().Get("to"); len(to) > 0 {
+		if toRevision, err = strconv.Atoi(to); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid to query parameter, required int value"))
+			return
+		}
+	}
+
+	result, err := c.manager.DiffDefinitionHistory(r.Context(), intID, fromRevision, toRevision)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c DefinitionsController) restoreDefinition(w http.ResponseWriter, r *http.Request) {
+	definitionID := chi.URLParam(r, "definition")
+	intID, err := strconv.Atoi(definitionID)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid definition route parameter, required int value"))
+		return
+	}
+
+	var m eve.DefinitionRestore
+	if err = json.ParseBody(r, &m); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	result, err := c.manager.RestoreDefinition(r.Context(), intID, m.Revision)
+	if err != nil {
+		respondDefinitionError(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+// definitionImpact previews what a change to a definition would do to the services and jobs it's mapped to, nothing
+// is saved
+func (c DefinitionsController) definitionImpact(w http.ResponseWriter, r *http.Request) {
+	var m eve.DefinitionProposal
+	if err := json.ParseBody(r, &m); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	result, err := c.manager.DefinitionImpact(r.Context(), m)
+	if err != nil {
+		respondDefinitionError(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
 func (c DefinitionsController) upsertDefinitionServiceMap(w http.ResponseWriter, r *http.Request) {
 	definitionID := chi.URLParam(r, "definition")
 	intID, err := strconv.Atoi(definitionID)
@@ -370,3 +481,14 @@ func (c DefinitionsController) definitionServiceMaps(w http.ResponseWriter, r *h
 
 	render.Respond(w, r, results)
 }
+
+// respondDefinitionError renders each schema violation instead of just the error message
+func respondDefinitionError(w http.ResponseWriter, r *http.Request, err error) {
+	var schemaErr eve.SchemaValidationError
+	if goErrors.As(err, &schemaErr) {
+		render.Status(r, schemaErr.Code)
+		render.DefaultResponder(w, r, schemaErr)
+		return
+	}
+	render.Respond(w, r, err)
+}
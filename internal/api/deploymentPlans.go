@@ -5,6 +5,7 @@ import (
 
 	"github.com/unanet/eve/internal/service/plans"
 	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
 	"github.com/unanet/go/pkg/json"
 
 	"github.com/go-chi/render"
@@ -12,16 +13,21 @@ import (
 
 type DeploymentPlansController struct {
 	planGenerator *plans.PlanGenerator
+	previewer     plans.NamespacePreviewer
 }
 
-func NewDeploymentPlansController(planGenerator *plans.PlanGenerator) *DeploymentPlansController {
+func NewDeploymentPlansController(planGenerator *plans.PlanGenerator, previewer plans.NamespacePreviewer) *DeploymentPlansController {
 	return &DeploymentPlansController{
 		planGenerator: planGenerator,
+		previewer:     previewer,
 	}
 }
 
 func (c DeploymentPlansController) Setup(r *Routers) {
 	r.Auth.Post("/deployment-plans", c.createDeploymentPlan)
+	// overriding a freeze window has its own route so it can be granted separately
+	r.Auth.Post("/deployment-plans/freeze-override", c.createFreezeOverrideDeploymentPlan)
+	r.Auth.Post("/deployment-plans/preview", c.previewDeploymentPlan)
 }
 
 func (c DeploymentPlansController) createDeploymentPlan(w http.ResponseWriter, r *http.Request) {
@@ -31,7 +37,31 @@ func (c DeploymentPlansController) createDeploymentPlan(w http.ResponseWriter, r
 		return
 	}
 
-	err := c.planGenerator.QueuePlan(r.Context(), &options)
+	if len(options.FreezeOverride) > 0 {
+		render.Respond(w, r, errors.BadRequest("freeze_override is only accepted by /deployment-plans/freeze-override"))
+		return
+	}
+
+	c.queuePlan(w, r, &options)
+}
+
+func (c DeploymentPlansController) createFreezeOverrideDeploymentPlan(w http.ResponseWriter, r *http.Request) {
+	var options eve.DeploymentPlanOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if len(options.FreezeOverride) == 0 {
+		render.Respond(w, r, errors.BadRequest("freeze_override is required"))
+		return
+	}
+
+	c.queuePlan(w, r, &options)
+}
+
+func (c DeploymentPlansController) queuePlan(w http.ResponseWriter, r *http.Request, options *eve.DeploymentPlanOptions) {
+	err := c.planGenerator.QueuePlan(r.Context(), options)
 	if err != nil {
 		render.Respond(w, r, err)
 		return
@@ -44,3 +74,19 @@ func (c DeploymentPlansController) createDeploymentPlan(w http.ResponseWriter, r
 	}
 	render.Respond(w, r, options)
 }
+
+func (c DeploymentPlansController) previewDeploymentPlan(w http.ResponseWriter, r *http.Request) {
+	var options eve.DeploymentPlanOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	preview, err := c.planGenerator.PreviewPlan(r.Context(), &options, c.previewer)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, preview)
+}
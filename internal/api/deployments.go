@@ -2,25 +2,101 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 
 	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/events"
+	"github.com/unanet/eve/internal/service/plans"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
 )
 
 type DeploymentsController struct {
-	manager *crud.Manager
+	manager       *crud.Manager
+	planGenerator *plans.PlanGenerator
+	eventBus      *events.Bus
 }
 
-func NewDeploymentsController(manager *crud.Manager) *DeploymentsController {
+func NewDeploymentsController(manager *crud.Manager, planGenerator *plans.PlanGenerator, eventBus *events.Bus) *DeploymentsController {
 	return &DeploymentsController{
-		manager: manager,
+		manager:       manager,
+		planGenerator: planGenerator,
+		eventBus:      eventBus,
 	}
 }
 
 func (c DeploymentsController) Setup(r *Routers) {
+	r.Auth.Get("/deployments", c.deployments)
 	r.Auth.Get("/deployments/{deployment}", c.deployment)
+	r.Auth.Get("/deployments/{deployment}/results", c.deploymentResults)
+	r.Auth.Post("/deployments/{deployment}/rollback", c.rollback)
+	r.Auth.Post("/deployments/{deployment}/cancel", c.cancel)
+	r.Auth.Post("/deployments/{deployment}/approve", c.approve)
+	r.Auth.Post("/deployments/{deployment}/reject", c.reject)
+	r.Auth.Get("/deployments/{deployment}/events", c.deploymentEvents)
+	r.Auth.Get("/environments/{environment}/deployment-events", c.environmentDeploymentEvents)
+}
+
+// nextCursorHeader has the cursor of the next page for the endpoints that respond with a list
+const nextCursorHeader = "X-Next-Cursor"
+
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.BadRequestf("invalid %s, must be an RFC3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+func (c DeploymentsController) deployments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := eve.DeploymentQuery{
+		Environment:   query.Get("environment"),
+		Namespace:     query.Get("namespace"),
+		User:          query.Get("user"),
+		State:         query.Get("state"),
+		CronID:        query.Get("cron"),
+		RolloutID:     query.Get("rollout"),
+		PipelineRunID: query.Get("pipeline_run"),
+		Cursor:        query.Get("cursor"),
+		Sort:          eve.DeploymentSort(query.Get("sort")),
+	}
+
+	var err error
+	if q.From, err = parseTimeParam(r, "from"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if q.To, err = parseTimeParam(r, "to"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid limit, required int value"))
+			return
+		}
+	}
+
+	page, err := c.manager.Deployments(r.Context(), q)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, page)
 }
 
 func (c DeploymentsController) deployment(w http.ResponseWriter, r *http.Request) {
@@ -34,3 +110,127 @@ func (c DeploymentsController) deployment(w http.ResponseWriter, r *http.Request
 
 	render.Respond(w, r, deployment)
 }
+
+func (c DeploymentsController) deploymentResults(w http.ResponseWriter, r *http.Request) {
+	deploymentID := chi.URLParam(r, "deployment")
+
+	results, err := c.manager.DeploymentResults(r.Context(), deploymentID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, results)
+}
+
+func (c DeploymentsController) rollback(w http.ResponseWriter, r *http.Request) {
+	deploymentID := chi.URLParam(r, "deployment")
+
+	var rollback eve.RollbackOptions
+	if err := json.ParseBody(r, &rollback); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	options, err := c.planGenerator.QueueRollback(r.Context(), deploymentID, rollback)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if len(options.Messages) > 0 {
+		render.Status(r, http.StatusPartialContent)
+	} else {
+		render.Status(r, http.StatusAccepted)
+	}
+	render.Respond(w, r, options)
+}
+
+func (c DeploymentsController) cancel(w http.ResponseWriter, r *http.Request) {
+	deploymentID := chi.URLParam(r, "deployment")
+
+	var cancel eve.CancelOptions
+	if err := json.ParseBody(r, &cancel); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	deployment, err := c.planGenerator.CancelDeployment(r.Context(), deploymentID, cancel)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, deployment)
+}
+
+func (c DeploymentsController) approve(w http.ResponseWriter, r *http.Request) {
+	deploymentID := chi.URLParam(r, "deployment")
+
+	var approval eve.ApprovalOptions
+	if err := json.ParseBody(r, &approval); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	deployment, err := c.planGenerator.ApproveDeployment(r.Context(), deploymentID, approval)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, deployment)
+}
+
+func (c DeploymentsController) reject(w http.ResponseWriter, r *http.Request) {
+	deploymentID := chi.URLParam(r, "deployment")
+
+	var approval eve.ApprovalOptions
+	if err := json.ParseBody(r, &approval); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	deployment, err := c.planGenerator.RejectDeployment(r.Context(), deploymentID, approval)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, deployment)
+}
+
+func (c DeploymentsController) deploymentEvents(w http.ResponseWriter, r *http.Request) {
+	deployment, err := c.manager.Deployment(r.Context(), chi.URLParam(r, "deployment"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	// the first event is the deployment's current state so a client that connects late knows where it's at
+	current := eve.DeploymentEvent{
+		Type:          eve.DeploymentEventForState(deployment.State),
+		DeploymentID:  deployment.ID,
+		EnvironmentID: deployment.EnvironmentID,
+		NamespaceID:   deployment.NamespaceID,
+		State:         deployment.State,
+		CreatedAt:     deployment.UpdatedAt,
+	}
+
+	streamEvents(w, r, c.eventBus, func(e eve.DeploymentEvent) bool {
+		return e.DeploymentID == deployment.ID
+	}, []eve.DeploymentEvent{current}, true)
+}
+
+func (c DeploymentsController) environmentDeploymentEvents(w http.ResponseWriter, r *http.Request) {
+	environment, err := c.manager.Environment(r.Context(), chi.URLParam(r, "environment"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	streamEvents(w, r, c.eventBus, func(e eve.DeploymentEvent) bool {
+		return e.EnvironmentID == environment.ID
+	}, nil, false)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/service/events"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	// streams are closed before the server's write timeout, the client reconnects with the Last-Event-ID header
+	// (EventSource does this for you) and picks up from where it left off
+	maxStreamDuration = 25 * time.Second
+	streamHeartbeat   = 10 * time.Second
+	streamRetryMillis = 1000
+)
+
+func writeEvent(w http.ResponseWriter, e eve.DeploymentEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, b)
+	return err
+}
+
+// streamEvents writes the events matching the filter as Server-Sent Events, initial events are written first
+// and the stream ends early when stopOnFinished is set and a finished event is written
+func streamEvents(w http.ResponseWriter, r *http.Request, bus *events.Bus, filter events.Filter, initial []eve.DeploymentEvent, stopOnFinished bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Respond(w, r, errors.NewRestError(http.StatusInternalServerError, "streaming is not supported"))
+		return
+	}
+
+	var lastEventID int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastEventID, _ = strconv.ParseInt(v, 10, 64)
+	}
+
+	sub, missed := bus.Subscribe(filter, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis); err != nil {
+		return
+	}
+
+	// when reconnecting the missed events replace the initial snapshot
+	if lastEventID > 0 {
+		initial = missed
+	}
+
+	for _, e := range initial {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+		if stopOnFinished && e.Finished() {
+			flusher.Flush()
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(maxStreamDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline.C:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+			flusher.Flush()
+			if stopOnFinished && e.Finished() {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type FreezeWindowsController struct {
+	manager *crud.Manager
+}
+
+func NewFreezeWindowsController(manager *crud.Manager) *FreezeWindowsController {
+	return &FreezeWindowsController{
+		manager: manager,
+	}
+}
+
+func (c FreezeWindowsController) Setup(r *Routers) {
+	r.Auth.Get("/freeze-windows", c.freezeWindows)
+	r.Auth.Post("/freeze-windows", c.createFreezeWindow)
+	r.Auth.Get("/freeze-windows/{window}", c.freezeWindow)
+	r.Auth.Put("/freeze-windows/{window}", c.updateFreezeWindow)
+	r.Auth.Delete("/freeze-windows/{window}", c.deleteFreezeWindow)
+}
+
+func (c FreezeWindowsController) freezeWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := c.manager.FreezeWindows(r.Context(), r.URL.Query().Get("environment"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, windows)
+}
+
+func (c FreezeWindowsController) freezeWindow(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "window"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid freeze window in route"))
+		return
+	}
+
+	window, err := c.manager.FreezeWindow(r.Context(), intID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, window)
+}
+
+func (c FreezeWindowsController) createFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	var window eve.FreezeWindow
+	if err := json.ParseBody(r, &window); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	err := c.manager.CreateFreezeWindow(r.Context(), &window)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Respond(w, r, window)
+}
+
+func (c FreezeWindowsController) updateFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "window"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid freeze window in route"))
+		return
+	}
+
+	var window eve.FreezeWindow
+	if e := json.ParseBody(r, &window); e != nil {
+		render.Respond(w, r, e)
+		return
+	}
+
+	window.ID = intID
+	rs, err := c.manager.UpdateFreezeWindow(r.Context(), &window)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, rs)
+}
+
+func (c FreezeWindowsController) deleteFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "window"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid freeze window in route"))
+		return
+	}
+
+	err = c.manager.DeleteFreezeWindow(r.Context(), intID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -30,7 +30,10 @@ func (c JobController) Setup(r *Routers) {
 	r.Auth.Post("/jobs/{job}", c.updateJob)
 	r.Auth.Delete("/jobs/{job}", c.delete)
 	r.Auth.Get("/jobs/{job}/metadata", c.getJobMetadata)
+	r.Auth.Get("/jobs/{job}/metadata/explain", c.getJobMetadataExplanation)
+	r.Auth.Get("/jobs/{job}/definitions/explain", c.getJobDefinitionExplanations)
 	r.Auth.Get("/jobs/{job}/metadata-maps", c.getJobMetadataMaps)
+	r.Auth.Get("/jobs/{job}/manifests", c.getJobManifests)
 }
 
 func (c JobController) job(w http.ResponseWriter, r *http.Request) {
@@ -83,7 +86,73 @@ func (c JobController) getJobMetadata(w http.ResponseWriter, r *http.Request) {
 		render.Respond(w, r, errors.BadRequest("invalid job route parameter, required int value"))
 		return
 	}
-	result, err := c.manager.JobMetadata(r.Context(), jobID)
+
+	at, err := parseTimeParam(r, "at")
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	var result eve.MetadataField
+	if at != nil {
+		result, err = c.manager.JobMetadataAt(r.Context(), jobID, *at)
+	} else {
+		result, err = c.manager.JobMetadata(r.Context(), jobID)
+	}
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c JobController) getJobManifests(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "job"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid job route parameter, required int value"))
+		return
+	}
+
+	format, err := manifestFormat(r)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	manifests, err := c.manager.JobManifests(r.Context(), jobID, r.URL.Query().Get("version"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	respondManifests(w, r, manifests, format)
+}
+
+func (c JobController) getJobMetadataExplanation(w http.ResponseWriter, r *http.Request) {
+	job := chi.URLParam(r, "job")
+	jobID, err := strconv.Atoi(job)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid job route parameter, required int value"))
+		return
+	}
+	result, err := c.manager.JobMetadataExplanation(r.Context(), jobID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c JobController) getJobDefinitionExplanations(w http.ResponseWriter, r *http.Request) {
+	job := chi.URLParam(r, "job")
+	jobID, err := strconv.Atoi(job)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid job route parameter, required int value"))
+		return
+	}
+	result, err := c.manager.JobDefinitionExplanations(r.Context(), jobID)
 	if err != nil {
 		render.Respond(w, r, err)
 		return
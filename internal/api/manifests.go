@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+)
+
+// manifestFormat is the format query parameter, the manifests are returned as json by default
+func manifestFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", eve.ManifestFormatJSON:
+		return eve.ManifestFormatJSON, nil
+	case eve.ManifestFormatYAML:
+		return eve.ManifestFormatYAML, nil
+	default:
+		return "", errors.BadRequestf("invalid format: %s, it must be json or yaml", format)
+	}
+}
+
+func respondManifests(w http.ResponseWriter, r *http.Request, manifests *eve.Manifests, format string) {
+	if format != eve.ManifestFormatYAML {
+		render.Respond(w, r, manifests)
+		return
+	}
+
+	b, err := manifests.YAML()
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
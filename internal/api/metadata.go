@@ -32,6 +32,10 @@ func (c MetadataController) Setup(r *Routers) {
 	r.Auth.Delete("/metadata/{metadata}", c.deleteMetadata)
 	r.Auth.Get("/metadata/{metadata}", c.getMetadata)
 
+	r.Auth.Get("/metadata/{metadata}/history/diff", c.diffMetadataHistory)
+	r.Auth.Post("/metadata/{metadata}/restore", c.restoreMetadata)
+	r.Auth.Post("/metadata/impact", c.metadataImpact)
+
 	r.Auth.Get("/metadata/job-maps", c.metadataJobMaps)
 	r.Auth.Put("/metadata/job-maps", c.updateMetadataJobMap)
 	r.Auth.Post("/metadata/job-maps", c.createMetadataJobMap)
@@ -81,15 +85,113 @@ func (c MetadataController) upsertMergeMetadata(w http.ResponseWriter, r *http.R
 }
 
 func (c MetadataController) metadataHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := eve.MetadataHistoryQuery{
+		Metadata: query.Get("metadata"),
+		Cursor:   query.Get("cursor"),
+	}
 
-	results, err := c.manager.MetadataHistory(r.Context())
+	var err error
+	if q.From, err = parseTimeParam(r, "from"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if q.To, err = parseTimeParam(r, "to"); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
 
+	if limit := query.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid limit, required int value"))
+			return
+		}
+	}
+
+	page, err := c.manager.MetadataHistory(r.Context(), q)
 	if err != nil {
 		render.Respond(w, r, err)
 		return
 	}
 
-	render.Respond(w, r, results)
+	if page.NextCursor != "" {
+		w.Header().Set(nextCursorHeader, page.NextCursor)
+	}
+	render.Respond(w, r, page.History)
+}
+
+// diffMetadataHistory compares the from revision with the to revision, or the current value when to isn't supplied
+func (c MetadataController) diffMetadataHistory(w http.ResponseWriter, r *http.Request) {
+	metadataID := chi.URLParam(r, "metadata")
+	intID, err := strconv.Atoi(metadataID)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid metadata route parameter, required int value"))
+		return
+	}
+
+	fromRevision, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid from query parameter, required int value"))
+		return
+	}
+
+	var toRevision int
+	if to := r.URL.Query().Get("to"); len(to) > 0 {
+		if toRevision, err = strconv.Atoi(to); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid to query parameter, required int value"))
+			return
+		}
+	}
+
+	result, err := c.manager.DiffMetadataHistory(r.Context(), intID, fromRevision, toRevision)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c MetadataController) restoreMetadata(w http.ResponseWriter, r *http.Request) {
+	metadataID := chi.URLParam(r, "metadata")
+	intID, err := strconv.Atoi(metadataID)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid metadata route parameter, required int value"))
+		return
+	}
+
+	var m eve.MetadataRestore
+	if err = json.ParseBody(r, &m); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	result, err := c.manager.RestoreMetadata(r.Context(), intID, m.Time)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+// metadataImpact previews what a change to a metadata document would do to the services and jobs it's mapped to,
+// nothing is saved
+func (c MetadataController) metadataImpact(w http.ResponseWriter, r *http.Request) {
+	var m eve.MetadataProposal
+	if err := json.ParseBody(r, &m); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	result, err := c.manager.MetadataImpact(r.Context(), m)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
 }
 
 func (c MetadataController) upsertMetadata(w http.ResponseWriter, r *http.Request) {
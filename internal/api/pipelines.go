@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/pipelines"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type PipelinesController struct {
+	manager *crud.Manager
+	runner  *pipelines.Runner
+}
+
+func NewPipelinesController(manager *crud.Manager, runner *pipelines.Runner) *PipelinesController {
+	return &PipelinesController{
+		manager: manager,
+		runner:  runner,
+	}
+}
+
+func (c PipelinesController) Setup(r *Routers) {
+	r.Auth.Get("/pipelines", c.pipelines)
+	r.Auth.Post("/pipelines", c.createPipeline)
+	r.Auth.Get("/pipelines/{pipeline}", c.pipeline)
+	r.Auth.Put("/pipelines/{pipeline}", c.updatePipeline)
+	r.Auth.Delete("/pipelines/{pipeline}", c.deletePipeline)
+	r.Auth.Post("/pipelines/{pipeline}/runs", c.startRun)
+
+	r.Auth.Get("/pipeline-runs", c.runs)
+	r.Auth.Get("/pipeline-runs/{run}", c.run)
+	r.Auth.Post("/pipeline-runs/{run}/approve", c.approveRun)
+	r.Auth.Post("/pipeline-runs/{run}/cancel", c.cancelRun)
+}
+
+func (c PipelinesController) pipelines(w http.ResponseWriter, r *http.Request) {
+	results, err := c.manager.Pipelines(r.Context())
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, results)
+}
+
+func (c PipelinesController) pipeline(w http.ResponseWriter, r *http.Request) {
+	pipeline, err := c.manager.Pipeline(r.Context(), chi.URLParam(r, "pipeline"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, pipeline)
+}
+
+func (c PipelinesController) createPipeline(w http.ResponseWriter, r *http.Request) {
+	var pipeline eve.Pipeline
+	if err := json.ParseBody(r, &pipeline); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	err := c.manager.CreatePipeline(r.Context(), &pipeline)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Respond(w, r, pipeline)
+}
+
+func (c PipelinesController) updatePipeline(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "pipeline"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid pipeline in route"))
+		return
+	}
+
+	var pipeline eve.Pipeline
+	if e := json.ParseBody(r, &pipeline); e != nil {
+		render.Respond(w, r, e)
+		return
+	}
+
+	pipeline.ID = intID
+	rs, err := c.manager.UpdatePipeline(r.Context(), &pipeline)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, rs)
+}
+
+func (c PipelinesController) deletePipeline(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "pipeline"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid pipeline in route"))
+		return
+	}
+
+	err = c.manager.DeletePipeline(r.Context(), intID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+func (c PipelinesController) startRun(w http.ResponseWriter, r *http.Request) {
+	pipeline, err := c.manager.Pipeline(r.Context(), chi.URLParam(r, "pipeline"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	var options eve.PipelineRunOptions
+	if e := json.ParseBody(r, &options); e != nil {
+		render.Respond(w, r, e)
+		return
+	}
+
+	run, err := c.runner.StartRun(r.Context(), pipeline, options)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, run)
+}
+
+func (c PipelinesController) runs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	runs, err := c.manager.PipelineRuns(r.Context(), query.Get("pipeline"), query.Get("state"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, runs)
+}
+
+func (c PipelinesController) run(w http.ResponseWriter, r *http.Request) {
+	run, err := c.manager.PipelineRun(r.Context(), chi.URLParam(r, "run"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, run)
+}
+
+func (c PipelinesController) approveRun(w http.ResponseWriter, r *http.Request) {
+	var options eve.ApprovalOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	run, err := c.runner.Approve(r.Context(), chi.URLParam(r, "run"), options)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, run)
+}
+
+func (c PipelinesController) cancelRun(w http.ResponseWriter, r *http.Request) {
+	var options eve.CancelOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	run, err := c.runner.Cancel(r.Context(), chi.URLParam(r, "run"), options)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, run)
+}
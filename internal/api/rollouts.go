@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/plans"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/json"
+)
+
+type RolloutsController struct {
+	manager       *crud.Manager
+	planGenerator *plans.PlanGenerator
+}
+
+func NewRolloutsController(manager *crud.Manager, planGenerator *plans.PlanGenerator) *RolloutsController {
+	return &RolloutsController{
+		manager:       manager,
+		planGenerator: planGenerator,
+	}
+}
+
+func (c RolloutsController) Setup(r *Routers) {
+	r.Auth.Get("/rollouts/{rollout}", c.rollout)
+	r.Auth.Post("/rollouts/{rollout}/promote", c.promote)
+	r.Auth.Post("/rollouts/{rollout}/abort", c.abort)
+}
+
+func (c RolloutsController) rollout(w http.ResponseWriter, r *http.Request) {
+	rollout, err := c.manager.Rollout(r.Context(), chi.URLParam(r, "rollout"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, rollout)
+}
+
+func (c RolloutsController) promote(w http.ResponseWriter, r *http.Request) {
+	rolloutID := chi.URLParam(r, "rollout")
+
+	var options eve.RolloutOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if err := c.planGenerator.PromoteRollout(r.Context(), rolloutID, options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	c.respondAccepted(w, r, rolloutID)
+}
+
+func (c RolloutsController) abort(w http.ResponseWriter, r *http.Request) {
+	rolloutID := chi.URLParam(r, "rollout")
+
+	var options eve.RolloutOptions
+	if err := json.ParseBody(r, &options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	if err := c.planGenerator.AbortRollout(r.Context(), rolloutID, options); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	c.respondAccepted(w, r, rolloutID)
+}
+
+func (c RolloutsController) respondAccepted(w http.ResponseWriter, r *http.Request, rolloutID string) {
+	rollout, err := c.manager.Rollout(r.Context(), rolloutID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, rollout)
+}
@@ -30,9 +30,15 @@ func (c ServiceController) Setup(r *Routers) {
 	r.Auth.Post("/services/{service}", c.updateService)
 	r.Auth.Delete("/services/{service}", c.delete)
 	r.Auth.Get("/services/{service}/metadata", c.getServiceMetadata)
+	r.Auth.Get("/services/{service}/metadata/explain", c.getServiceMetadataExplanation)
 	r.Auth.Get("/services/{service}/metadata-maps", c.getServiceMetadataMaps)
 	r.Auth.Get("/services/{service}/definitions", c.getServiceDefinitionResult)
+	r.Auth.Get("/services/{service}/definitions/explain", c.getServiceDefinitionExplanations)
 	r.Auth.Get("/services/{service}/definition-maps", c.getServiceDefinitions)
+	r.Auth.Get("/services/{service}/manifests", c.getServiceManifests)
+	r.Auth.Get("/services/{service}/dependencies", c.getServiceDependencies)
+	r.Auth.Post("/services/{service}/dependencies", c.createServiceDependency)
+	r.Auth.Delete("/services/{service}/dependencies/{dependency}", c.deleteServiceDependency)
 }
 
 func (c ServiceController) service(w http.ResponseWriter, r *http.Request) {
@@ -85,7 +91,35 @@ func (c ServiceController) getServiceMetadata(w http.ResponseWriter, r *http.Req
 		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
 		return
 	}
-	result, err := c.manager.ServiceMetadata(r.Context(), serviceID)
+
+	at, err := parseTimeParam(r, "at")
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	var result eve.MetadataField
+	if at != nil {
+		result, err = c.manager.ServiceMetadataAt(r.Context(), serviceID, *at)
+	} else {
+		result, err = c.manager.ServiceMetadata(r.Context(), serviceID)
+	}
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c ServiceController) getServiceMetadataExplanation(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	serviceID, err := strconv.Atoi(service)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+	result, err := c.manager.ServiceMetadataExplanation(r.Context(), serviceID)
 	if err != nil {
 		render.Respond(w, r, err)
 		return
@@ -126,6 +160,44 @@ func (c ServiceController) getServiceDefinitionResult(w http.ResponseWriter, r *
 	render.Respond(w, r, result)
 }
 
+func (c ServiceController) getServiceDefinitionExplanations(w http.ResponseWriter, r *http.Request) {
+	service := chi.URLParam(r, "service")
+	serviceID, err := strconv.Atoi(service)
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+	result, err := c.manager.ServiceDefinitionExplanations(r.Context(), serviceID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c ServiceController) getServiceManifests(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := strconv.Atoi(chi.URLParam(r, "service"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+
+	format, err := manifestFormat(r)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	manifests, err := c.manager.ServiceManifests(r.Context(), serviceID, r.URL.Query().Get("version"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	respondManifests(w, r, manifests, format)
+}
+
 func (c ServiceController) getServiceDefinitions(w http.ResponseWriter, r *http.Request) {
 	service := chi.URLParam(r, "service")
 	serviceID, err := strconv.Atoi(service)
@@ -186,3 +258,63 @@ func (c ServiceController) delete(w http.ResponseWriter, r *http.Request) {
 
 	render.Status(r, http.StatusNoContent)
 }
+
+func (c ServiceController) getServiceDependencies(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := strconv.Atoi(chi.URLParam(r, "service"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+
+	result, err := c.manager.ServiceDependencies(r.Context(), serviceID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, result)
+}
+
+func (c ServiceController) createServiceDependency(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := strconv.Atoi(chi.URLParam(r, "service"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+
+	var m eve.ServiceDependency
+	if iErr := json.ParseBody(r, &m); iErr != nil {
+		render.Respond(w, r, iErr)
+		return
+	}
+
+	m.ServiceID = serviceID
+	if err = c.manager.CreateServiceDependency(r.Context(), &m); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Respond(w, r, m)
+}
+
+func (c ServiceController) deleteServiceDependency(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := strconv.Atoi(chi.URLParam(r, "service"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid service route parameter, required int value"))
+		return
+	}
+
+	dependencyID, err := strconv.Atoi(chi.URLParam(r, "dependency"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid dependency route parameter, required int value"))
+		return
+	}
+
+	if err = c.manager.DeleteServiceDependency(r.Context(), serviceID, dependencyID); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/internal/service/webhooks"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type WebhooksController struct {
+	manager    *crud.Manager
+	dispatcher *webhooks.Dispatcher
+}
+
+func NewWebhooksController(manager *crud.Manager, dispatcher *webhooks.Dispatcher) *WebhooksController {
+	return &WebhooksController{
+		manager:    manager,
+		dispatcher: dispatcher,
+	}
+}
+
+func (c WebhooksController) Setup(r *Routers) {
+	r.Auth.Get("/webhooks", c.webhooks)
+	r.Auth.Post("/webhooks", c.createWebhook)
+	r.Auth.Get("/webhooks/{webhook}", c.webhook)
+	r.Auth.Put("/webhooks/{webhook}", c.updateWebhook)
+	r.Auth.Delete("/webhooks/{webhook}", c.deleteWebhook)
+	r.Auth.Get("/webhook-deliveries", c.deliveries)
+	r.Auth.Get("/webhook-deliveries/{delivery}", c.delivery)
+	r.Auth.Post("/webhook-deliveries/{delivery}/redeliver", c.redeliver)
+}
+
+func (c WebhooksController) webhooks(w http.ResponseWriter, r *http.Request) {
+	list, err := c.manager.Webhooks(r.Context())
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, list)
+}
+
+func (c WebhooksController) webhook(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "webhook"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid webhook in route"))
+		return
+	}
+
+	webhook, err := c.manager.Webhook(r.Context(), intID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, webhook)
+}
+
+func (c WebhooksController) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook eve.Webhook
+	if err := json.ParseBody(r, &webhook); err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	err := c.manager.CreateWebhook(r.Context(), &webhook)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Respond(w, r, webhook)
+}
+
+func (c WebhooksController) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "webhook"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid webhook in route"))
+		return
+	}
+
+	var webhook eve.Webhook
+	if e := json.ParseBody(r, &webhook); e != nil {
+		render.Respond(w, r, e)
+		return
+	}
+
+	webhook.ID = intID
+	rs, err := c.manager.UpdateWebhook(r.Context(), &webhook)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, rs)
+}
+
+func (c WebhooksController) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	intID, err := strconv.Atoi(chi.URLParam(r, "webhook"))
+	if err != nil {
+		render.Respond(w, r, errors.BadRequest("invalid webhook in route"))
+		return
+	}
+
+	err = c.manager.DeleteWebhook(r.Context(), intID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+func (c WebhooksController) deliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := eve.WebhookDeliveryQuery{
+		DeploymentID: query.Get("deployment"),
+		WebhookID:    query.Get("webhook"),
+		State:        query.Get("state"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			render.Respond(w, r, errors.BadRequest("invalid limit, required int value"))
+			return
+		}
+	}
+
+	deliveries, err := c.manager.WebhookDeliveries(r.Context(), q)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, deliveries)
+}
+
+func (c WebhooksController) delivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := c.manager.WebhookDelivery(r.Context(), chi.URLParam(r, "delivery"))
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, delivery)
+}
+
+func (c WebhooksController) redeliver(w http.ResponseWriter, r *http.Request) {
+	deliveryID := chi.URLParam(r, "delivery")
+
+	err := c.dispatcher.Redeliver(r.Context(), deliveryID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	delivery, err := c.manager.WebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		render.Respond(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, delivery)
+}
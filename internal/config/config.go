@@ -23,6 +23,9 @@ var (
 	mutex      = sync.Mutex{}
 )
 
+// defaultQueueName is the api queue's name for the memory and postgres backends when API_Q_URL isn't set
+const defaultQueueName = "eve-api"
+
 type LogConfig = log.Config
 type ArtifactoryConfig = artifactory.Config
 type GitLabConfig = gitlab.Config
@@ -55,16 +58,22 @@ type Config struct {
 	GitHubConfig
 	Identity 			   IdentityValidatorConfig
 	LocalDev 			   bool          `envconfig:"LOCAL_DEV" default:"false"`
-	ApiQUrl                string        `envconfig:"API_Q_URL" required:"true"`
+	ApiQUrl                string        `envconfig:"API_Q_URL"`
+	QueueBackend           string        `envconfig:"QUEUE_BACKEND" default:"sqs"`
 	SourceControlProvider  string        `envconfig:"SCM_PROVIDER" default:"gitlab"`
 	ApiQWaitTimeSecond     int64         `envconfig:"API_Q_WAIT_TIME_SECOND" default:"20"`
 	ApiQVisibilityTimeout  int64         `envconfig:"API_Q_VISIBILITY_TIMEOUT" default:"3600"`
 	ApiQMaxNumberOfMessage int64         `envconfig:"API_Q_MAX_NUMBER_OF_MESSAGE" default:"10"`
 	ApiQWorkerTimeout      time.Duration `envconfig:"API_Q_WORKER_TIMEOUT" default:"60s"`
 	CronTimeout            time.Duration `envconfig:"CRON_TIMEOUT" default:"120s"`
+	DeploymentTimeout      time.Duration `envconfig:"DEPLOYMENT_TIMEOUT" default:"45m"`
 	HttpCallbackTimeout    time.Duration `envconfig:"HTTP_CALLBACK_TIMEOUT" default:"8s"`
-	S3Bucket               string        `envconfig:"S3_BUCKET" required:"true"`
-	AWSRegion              string        `envconfig:"AWS_REGION" required:"true"`
+	WebhookSecret          string        `envconfig:"WEBHOOK_SECRET"`
+	S3Bucket               string        `envconfig:"S3_BUCKET"`
+	AWSRegion              string        `envconfig:"AWS_REGION"`
+	PlanStorage            string        `envconfig:"PLAN_STORAGE" default:"s3"`
+	PlanStorageDir         string        `envconfig:"PLAN_STORAGE_DIR" default:"/tmp/eve/plans"`
+	PlanInlineLimit        int           `envconfig:"PLAN_INLINE_LIMIT" default:"0"`
 	Port                   int           `envconfig:"PORT" default:"8080"`
 	MetricsPort            int           `envconfig:"METRICS_PORT" default:"3001"`
 	ServiceName            string        `envconfig:"SERVICE_NAME" default:"eve"`
@@ -103,10 +112,26 @@ func GetConfig() Config {
 	if err != nil {
 		log.Logger.Panic("Unable to Load Config", zap.Error(err))
 	}
+	if err = c.setQueueURL(); err != nil {
+		log.Logger.Panic("Unable to Load Config", zap.Error(err))
+	}
 	config = &c
 	return *config
 }
 
+// setQueueURL requires the api queue url for sqs, the memory and postgres backends only use it as the queue's name
+// so it defaults to defaultQueueName
+func (c *Config) setQueueURL() error {
+	if len(c.ApiQUrl) > 0 {
+		return nil
+	}
+	if c.QueueBackend == "sqs" {
+		return fmt.Errorf("required key EVE_API_Q_URL missing value, it's required for the sqs queue backend")
+	}
+	c.ApiQUrl = defaultQueueName
+	return nil
+}
+
 func GetFlagsConfig() FlagConfig {
 	mutex.Lock()
 	defer mutex.Unlock()
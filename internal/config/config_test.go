@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestConfig_setQueueURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{name: "sqs", config: Config{QueueBackend: "sqs", ApiQUrl: "https://sqs/eve-api"}, want: "https://sqs/eve-api"},
+		{name: "sqs without a url", config: Config{QueueBackend: "sqs"}, wantErr: true},
+		{name: "memory", config: Config{QueueBackend: "memory"}, want: defaultQueueName},
+		{name: "postgres", config: Config{QueueBackend: "postgres"}, want: defaultQueueName},
+		{name: "postgres with a name", config: Config{QueueBackend: "postgres", ApiQUrl: "eve-api-int"}, want: "eve-api-int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.setQueueURL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setQueueURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.config.ApiQUrl != tt.want {
+				t.Errorf("setQueueURL() = %v, want %v", tt.config.ApiQUrl, tt.want)
+			}
+		})
+	}
+}
@@ -22,7 +22,7 @@ type Artifacts []Artifact
 func (r *Repo) ArtifactByName(ctx context.Context, name string) (*Artifact, error) {
 	var artifact Artifact
 
-	row := r.db.QueryRowxContext(ctx, "select * from artifact where name = $1", name)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from artifact where name = $1", name)
 	err := row.StructScan(&artifact)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -37,7 +37,7 @@ func (r *Repo) ArtifactByName(ctx context.Context, name string) (*Artifact, erro
 func (r *Repo) ArtifactByID(ctx context.Context, id int) (*Artifact, error) {
 	var artifact Artifact
 
-	row := r.db.QueryRowxContext(ctx, "select * from artifact where id = $1", id)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from artifact where id = $1", id)
 	err := row.StructScan(&artifact)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -51,7 +51,7 @@ func (r *Repo) ArtifactByID(ctx context.Context, id int) (*Artifact, error) {
 
 func (r *Repo) ArtifactsByProvider(ctx context.Context, provider string) (Artifacts, error) {
 
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select a.id,
 		       a.name,
 		       a.feed_type,
@@ -82,7 +82,7 @@ func (r *Repo) ArtifactsByProvider(ctx context.Context, provider string) (Artifa
 }
 
 func (r *Repo) Artifact(ctx context.Context) ([]Artifact, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select
 			id,
 			name,
@@ -117,7 +117,7 @@ func (r *Repo) Artifact(ctx context.Context) ([]Artifact, error) {
 
 func (r *Repo) CreateArtifact(ctx context.Context, art *Artifact) error {
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO artifact (
 		 id, 
 		 name, 
@@ -146,7 +146,7 @@ func (r *Repo) CreateArtifact(ctx context.Context, art *Artifact) error {
 
 func (r *Repo) UpdateArtifact(ctx context.Context, model *Artifact) error {
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update artifact set 
 			name = $2,
 			feed_type = $3,
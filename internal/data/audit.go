@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type AuditEntry struct {
+	ID        int            `db:"id"`
+	Entity    string         `db:"entity"`
+	EntityID  string         `db:"entity_id"`
+	Action    string         `db:"action"`
+	User      string         `db:"user"`
+	RequestID sql.NullString `db:"request_id"`
+	Method    sql.NullString `db:"method"`
+	Route     sql.NullString `db:"route"`
+	Before    json.Object    `db:"before"`
+	After     json.Object    `db:"after"`
+	CreatedAt sql.NullTime   `db:"created_at"`
+}
+
+func (r *Repo) CreateAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into audit(entity, entity_id, action, "user", request_id, method, route, before, after, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		returning id, created_at
+	`,
+		entry.Entity,
+		entry.EntityID,
+		entry.Action,
+		entry.User,
+		entry.RequestID,
+		entry.Method,
+		entry.Route,
+		entry.Before,
+		entry.After,
+		now).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+// AuditEntries returns the entries that match the where args, the newest first
+func (r *Repo) AuditEntries(ctx context.Context, limit int, whereArgs ...WhereArg) ([]AuditEntry, error) {
+	esql, args := CheckWhereArgs(`
+		SELECT 
+			id,
+			entity,
+			entity_id,
+			action,
+			"user",
+			request_id,
+			method,
+			route,
+			before,
+			after,
+			created_at
+		FROM audit`, whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s ORDER BY id DESC LIMIT %d", esql, limit), args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		if rows.Err() != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		var e AuditEntry
+		err = rows.StructScan(&e)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
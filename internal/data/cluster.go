@@ -23,7 +23,7 @@ type Clusters []Cluster
 func (r *Repo) ClusterByID(ctx context.Context, id int) (*Cluster, error) {
 	var cluster Cluster
 
-	row := r.db.QueryRowxContext(ctx, "select * from cluster where id = $1", id)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from cluster where id = $1", id)
 	err := row.StructScan(&cluster)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -37,7 +37,7 @@ func (r *Repo) ClusterByID(ctx context.Context, id int) (*Cluster, error) {
 
 func (r *Repo) ClustersByProvider(ctx context.Context, provider string) (Artifacts, error) {
 
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select c.id,
 		       c.name,
 		       c.sch_queue_url,
@@ -73,7 +73,7 @@ func (r *Repo) CreateCluster(ctx context.Context, model *Cluster) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO cluster(id, name, provider_group, sch_queue_url, created_at)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at
@@ -88,7 +88,7 @@ func (r *Repo) CreateCluster(ctx context.Context, model *Cluster) error {
 }
 
 func (r *Repo) Clusters(ctx context.Context) ([]Cluster, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select
 			id,
 			name,
@@ -127,7 +127,7 @@ func (r *Repo) UpdateCluster(ctx context.Context, model *Cluster) error {
 		Valid: true,
 	}
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update cluster set 
 			name = $2,
 		    provider_group = $3,
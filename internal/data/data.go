@@ -100,7 +100,7 @@ func (r *Repo) deleteByIDWithField(ctx context.Context, tableName string, fieldN
 }
 
 func (r *Repo) deleteWithQuery(ctx context.Context, tableName string, query string) error {
-	result, err := r.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, query))
+	result, err := r.conn(ctx).ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, query))
 	if err != nil {
 		return errors.Wrap(err)
 	}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type DefinitionHistory struct {
+	Revision     int          `db:"revision"`
+	DefinitionID int          `db:"definition_id"`
+	Description  string       `db:"description"`
+	Data         json.Object  `db:"data"`
+	Created      sql.NullTime `db:"created"`
+	CreatedBy    string       `db:"created_by"`
+	Deleted      sql.NullTime `db:"deleted"`
+	DeletedBy    *string      `db:"deleted_by"`
+}
+
+const definitionHistorySelect = `
+		SELECT 
+			revision,
+			definition_id,
+			description,
+			data,
+			created,
+			created_by,
+			deleted,
+			deleted_by
+		FROM definition_history`
+
+// DefinitionHistory returns the revisions that match the where args, the newest first
+func (r *Repo) DefinitionHistory(ctx context.Context, limit int, whereArgs ...WhereArg) ([]DefinitionHistory, error) {
+	esql, args := CheckWhereArgs(definitionHistorySelect, whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s ORDER BY revision DESC LIMIT %d", esql, limit), args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var dd []DefinitionHistory
+	for rows.Next() {
+		if rows.Err() != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		var d DefinitionHistory
+		err = rows.StructScan(&d)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		dd = append(dd, d)
+	}
+
+	return dd, nil
+}
+
+func (r *Repo) DefinitionHistoryByRevision(ctx context.Context, definitionID int, revision int) (*DefinitionHistory, error) {
+	var history DefinitionHistory
+
+	row := r.conn(ctx).QueryRowxContext(ctx, definitionHistorySelect+`
+		WHERE definition_id = $1 AND revision = $2`, definitionID, revision)
+	err := row.StructScan(&history)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("revision: %d of definition with id: %d not found", revision, definitionID)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &history, nil
+}
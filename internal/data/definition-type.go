@@ -3,8 +3,11 @@ package data
 import (
 	"context"
 	"database/sql"
-	"github.com/unanet/go/pkg/errors"
+	goErrors "errors"
 	"time"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
 )
 
 type DefinitionType struct {
@@ -17,10 +20,11 @@ type DefinitionType struct {
 	Version         string       `db:"version"`
 	Kind            string       `db:"kind"`
 	DefinitionOrder string       `db:"definition_order"`
+	Schema          json.Object  `db:"schema"`
 }
 
 func (r *Repo) DefinitionTypes(ctx context.Context) ([]DefinitionType, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			id,
 			name,
@@ -30,7 +34,8 @@ func (r *Repo) DefinitionTypes(ctx context.Context) ([]DefinitionType, error) {
 			class,
 			version,
 			kind,
-			definition_order
+			definition_order,
+			schema
 		from definition_type`)
 	if err != nil {
 		return nil, errors.Wrap(err)
@@ -54,13 +59,41 @@ func (r *Repo) DefinitionTypes(ctx context.Context) ([]DefinitionType, error) {
 	return ss, nil
 }
 
+func (r *Repo) DefinitionTypeByID(ctx context.Context, id int) (*DefinitionType, error) {
+	var definitionType DefinitionType
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		select 
+			id,
+			name,
+			description,
+			created_at,
+			updated_at,
+			class,
+			version,
+			kind,
+			definition_order,
+			schema
+		from definition_type
+		where id = $1`, id)
+	err := row.StructScan(&definitionType)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("definition type with id: %d, not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &definitionType, nil
+}
+
 func (r *Repo) CreateDefinitionType(ctx context.Context, model *DefinitionType) error {
 	model.CreatedAt.Time = time.Now().UTC()
 	model.CreatedAt.Valid = true
 
-	err := r.db.QueryRowxContext(ctx, `
-	INSERT INTO definition_type(name, description, class, version, kind, definition_order, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+	INSERT INTO definition_type(name, description, class, version, kind, definition_order, schema, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	RETURNING id
 	`,
 		model.Name,
@@ -69,6 +102,7 @@ func (r *Repo) CreateDefinitionType(ctx context.Context, model *DefinitionType)
 		model.Version,
 		model.Kind,
 		model.DefinitionOrder,
+		model.Schema,
 		model.CreatedAt).
 		StructScan(model)
 
@@ -83,7 +117,7 @@ func (r *Repo) UpdateDefinitionType(ctx context.Context, m *DefinitionType) erro
 	m.UpdatedAt.Time = time.Now().UTC()
 	m.UpdatedAt.Valid = true
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update definition_type set 
 			name = $2,
 			description = $3, 
@@ -91,7 +125,8 @@ func (r *Repo) UpdateDefinitionType(ctx context.Context, m *DefinitionType) erro
 			version = $5, 
 			kind = $6,
 			definition_order = $7,
-			updated_at = $8
+			schema = $8,
+			updated_at = $9
 		where id = $1
 		RETURNING created_at
 	`,
@@ -102,6 +137,7 @@ func (r *Repo) UpdateDefinitionType(ctx context.Context, m *DefinitionType) erro
 		m.Version,
 		m.Kind,
 		m.DefinitionOrder,
+		m.Schema,
 		m.UpdatedAt,
 	)
 	if err != nil {
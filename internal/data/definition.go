@@ -97,7 +97,7 @@ func (r *Repo) UpsertMergeDefinition(ctx context.Context, def *Definition) error
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO definition(description, definition_type_id, data, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (description)
@@ -124,7 +124,7 @@ func (r *Repo) UpsertDefinition(ctx context.Context, def *Definition) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
 	INSERT INTO definition(description, definition_type_id,  data, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5)
@@ -153,7 +153,7 @@ func (r *Repo) UpsertDefinitionJobMap(ctx context.Context, djm *DefinitionJobMap
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 
 	INSERT INTO definition_job_map(description, definition_id, environment_id, artifact_id, namespace_id, job_id, cluster_id, stacking_order, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
@@ -182,7 +182,7 @@ func (r *Repo) UpsertDefinitionServiceMap(ctx context.Context, dsm *DefinitionSe
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
 	INSERT INTO definition_service_map(description, definition_id, environment_id, artifact_id, namespace_id, service_id, cluster_id, stacking_order, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
@@ -203,7 +203,7 @@ func (r *Repo) UpsertDefinitionServiceMap(ctx context.Context, dsm *DefinitionSe
 func (r *Repo) GetDefinition(ctx context.Context, definitionID int) (*Definition, error) {
 	var definition Definition
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id, 
 		       description, 
 		       definition_type_id,
@@ -227,7 +227,7 @@ func (r *Repo) GetDefinition(ctx context.Context, definitionID int) (*Definition
 func (r *Repo) GetDefinitionByDescription(ctx context.Context, description string) (*Definition, error) {
 	var definition Definition
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id, 
 		       description, 
 		       definition_type_id,
@@ -249,7 +249,7 @@ func (r *Repo) GetDefinitionByDescription(ctx context.Context, description strin
 }
 
 func (r *Repo) Definition(ctx context.Context) ([]Definition, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select id, 
 		       description, 
 		       definition_type_id,
@@ -282,7 +282,7 @@ func (r *Repo) Definition(ctx context.Context) ([]Definition, error) {
 
 func (r *Repo) DeleteDefinitionKey(ctx context.Context, definitionID int, key string) (*Definition, error) {
 	var definition Definition
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 		UPDATE definition SET data = definition.data - $1 WHERE id = $2
 		RETURNING id, data, description,definition_type_id, created_at, updated_at
 	`, key, definitionID).StructScan(&definition)
@@ -294,7 +294,7 @@ func (r *Repo) DeleteDefinitionKey(ctx context.Context, definitionID int, key st
 }
 
 func (r *Repo) DeleteDefinition(ctx context.Context, definitionID int) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM definition WHERE id = $1
 	`, definitionID)
 	if err != nil {
@@ -314,7 +314,7 @@ func (r *Repo) DeleteDefinition(ctx context.Context, definitionID int) error {
 }
 
 func (r *Repo) DeleteDefinitionJobMap(ctx context.Context, definitionID int, mapDescription string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM definition_job_map WHERE definition_id = $1 AND description = $2
 	`, definitionID, mapDescription)
 	if err != nil {
@@ -334,7 +334,7 @@ func (r *Repo) DeleteDefinitionJobMap(ctx context.Context, definitionID int, map
 }
 
 func (r *Repo) DeleteDefinitionServiceMap(ctx context.Context, definitionID int, mapDescription string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM definition_service_map WHERE definition_id = $1 AND description = $2
 	`, definitionID, mapDescription)
 	if err != nil {
@@ -354,7 +354,7 @@ func (r *Repo) DeleteDefinitionServiceMap(ctx context.Context, definitionID int,
 }
 
 func (r *Repo) JobDefinitionMapsByJobID(ctx context.Context, jobID int) ([]DefinitionJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       definition_id, 
 		       environment_id, 
@@ -391,7 +391,7 @@ func (r *Repo) JobDefinitionMapsByJobID(ctx context.Context, jobID int) ([]Defin
 }
 
 func (r *Repo) DefinitionJobMaps(ctx context.Context) ([]DefinitionJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select
 			description,
 			definition_id,
@@ -428,7 +428,7 @@ func (r *Repo) DefinitionJobMaps(ctx context.Context) ([]DefinitionJobMap, error
 }
 
 func (r *Repo) DefinitionServiceMaps(ctx context.Context) ([]DefinitionServiceMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			description,
 			definition_id,
@@ -464,7 +464,7 @@ func (r *Repo) DefinitionServiceMaps(ctx context.Context) ([]DefinitionServiceMa
 }
 
 func (r *Repo) JobDefinitionMapsByDefinitionID(ctx context.Context, definitionID int) ([]DefinitionJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       definition_id, 
 		       environment_id, 
@@ -501,7 +501,7 @@ func (r *Repo) JobDefinitionMapsByDefinitionID(ctx context.Context, definitionID
 }
 
 func (r *Repo) ServiceDefinitionMapsByDefinitionID(ctx context.Context, definitionID int) ([]DefinitionServiceMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       definition_id, 
 		       environment_id, 
@@ -538,7 +538,7 @@ func (r *Repo) ServiceDefinitionMapsByDefinitionID(ctx context.Context, definiti
 }
 
 func (r *Repo) JobDefinition(ctx context.Context, jobID int) ([]DefinitionJob, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		WITH env_data AS (
 			select j.id as job_id, 
 			       environment_id, 
@@ -614,7 +614,7 @@ func (r *Repo) JobDefinition(ctx context.Context, jobID int) ([]DefinitionJob, e
 }
 
 func (r *Repo) ServiceDefinition(ctx context.Context, serviceID int) ([]DefinitionService, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		WITH env_data AS (
 			select s.id as service_id, 
 			       environment_id, 
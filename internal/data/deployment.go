@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	goErrors "errors"
+	"fmt"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
@@ -18,25 +19,66 @@ const (
 	DeploymentStateQueued    DeploymentState = "queued"
 	DeploymentStateScheduled DeploymentState = "scheduled"
 	DeploymentStateCompleted DeploymentState = "completed"
+	DeploymentStateCancelled DeploymentState = "cancelled"
+	DeploymentStateTimedOut  DeploymentState = "timedout"
+	// DeploymentStatePendingApproval deployments aren't queued until they've been approved
+	DeploymentStatePendingApproval DeploymentState = "pending_approval"
+	DeploymentStateRejected        DeploymentState = "rejected"
+	// DeploymentStateWaiting deployments aren't queued until the deployments with a lower deploy order in the same plan
+	// have completed
+	DeploymentStateWaiting DeploymentState = "waiting"
 )
 
 type Deployment struct {
-	ID            uuid.UUID       `db:"id"`
-	EnvironmentID int             `db:"environment_id"`
-	NamespaceID   int             `db:"namespace_id"`
-	MessageID     sql.NullString  `db:"message_id"`
-	ReceiptHandle sql.NullString  `db:"receipt_handle"`
-	ReqID         string          `db:"req_id"`
-	PlanOptions   json.Object     `db:"plan_options"`
-	PlanLocation  json.Object     `db:"plan_location"`
-	State         DeploymentState `db:"state"`
-	User          string          `db:"user"`
-	CreatedAt     sql.NullTime    `db:"created_at"`
-	UpdatedAt     sql.NullTime    `db:"updated_at"`
+	ID                uuid.UUID       `db:"id"`
+	EnvironmentID     int             `db:"environment_id"`
+	NamespaceID       int             `db:"namespace_id"`
+	MessageID         sql.NullString  `db:"message_id"`
+	ReceiptHandle     sql.NullString  `db:"receipt_handle"`
+	ReqID             string          `db:"req_id"`
+	PlanOptions       json.Object     `db:"plan_options"`
+	PlanLocation      json.Object     `db:"plan_location"`
+	State             DeploymentState `db:"state"`
+	User              string          `db:"user"`
+	RequestedBy       string          `db:"requested_by"`
+	RequiredApprovals int             `db:"required_approvals"`
+	RolloutID         uuid.NullUUID   `db:"rollout_id"`
+	RolloutStage      sql.NullString  `db:"rollout_stage"`
+	PipelineRunID     uuid.NullUUID   `db:"pipeline_run_id"`
+	PendingWaves      json.Object     `db:"pending_waves"`
+	Wave              int             `db:"wave"`
+	PlanID            uuid.NullUUID   `db:"plan_id"`
+	DeployOrder       int             `db:"deploy_order"`
+	CreatedAt         sql.NullTime    `db:"created_at"`
+	UpdatedAt         sql.NullTime    `db:"updated_at"`
+}
+
+// Released is true when the original schedule message was deleted without waiting on the scheduler's reply
+func (d Deployment) Released() bool {
+	return d.State == DeploymentStateCancelled || d.State == DeploymentStateTimedOut
+}
+
+// Waves returns the plan locations of the pending waves in the order they're sent
+func (d Deployment) Waves() ([]json.Object, error) {
+	var waves []json.Object
+	if len(d.PendingWaves) == 0 || d.PendingWaves.String() == string(json.EmptyJSONObject) {
+		return waves, nil
+	}
+	if err := d.PendingWaves.Unmarshal(&waves); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return waves, nil
+}
+
+// DeploymentHistory is a deployment with the names of the environment and namespace it ran in
+type DeploymentHistory struct {
+	Deployment
+	EnvironmentName sql.NullString `db:"environment_name"`
+	NamespaceName   sql.NullString `db:"namespace_name"`
 }
 
 func (r *Repo) UpdateDeploymentMessageID(ctx context.Context, id uuid.UUID, messageID string) error {
-	result, err := r.db.ExecContext(ctx, "update deployment set message_id = $1, updated_at = $2 where id = $3", messageID, time.Now().UTC(), id)
+	result, err := r.conn(ctx).ExecContext(ctx, "update deployment set message_id = $1, updated_at = $2 where id = $3", messageID, time.Now().UTC(), id)
 	if err != nil {
 		return errors.Wrap(err)
 	}
@@ -52,9 +94,13 @@ func (r *Repo) UpdateDeploymentMessageID(ctx context.Context, id uuid.UUID, mess
 	return nil
 }
 
-func (r *Repo) UpdateDeploymentPlanLocation(ctx context.Context, id uuid.UUID, location json.Object) error {
-	result, err := r.db.ExecContext(ctx, "update deployment set plan_location = $1, state = $2, updated_at = $3 where id = $4",
-		location, DeploymentStateScheduled, time.Now().UTC(), id)
+// UpdateDeploymentWave records the wave that was sent to the scheduler, a deployment that was cancelled or timed out
+// while the wave before it was deployed keeps that state
+func (r *Repo) UpdateDeploymentWave(ctx context.Context, id uuid.UUID, wave int) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		update deployment set wave = $1, state = case when state in ($2, $3) then state else $4 end, updated_at = $5
+		where id = $6
+		`, wave, DeploymentStateCancelled, DeploymentStateTimedOut, DeploymentStateScheduled, time.Now().UTC(), id)
 	if err != nil {
 		return errors.Wrap(err)
 	}
@@ -73,13 +119,183 @@ func (r *Repo) UpdateDeploymentPlanLocation(ctx context.Context, id uuid.UUID, l
 func (r *Repo) UpdateDeploymentResult(ctx context.Context, id uuid.UUID) (*Deployment, error) {
 	var deployment Deployment
 
-	row := r.db.QueryRowxContext(ctx, `
-		update deployment set state = $1, updated_at = $2 where id = $3
+	// a deployment that was cancelled or timed out after it was sent to the scheduler keeps that state
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set state = case when state in ($1, $2) then state else $3 end, updated_at = $4 where id = $5
+		returning *
+		`, DeploymentStateCancelled, DeploymentStateTimedOut, DeploymentStateCompleted, time.Now().UTC(), id)
+
+	err := row.StructScan(&deployment)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &deployment, nil
+}
+
+// ScheduleDeployment moves a queued deployment to scheduled with the location of the plan (or its first wave) sent to the
+// scheduler, a deployment that's no longer queued (it was cancelled while its plan was being built) isn't found
+func (r *Repo) ScheduleDeployment(ctx context.Context, id uuid.UUID, wave int, location json.Object) (*Deployment, error) {
+	var deployment Deployment
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set plan_location = $1, wave = $2, state = $3, updated_at = $4 where id = $5 and state = $6
+		returning *
+		`, location, wave, DeploymentStateScheduled, time.Now().UTC(), id, DeploymentStateQueued)
+
+	err := row.StructScan(&deployment)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("queued deployment with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &deployment, nil
+}
+
+// UpdateDeploymentResultLocation replaces the plan location with the one the scheduler sent back so the results are kept
+func (r *Repo) UpdateDeploymentResultLocation(ctx context.Context, id uuid.UUID, location json.Object) error {
+	result, err := r.conn(ctx).ExecContext(ctx, "update deployment set plan_location = $1, updated_at = $2 where id = $3",
+		location, time.Now().UTC(), id)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if affected == 0 {
+		return errors.Wrapf("the following id: %s was not found to update in deployment table", id)
+	}
+	return nil
+}
+
+// UpdateDeploymentPendingWaves replaces the plan locations of the waves that haven't been sent to the scheduler yet,
+// a nil list clears them
+func (r *Repo) UpdateDeploymentPendingWaves(ctx context.Context, id uuid.UUID, waves []json.Object) error {
+	var value interface{}
+	if len(waves) > 0 {
+		obj, err := json.StructToJsonObject(waves)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		value = obj
+	}
+
+	result, err := r.conn(ctx).ExecContext(ctx, "update deployment set pending_waves = $1, updated_at = $2 where id = $3", value, time.Now().UTC(), id)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if affected == 0 {
+		return errors.Wrapf("the following id: %s was not found to update in deployment table", id)
+	}
+	return nil
+}
+
+// Deployments returns up to limit deployments ordered by created_at (and id to break ties)
+func (r *Repo) Deployments(ctx context.Context, ascending bool, limit int, whereArgs ...WhereArg) ([]DeploymentHistory, error) {
+	esql, args := CheckWhereArgs(`
+		select d.*,
+		       e.name as environment_name,
+		       ns.name as namespace_name
+		from deployment d
+		    left join environment e on d.environment_id = e.id
+		    left join namespace ns on d.namespace_id = ns.id
+		`, whereArgs)
+
+	order := "desc"
+	if ascending {
+		order = "asc"
+	}
+
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s order by d.created_at %s, d.id %s limit %d", esql, order, order, limit), args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var deployments []DeploymentHistory
+	for rows.Next() {
+		var deployment DeploymentHistory
+		err = rows.StructScan(&deployment)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, nil
+}
+
+// CancelDeployment cancels the deployment if it hasn't finished yet
+func (r *Repo) CancelDeployment(ctx context.Context, id uuid.UUID) (*Deployment, error) {
+	var deployment Deployment
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set state = $1, updated_at = $2 where id = $3 and state in ($4, $5, $6, $7)
 		returning *
-		`, DeploymentStateCompleted, time.Now().UTC(), id)
+		`, DeploymentStateCancelled, time.Now().UTC(), id, DeploymentStateQueued, DeploymentStateScheduled, DeploymentStatePendingApproval, DeploymentStateWaiting)
 
 	err := row.StructScan(&deployment)
 	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("unfinished deployment with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &deployment, nil
+}
+
+// TimedOutDeployments returns the deployments that have been scheduled for longer than the environment's deployment timeout
+// (or the default timeout when the environment doesn't have one), an environment's timeout is capped at the max timeout
+func (r *Repo) TimedOutDeployments(ctx context.Context, defaultTimeout time.Duration, maxTimeout time.Duration) ([]Deployment, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
+		select d.* from deployment d
+		    left join environment e on d.environment_id = e.id
+		where d.state = $1 and d.updated_at < $2 - make_interval(secs => least(coalesce(e.deployment_timeout, $3), $4))
+		`, DeploymentStateScheduled, time.Now().UTC(), int(defaultTimeout.Seconds()), int(maxTimeout.Seconds()))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var deployments []Deployment
+	for rows.Next() {
+		var deployment Deployment
+		err = rows.StructScan(&deployment)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, nil
+}
+
+// TimeoutDeployment marks the deployment as timed out, it returns a NotFoundError if the deployment is no longer scheduled
+func (r *Repo) TimeoutDeployment(ctx context.Context, id uuid.UUID) (*Deployment, error) {
+	var deployment Deployment
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set state = $1, updated_at = $2 where id = $3 and state = $4
+		returning *
+		`, DeploymentStateTimedOut, time.Now().UTC(), id, DeploymentStateScheduled)
+
+	err := row.StructScan(&deployment)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("scheduled deployment with id: %s not found", id.String())
+		}
 		return nil, errors.Wrap(err)
 	}
 
@@ -89,7 +305,7 @@ func (r *Repo) UpdateDeploymentResult(ctx context.Context, id uuid.UUID) (*Deplo
 func (r *Repo) DeploymentByID(ctx context.Context, id uuid.UUID) (*Deployment, error) {
 	var deployment Deployment
 
-	row := r.db.QueryRowxContext(ctx, "select * from deployment where id = $1", id)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from deployment where id = $1", id)
 	err := row.StructScan(&deployment)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -101,14 +317,19 @@ func (r *Repo) DeploymentByID(ctx context.Context, id uuid.UUID) (*Deployment, e
 	return &deployment, nil
 }
 
+// UpdateDeploymentReceiptHandle records the receipt handle of the deployment's schedule message. The message is redelivered
+// when it's kept while the deployment's waves are scheduled, a deployment that's no longer queued or scheduled isn't found
 func (r *Repo) UpdateDeploymentReceiptHandle(ctx context.Context, id uuid.UUID, receiptHandle string) (*Deployment, error) {
 	var deployment Deployment
-	row := r.db.QueryRowxContext(ctx, `
-		update deployment set receipt_handle = $1, updated_at = $2 where id = $3
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set receipt_handle = $1 where id = $2 and state in ($3, $4)
 		returning *
-	`, receiptHandle, time.Now().UTC(), id)
+	`, receiptHandle, id, DeploymentStateQueued, DeploymentStateScheduled)
 	err := row.StructScan(&deployment)
 	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("queued or scheduled deployment with id: %s not found", id.String())
+		}
 		return nil, errors.Wrap(err)
 	}
 
@@ -125,14 +346,17 @@ func (r *Repo) CreateDeployment(ctx context.Context, d *Deployment) error {
 		Time:  now,
 		Valid: true,
 	}
+	if len(d.State) == 0 {
+		d.State = DeploymentStateQueued
+	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
-	insert into deployment(environment_id, namespace_id, req_id, plan_options, plan_location, state, "user", created_at, updated_at) 
-		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	insert into deployment(environment_id, namespace_id, req_id, plan_options, plan_location, state, "user", requested_by, required_approvals, rollout_id, rollout_stage, pipeline_run_id, plan_id, deploy_order, created_at, updated_at) 
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		returning (id)
 	
-	`, d.EnvironmentID, d.NamespaceID, d.ReqID, d.PlanOptions, d.PlanLocation, DeploymentStateQueued, d.User, d.CreatedAt, d.UpdatedAt).
+	`, d.EnvironmentID, d.NamespaceID, d.ReqID, d.PlanOptions, d.PlanLocation, d.State, d.User, d.RequestedBy, d.RequiredApprovals, d.RolloutID, d.RolloutStage, d.PipelineRunID, d.PlanID, d.DeployOrder, d.CreatedAt, d.UpdatedAt).
 		Scan(&d.ID)
 
 	if err != nil {
@@ -141,3 +365,49 @@ func (r *Repo) CreateDeployment(ctx context.Context, d *Deployment) error {
 
 	return nil
 }
+
+// WaitingPlanIDs returns the plans that have deployments waiting on an earlier deploy order
+func (r *Repo) WaitingPlanIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, "select distinct plan_id from deployment where state = $1 and plan_id is not null", DeploymentStateWaiting)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r *Repo) DeploymentsByPlanID(ctx context.Context, planID uuid.UUID) ([]DeploymentHistory, error) {
+	return r.Deployments(ctx, true, maxPlanDeployments, Where("d.plan_id", planID))
+}
+
+const maxPlanDeployments = 1000
+
+// ReleaseWaitingDeployment moves a waiting deployment to the state it would've been created in (queued or pending approval)
+func (r *Repo) ReleaseWaitingDeployment(ctx context.Context, id uuid.UUID, state DeploymentState) (*Deployment, error) {
+	var deployment Deployment
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update deployment set state = $1, updated_at = $2 where id = $3 and state = $4
+		returning *
+		`, state, time.Now().UTC(), id, DeploymentStateWaiting)
+
+	err := row.StructScan(&deployment)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("waiting deployment with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &deployment, nil
+}
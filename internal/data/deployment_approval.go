@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+type DeploymentApproval struct {
+	ID           int            `db:"id"`
+	DeploymentID uuid.UUID      `db:"deployment_id"`
+	User         string         `db:"user"`
+	Approved     bool           `db:"approved"`
+	Reason       sql.NullString `db:"reason"`
+	CreatedAt    sql.NullTime   `db:"created_at"`
+}
+
+type DeploymentApprovals []DeploymentApproval
+
+func (r *Repo) DeploymentApprovalsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (DeploymentApprovals, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, "select * from deployment_approval where deployment_id = $1 order by id", deploymentID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var approvals DeploymentApprovals
+	for rows.Next() {
+		var approval DeploymentApproval
+		err = rows.StructScan(&approval)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, nil
+}
+
+// RecordDeploymentApproval records the approval (or rejection) of a deployment that's pending approval and moves the deployment
+// to the queued state once it has its required approvals, or to the rejected state when it's rejected.
+// The deployment is locked while the approval is recorded so concurrent approvals can't both queue it
+func (r *Repo) RecordDeploymentApproval(ctx context.Context, a *DeploymentApproval) (*Deployment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var deployment Deployment
+	err = tx.QueryRowxContext(ctx, "select * from deployment where id = $1 and state = $2 for update", a.DeploymentID, DeploymentStatePendingApproval).
+		StructScan(&deployment)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			_ = tx.Rollback()
+			return nil, NotFoundErrorf("deployment pending approval with id: %s not found", a.DeploymentID.String())
+		}
+		return nil, errors.WrapTx(tx, err)
+	}
+
+	now := time.Now().UTC()
+	a.CreatedAt = sql.NullTime{
+		Time:  now,
+		Valid: true,
+	}
+	err = tx.QueryRowxContext(ctx, `
+		insert into deployment_approval(deployment_id, "user", approved, reason, created_at)
+		values ($1, $2, $3, $4, $5)
+		returning id
+	`, a.DeploymentID, a.User, a.Approved, a.Reason, a.CreatedAt).Scan(&a.ID)
+	if err != nil {
+		return nil, errors.WrapTx(tx, err)
+	}
+
+	state := DeploymentStatePendingApproval
+	if !a.Approved {
+		state = DeploymentStateRejected
+	} else {
+		var approved int
+		err = tx.QueryRowxContext(ctx, "select count(*) from deployment_approval where deployment_id = $1 and approved = true", a.DeploymentID).
+			Scan(&approved)
+		if err != nil {
+			return nil, errors.WrapTx(tx, err)
+		}
+		if approved >= deployment.RequiredApprovals {
+			state = DeploymentStateQueued
+		}
+	}
+
+	if state != deployment.State {
+		err = tx.QueryRowxContext(ctx, "update deployment set state = $1, updated_at = $2 where id = $3 returning *", state, now, a.DeploymentID).
+			StructScan(&deployment)
+		if err != nil {
+			return nil, errors.WrapTx(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.WrapTx(tx, err)
+	}
+
+	return &deployment, nil
+}
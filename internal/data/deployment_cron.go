@@ -135,12 +135,12 @@ func (r *Repo) ScheduleDeploymentCronJobs(ctx context.Context, schedule func(con
 
 func (r *Repo) UpdateFinishedJobs(ctx context.Context) error {
 	now := time.Now().UTC()
-	_, err := r.db.ExecContext(ctx, `
+	_, err := r.conn(ctx).ExecContext(ctx, `
 		update deployment_cron set state = 'idle', last_run = $1
 		where state = 'running' and
 		      (select count(*) from deployment_cron_job as dcj
 		    		left join deployment d on dcj.deployment_id = d.id
-		    		where d.state != 'completed' and dcj.deployment_cron_id = deployment_cron.id) = 0 
+		    		where d.state not in ('completed', 'cancelled', 'timedout', 'rejected') and dcj.deployment_cron_id = deployment_cron.id) = 0 
 	`, now)
 	if err != nil {
 		return errors.Wrap(err)
@@ -150,7 +150,7 @@ func (r *Repo) UpdateFinishedJobs(ctx context.Context) error {
 }
 
 func (r *Repo) DeploymentCronJobs(ctx context.Context) ([]DeploymentCronJob, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			id,
 			description,
@@ -192,7 +192,7 @@ func (r *Repo) CreateDeploymentCronJob(ctx context.Context, m *DeploymentCronJob
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO deployment_cron(plan_options, schedule, state, last_run, disabled, description, exec_order)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
@@ -215,7 +215,7 @@ func (r *Repo) CreateDeploymentCronJob(ctx context.Context, m *DeploymentCronJob
 
 func (r *Repo) UpdateDeploymentCronJob(ctx context.Context, m *DeploymentCronJob) error {
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update deployment_cron set plan_options = $2, schedule = $3, state = $4, disabled = $5, description = $6, exec_order = $7
 		where id = $1
 		RETURNING last_run
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// DeploymentResult is the outcome of a single service or job in a deployment
+type DeploymentResult struct {
+	ID               int            `db:"id"`
+	DeploymentID     uuid.UUID      `db:"deployment_id"`
+	ServiceID        sql.NullInt32  `db:"service_id"`
+	JobID            sql.NullInt32  `db:"job_id"`
+	ArtifactID       int            `db:"artifact_id"`
+	ArtifactName     string         `db:"artifact_name"`
+	Name             string         `db:"name"`
+	RequestedVersion sql.NullString `db:"requested_version"`
+	PreviousVersion  sql.NullString `db:"previous_version"`
+	NewVersion       sql.NullString `db:"new_version"`
+	Result           string         `db:"result"`
+	ExitCode         int            `db:"exit_code"`
+	CreatedAt        sql.NullTime   `db:"created_at"`
+	UpdatedAt        sql.NullTime   `db:"updated_at"`
+}
+
+type DeploymentResults []DeploymentResult
+
+// UpsertDeploymentResult records the result, a result that's already been recorded for the deployment
+// (ex: the scheduler's message was redelivered) is overwritten
+func (r *Repo) UpsertDeploymentResult(ctx context.Context, dr *DeploymentResult) error {
+	now := time.Now().UTC()
+	dr.CreatedAt = sql.NullTime{
+		Time:  now,
+		Valid: true,
+	}
+	dr.UpdatedAt = sql.NullTime{
+		Time:  now,
+		Valid: true,
+	}
+
+	conflict := "(deployment_id, service_id) where service_id is not null"
+	if !dr.ServiceID.Valid {
+		conflict = "(deployment_id, job_id) where job_id is not null"
+	}
+
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into deployment_result(deployment_id, service_id, job_id, artifact_id, artifact_name, name, requested_version, 
+		                              previous_version, new_version, result, exit_code, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		on conflict `+conflict+`
+		do update set requested_version = $7, previous_version = $8, new_version = $9, result = $10, exit_code = $11, updated_at = $13
+		returning id, created_at
+	`,
+		dr.DeploymentID,
+		dr.ServiceID,
+		dr.JobID,
+		dr.ArtifactID,
+		dr.ArtifactName,
+		dr.Name,
+		dr.RequestedVersion,
+		dr.PreviousVersion,
+		dr.NewVersion,
+		dr.Result,
+		dr.ExitCode,
+		dr.CreatedAt,
+		dr.UpdatedAt).Scan(&dr.ID, &dr.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeploymentResultsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (DeploymentResults, error) {
+	return r.deploymentResults(ctx, Where("deployment_id", deploymentID))
+}
+
+// DeploymentResultsByDeploymentIDs returns the recorded results of each of the deployments in one query
+func (r *Repo) DeploymentResultsByDeploymentIDs(ctx context.Context, deploymentIDs []interface{}) (DeploymentResults, error) {
+	if len(deploymentIDs) == 0 {
+		return nil, nil
+	}
+	return r.deploymentResults(ctx, WhereIn("deployment_id", deploymentIDs))
+}
+
+func (r *Repo) deploymentResults(ctx context.Context, whereArgs ...WhereArg) (DeploymentResults, error) {
+	esql, args := CheckWhereArgs("select * from deployment_result", whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+" order by name", args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var results DeploymentResults
+	for rows.Next() {
+		var result DeploymentResult
+		err = rows.StructScan(&result)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -12,7 +12,7 @@ type EnvironmentFeedMap struct {
 }
 
 func (r *Repo) EnvironmentFeedMaps(ctx context.Context) ([]EnvironmentFeedMap, error) {
-	rows, err := r.db.QueryxContext(ctx, fmt.Sprintf(`
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf(`
 		select 
 			environment_id,
 			feed_id
@@ -40,7 +40,7 @@ func (r *Repo) EnvironmentFeedMaps(ctx context.Context) ([]EnvironmentFeedMap, e
 }
 
 func (r *Repo) CreateEnvironmentFeedMap(ctx context.Context, model *EnvironmentFeedMap) error {
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO environment_feed_map(environment_id, feed_id)
 		VALUES ($1, $2)
 	RETURNING environment_id, feed_id
@@ -57,7 +57,7 @@ func (r *Repo) CreateEnvironmentFeedMap(ctx context.Context, model *EnvironmentF
 }
 
 func (r *Repo) UpdateEnvironmentFeedMap(ctx context.Context, model *EnvironmentFeedMap) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update environment_feed_map set 
 			environment_id = $1,
 		    feed_id = $2
@@ -10,11 +10,15 @@ import (
 )
 
 type Environment struct {
-	ID          int          `db:"id"`
-	Name        string       `db:"name"`
-	Alias       string       `db:"alias"`
-	Description string       `db:"description"`
-	UpdatedAt   sql.NullTime `db:"updated_at"`
+	ID          int    `db:"id"`
+	Name        string `db:"name"`
+	Alias       string `db:"alias"`
+	Description string `db:"description"`
+	// DeploymentTimeout is the number of seconds a deployment can stay scheduled before it's timed out
+	DeploymentTimeout sql.NullInt32 `db:"deployment_timeout"`
+	// RequiredApprovals is the number of approvals a deployment to the environment needs before it's queued
+	RequiredApprovals int          `db:"required_approvals"`
+	UpdatedAt         sql.NullTime `db:"updated_at"`
 }
 
 type Environments []Environment
@@ -22,11 +26,13 @@ type Environments []Environment
 func (r *Repo) EnvironmentByName(ctx context.Context, name string) (*Environment, error) {
 	var environment Environment
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id,
 		       name,
 		       alias,
 		       description,
+		       deployment_timeout,
+		       required_approvals,
 		       updated_at
 		from environment where name = $1
 		`, name)
@@ -44,11 +50,13 @@ func (r *Repo) EnvironmentByName(ctx context.Context, name string) (*Environment
 func (r *Repo) EnvironmentByID(ctx context.Context, id int) (*Environment, error) {
 	var environment Environment
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id,
 		       name,
 		       alias,
 		       description,
+		       deployment_timeout,
+		       required_approvals,
 		       updated_at
 		from environment where id = $1
 		`, id)
@@ -64,11 +72,13 @@ func (r *Repo) EnvironmentByID(ctx context.Context, id int) (*Environment, error
 }
 
 func (r *Repo) Environments(ctx context.Context) (Environments, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select id, 
 		       name,
 		       alias,
-		       description 
+		       description,
+		       deployment_timeout,
+		       required_approvals
 		from environment order by name
 		`)
 	if err != nil {
@@ -90,13 +100,17 @@ func (r *Repo) Environments(ctx context.Context) (Environments, error) {
 func (r *Repo) UpdateEnvironment(ctx context.Context, environment *Environment) error {
 	environment.UpdatedAt.Time = time.Now().UTC()
 	environment.UpdatedAt.Valid = true
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update environment set 
 			description = $1,
-			updated_at = $2
-		where id = $3
+			deployment_timeout = $2,
+			required_approvals = $3,
+			updated_at = $4
+		where id = $5
 	`,
 		environment.Description,
+		environment.DeploymentTimeout,
+		environment.RequiredApprovals,
 		environment.UpdatedAt,
 		environment.ID)
 	if err != nil {
@@ -115,7 +129,7 @@ func (r *Repo) UpdateEnvironment(ctx context.Context, environment *Environment)
 }
 
 func (r *Repo) CreateEnvironment(ctx context.Context, model *Environment) error {
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO environment(id, name, alias, description)
 		VALUES ($1, $2, $3, $4)
 	`,
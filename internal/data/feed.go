@@ -16,6 +16,21 @@ type Feed struct {
 
 type Feeds []Feed
 
+func (r *Repo) FeedByID(ctx context.Context, id int) (*Feed, error) {
+	var feed Feed
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from feed where id = $1", id)
+	err := row.StructScan(&feed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NotFoundErrorf("feed with id: %d, not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &feed, nil
+}
+
 func (r *Repo) FeedByAliasAndType(ctx context.Context, alias, feedType string) (*Feed, error) {
 	var feed Feed
 
@@ -25,7 +40,7 @@ func (r *Repo) FeedByAliasAndType(ctx context.Context, alias, feedType string) (
 		alias = "int"
 	}
 
-	row := r.db.QueryRowxContext(ctx, "select * from feed where feed_type = $1 AND alias = $2", feedType, alias)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from feed where feed_type = $1 AND alias = $2", feedType, alias)
 	err := row.StructScan(&feed)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -37,10 +52,30 @@ func (r *Repo) FeedByAliasAndType(ctx context.Context, alias, feedType string) (
 	return &feed, nil
 }
 
+// FeedByEnvironmentIDAndType returns the feed the environment deploys the feed type from
+func (r *Repo) FeedByEnvironmentIDAndType(ctx context.Context, environmentID int, feedType string) (*Feed, error) {
+	var feed Feed
+
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		select f.* from feed f
+			join environment_feed_map efm on f.id = efm.feed_id
+		where efm.environment_id = $1 and f.feed_type = $2
+		`, environmentID, feedType)
+	err := row.StructScan(&feed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NotFoundErrorf("feed with feed_type: %v for environment: %v, not found", feedType, environmentID)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &feed, nil
+}
+
 func (r *Repo) NextFeedByPromotionOrderType(ctx context.Context, promotionOrder int, feedType string) (*Feed, error) {
 	var feed Feed
 
-	row := r.db.QueryRowxContext(ctx, "select * from feed where feed_type = $1 AND promotion_order > $2 order by promotion_order asc limit 1;", feedType, promotionOrder)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from feed where feed_type = $1 AND promotion_order > $2 order by promotion_order asc limit 1;", feedType, promotionOrder)
 
 	err := row.StructScan(&feed)
 	if err != nil {
@@ -56,7 +91,7 @@ func (r *Repo) NextFeedByPromotionOrderType(ctx context.Context, promotionOrder
 func (r *Repo) PreviousFeedByPromotionOrderType(ctx context.Context, promotionOrder int, feedType string) (*Feed, error) {
 	var feed Feed
 
-	row := r.db.QueryRowxContext(ctx, "select * from feed where alias <> '' AND feed_type = $1 AND promotion_order < $2 order by promotion_order desc limit 1;", feedType, promotionOrder)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from feed where alias <> '' AND feed_type = $1 AND promotion_order < $2 order by promotion_order desc limit 1;", feedType, promotionOrder)
 
 	err := row.StructScan(&feed)
 	if err != nil {
@@ -70,7 +105,7 @@ func (r *Repo) PreviousFeedByPromotionOrderType(ctx context.Context, promotionOr
 }
 
 func (r *Repo) Feeds(ctx context.Context) ([]Feed, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			id,
 			name,
@@ -101,7 +136,7 @@ func (r *Repo) Feeds(ctx context.Context) ([]Feed, error) {
 }
 
 func (r *Repo) CreateFeed(ctx context.Context, model *Feed) error {
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO feed(id, name, promotion_order, feed_type, alias)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
@@ -116,7 +151,7 @@ func (r *Repo) CreateFeed(ctx context.Context, model *Feed) error {
 }
 
 func (r *Repo) UpdateFeed(ctx context.Context, model *Feed) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update feed set 
 			name = $2,
 		    promotion_order = $3,
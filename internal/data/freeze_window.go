@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// FreezeWindow blocks deployments to an environment (or one of its namespaces), it's either recurring (a cron schedule
+// for when the window starts and a duration) or a one-off date range
+type FreezeWindow struct {
+	ID            int            `db:"id"`
+	EnvironmentID int            `db:"environment_id"`
+	NamespaceID   sql.NullInt32  `db:"namespace_id"`
+	Description   string         `db:"description"`
+	Schedule      sql.NullString `db:"schedule"`
+	// Duration is in seconds
+	Duration  sql.NullInt32 `db:"duration"`
+	StartsAt  sql.NullTime  `db:"starts_at"`
+	EndsAt    sql.NullTime  `db:"ends_at"`
+	Disabled  bool          `db:"disabled"`
+	CreatedAt sql.NullTime  `db:"created_at"`
+	UpdatedAt sql.NullTime  `db:"updated_at"`
+}
+
+type FreezeWindows []FreezeWindow
+
+func (r *Repo) FreezeWindows(ctx context.Context, whereArgs ...WhereArg) (FreezeWindows, error) {
+	esql, args := CheckWhereArgs("select * from freeze_window", whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+" order by environment_id, id", args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var windows FreezeWindows
+	for rows.Next() {
+		var window FreezeWindow
+		err = rows.StructScan(&window)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// EnabledFreezeWindowsByEnvironmentID returns the windows that apply to the environment and its namespaces
+func (r *Repo) EnabledFreezeWindowsByEnvironmentID(ctx context.Context, environmentID int) (FreezeWindows, error) {
+	return r.FreezeWindows(ctx, Where("environment_id", environmentID), Where("disabled", false))
+}
+
+func (r *Repo) FreezeWindowByID(ctx context.Context, id int) (*FreezeWindow, error) {
+	var window FreezeWindow
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from freeze_window where id = $1", id)
+	err := row.StructScan(&window)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("freeze window with id: %d not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &window, nil
+}
+
+func (r *Repo) CreateFreezeWindow(ctx context.Context, w *FreezeWindow) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into freeze_window(environment_id, namespace_id, description, schedule, duration, starts_at, ends_at, disabled, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		returning *
+	`,
+		w.EnvironmentID,
+		w.NamespaceID,
+		w.Description,
+		w.Schedule,
+		w.Duration,
+		w.StartsAt,
+		w.EndsAt,
+		w.Disabled,
+		now).StructScan(w)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) UpdateFreezeWindow(ctx context.Context, w *FreezeWindow) error {
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		update freeze_window set 
+			environment_id = $1,
+			namespace_id = $2,
+			description = $3,
+			schedule = $4,
+			duration = $5,
+			starts_at = $6,
+			ends_at = $7,
+			disabled = $8,
+			updated_at = $9
+		where id = $10
+		returning *
+	`,
+		w.EnvironmentID,
+		w.NamespaceID,
+		w.Description,
+		w.Schedule,
+		w.Duration,
+		w.StartsAt,
+		w.EndsAt,
+		w.Disabled,
+		time.Now().UTC(),
+		w.ID).StructScan(w)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return NotFoundErrorf("freeze window with id: %d not found", w.ID)
+		}
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteFreezeWindow(ctx context.Context, id int) error {
+	return r.deleteWithQuery(ctx, "freeze_window", fmt.Sprintf("id = %d", id))
+}
@@ -42,7 +42,7 @@ type Job struct {
 }
 
 func (r *Repo) UpdateDeployedJobVersion(ctx context.Context, id int, version string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update job
 		set deployed_version = $1, 
 		    updated_at = $2 
@@ -64,7 +64,7 @@ func (r *Repo) UpdateDeployedJobVersion(ctx context.Context, id int, version str
 }
 
 func (r *Repo) DeployedJobsByNamespaceID(ctx context.Context, namespaceID int) (DeployJobs, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select j.id as job_id,
 		       j.name as job_name,
 		       j.artifact_id,
@@ -104,7 +104,7 @@ func (r *Repo) DeployedJobsByNamespaceID(ctx context.Context, namespaceID int) (
 func (r *Repo) JobByName(ctx context.Context, name string, namespace string) (*Job, error) {
 	var job Job
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select j.id, 
 		       j.name, 
 		       j.namespace_id, 
@@ -149,7 +149,7 @@ func (r *Repo) JobArtifacts(ctx context.Context, namespaceIDs []int) (RequestArt
 		return nil, errors.Wrap(err)
 	}
 	s = r.db.Rebind(s)
-	rows, err := r.db.QueryxContext(ctx, s, args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, s, args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -168,7 +168,7 @@ func (r *Repo) JobArtifacts(ctx context.Context, namespaceIDs []int) (RequestArt
 func (r *Repo) JobByID(ctx context.Context, id int) (*Job, error) {
 	var job Job
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select j.id, 
 		       j.name, 
 		       j.namespace_id, 
@@ -218,7 +218,7 @@ func (r *Repo) Jobs(ctx context.Context, whereArgs ...WhereArg) ([]Job, error) {
 		    left join namespace n on j.namespace_id = n.id
 			left join artifact a on j.artifact_id = a.id
 		`, whereArgs)
-	rows, err := r.db.QueryxContext(ctx, s+"order by j.name", args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, s+"order by j.name", args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -239,7 +239,7 @@ func (r *Repo) UpdateJob(ctx context.Context, job *Job) error {
 	job.UpdatedAt.Time = time.Now().UTC()
 	job.UpdatedAt.Valid = true
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update job set 
 		   	name = $1, 
 			namespace_id = $2,
@@ -273,7 +273,7 @@ func (r *Repo) UpdateJob(ctx context.Context, job *Job) error {
 
 func (r *Repo) CreateJob(ctx context.Context, model *Job) error {
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO job(namespace_id,
 					artifact_id,
 					override_version,
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// ServiceIDsByMetadataID returns the services the metadata is mapped to, with the same scope rules ServiceMetadata uses
+func (r *Repo) ServiceIDsByMetadataID(ctx context.Context, metadataID int) ([]int, error) {
+	return r.mapTargetIDs(ctx, "metadata_service_map", "metadata_id", "service", "service_id", metadataID)
+}
+
+// JobIDsByMetadataID returns the jobs the metadata is mapped to, with the same scope rules JobMetadata uses
+func (r *Repo) JobIDsByMetadataID(ctx context.Context, metadataID int) ([]int, error) {
+	return r.mapTargetIDs(ctx, "metadata_job_map", "metadata_id", "job", "job_id", metadataID)
+}
+
+// ServiceIDsByDefinitionID returns the services the definition is mapped to, with the same scope rules
+// ServiceDefinition uses
+func (r *Repo) ServiceIDsByDefinitionID(ctx context.Context, definitionID int) ([]int, error) {
+	return r.mapTargetIDs(ctx, "definition_service_map", "definition_id", "service", "service_id", definitionID)
+}
+
+// JobIDsByDefinitionID returns the jobs the definition is mapped to, with the same scope rules JobDefinition uses
+func (r *Repo) JobIDsByDefinitionID(ctx context.Context, definitionID int) ([]int, error) {
+	return r.mapTargetIDs(ctx, "definition_job_map", "definition_id", "job", "job_id", definitionID)
+}
+
+func (r *Repo) mapTargetIDs(ctx context.Context, mapTable, mappedColumn, targetTable, targetColumn string, id int) ([]int, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT t.id
+		FROM %[1]s mt
+		    CROSS JOIN %[3]s t
+		    LEFT JOIN namespace n ON t.namespace_id = n.id
+		WHERE
+			mt.%[2]s = $1
+		AND (
+			(mt.%[4]s = t.id)
+		OR
+			(mt.cluster_id = n.cluster_id AND mt.artifact_id IS NULL)
+		OR
+		    (mt.environment_id = n.environment_id AND mt.artifact_id IS NULL) 
+		OR
+		    (mt.namespace_id = t.namespace_id AND mt.artifact_id IS NULL)
+		OR
+		    (mt.artifact_id = t.artifact_id AND mt.environment_id IS NULL AND mt.namespace_id IS NULL AND mt.cluster_id IS NULL)
+		OR
+		    (mt.artifact_id = t.artifact_id AND mt.cluster_id = n.cluster_id)
+		OR
+		    (mt.artifact_id = t.artifact_id AND mt.environment_id = n.environment_id)
+		OR
+		    (mt.artifact_id = t.artifact_id AND mt.namespace_id = t.namespace_id)
+		)
+		ORDER BY t.id
+	`, mapTable, mappedColumn, targetTable, targetColumn), id)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err())
+		}
+
+		var targetID int
+		err = rows.Scan(&targetID)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		ids = append(ids, targetID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return ids, nil
+}
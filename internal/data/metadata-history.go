@@ -3,11 +3,16 @@ package data
 import (
 	"context"
 	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
 	"github.com/unanet/go/pkg/errors"
 	"github.com/unanet/go/pkg/json"
 )
 
 type MetadataHistory struct {
+	Revision    int          `db:"revision"`
 	MetadataId  int          `db:"metadata_id"`
 	Description string       `db:"description"`
 	Value       json.Object  `db:"value"`
@@ -17,9 +22,9 @@ type MetadataHistory struct {
 	DeletedBy   *string      `db:"deleted_by"`
 }
 
-func (r *Repo) MetadataHistory(ctx context.Context) ([]MetadataHistory, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+const metadataHistorySelect = `
 		SELECT 
+			revision,
 			metadata_id,
 			description,
 			value,
@@ -27,9 +32,12 @@ func (r *Repo) MetadataHistory(ctx context.Context) ([]MetadataHistory, error) {
 			created_by,
 			deleted,
 			deleted_by
-		FROM metadata_history 
-		LIMIT 100`) // Arbitrary value to limit by
+		FROM metadata_history`
 
+// MetadataHistory returns the revisions that match the where args, the newest first
+func (r *Repo) MetadataHistory(ctx context.Context, limit int, whereArgs ...WhereArg) ([]MetadataHistory, error) {
+	esql, args := CheckWhereArgs(metadataHistorySelect, whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s ORDER BY revision DESC LIMIT %d", esql, limit), args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -52,3 +60,38 @@ func (r *Repo) MetadataHistory(ctx context.Context) ([]MetadataHistory, error) {
 
 	return mm, nil
 }
+
+func (r *Repo) MetadataHistoryByRevision(ctx context.Context, metadataID int, revision int) (*MetadataHistory, error) {
+	var history MetadataHistory
+
+	row := r.conn(ctx).QueryRowxContext(ctx, metadataHistorySelect+`
+		WHERE metadata_id = $1 AND revision = $2`, metadataID, revision)
+	err := row.StructScan(&history)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("revision: %d of metadata with id: %d not found", revision, metadataID)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &history, nil
+}
+
+// MetadataHistoryAt returns the revision of the metadata that was current at the time
+func (r *Repo) MetadataHistoryAt(ctx context.Context, metadataID int, at time.Time) (*MetadataHistory, error) {
+	var history MetadataHistory
+
+	row := r.conn(ctx).QueryRowxContext(ctx, metadataHistorySelect+`
+		WHERE metadata_id = $1 AND created <= $2 AND (deleted IS NULL OR deleted > $2)
+		ORDER BY revision DESC
+		LIMIT 1`, metadataID, at)
+	err := row.StructScan(&history)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("metadata with id: %d didn't exist at: %s", metadataID, at.Format(time.RFC3339))
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &history, nil
+}
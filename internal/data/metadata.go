@@ -53,6 +53,7 @@ type MetadataService struct {
 	MapArtifactID       sql.NullInt32 `db:"map_artifact_id"`
 	MapNamespaceID      sql.NullInt32 `db:"map_namespace_id"`
 	MapServiceID        sql.NullInt32 `db:"map_service_id"`
+	MapClusterID        sql.NullInt32 `db:"map_cluster_id"`
 	StackingOrder       int           `db:"stacking_order"`
 	CreatedAt           sql.NullTime  `db:"created_at"`
 	UpdatedAt           sql.NullTime  `db:"updated_at"`
@@ -67,6 +68,7 @@ type MetadataJob struct {
 	MapArtifactId       sql.NullInt32 `db:"map_artifact_id"`
 	MapNamespaceId      sql.NullInt32 `db:"map_namespace_id"`
 	MapJobId            sql.NullInt32 `db:"map_job_id"`
+	MapClusterID        sql.NullInt32 `db:"map_cluster_id"`
 	StackingOrder       int           `db:"stacking_order"`
 	CreatedAt           sql.NullTime  `db:"created_at"`
 	UpdatedAt           sql.NullTime  `db:"updated_at"`
@@ -83,7 +85,7 @@ func (r *Repo) UpsertMergeMetadata(ctx context.Context, m *Metadata) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO metadata(description, value, created_at, updated_at)
 		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (description)
@@ -110,7 +112,7 @@ func (r *Repo) UpsertMetadata(ctx context.Context, m *Metadata) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
 	INSERT INTO metadata(description, value, created_at, updated_at)
 		VALUES ($1, $2, $3, $4)
@@ -139,7 +141,7 @@ func (r *Repo) UpsertMetadataJobMap(ctx context.Context, mjm *MetadataJobMap) er
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
 	INSERT INTO metadata_job_map(description, metadata_id, environment_id, artifact_id, namespace_id, job_id, stacking_order, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
@@ -168,7 +170,7 @@ func (r *Repo) UpsertMetadataServiceMap(ctx context.Context, msm *MetadataServic
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	
 	INSERT INTO metadata_service_map(description, metadata_id, environment_id, artifact_id, namespace_id, service_id, stacking_order, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
@@ -189,7 +191,7 @@ func (r *Repo) UpsertMetadataServiceMap(ctx context.Context, msm *MetadataServic
 func (r *Repo) GetMetadata(ctx context.Context, metadataID int) (*Metadata, error) {
 	var metadata Metadata
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id, 
 		       description, 
 		       value, 
@@ -212,7 +214,7 @@ func (r *Repo) GetMetadata(ctx context.Context, metadataID int) (*Metadata, erro
 func (r *Repo) GetMetadataByDescription(ctx context.Context, description string) (*Metadata, error) {
 	var metadata Metadata
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select id, 
 		       description, 
 		       value, 
@@ -233,7 +235,7 @@ func (r *Repo) GetMetadataByDescription(ctx context.Context, description string)
 }
 
 func (r *Repo) Metadata(ctx context.Context) ([]Metadata, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select id, 
 		       description, 
 		       value, 
@@ -265,7 +267,7 @@ func (r *Repo) Metadata(ctx context.Context) ([]Metadata, error) {
 
 func (r *Repo) DeleteMetadataKey(ctx context.Context, metadataID int, key string) (*Metadata, error) {
 	var metadata Metadata
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 		UPDATE metadata SET value = metadata.value - $1 WHERE id = $2
 		RETURNING id, value, description, created_at, updated_at
 	`, key, metadataID).StructScan(&metadata)
@@ -277,7 +279,7 @@ func (r *Repo) DeleteMetadataKey(ctx context.Context, metadataID int, key string
 }
 
 func (r *Repo) DeleteMetadata(ctx context.Context, metadataID int) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM metadata WHERE id = $1
 	`, metadataID)
 	if err != nil {
@@ -297,7 +299,7 @@ func (r *Repo) DeleteMetadata(ctx context.Context, metadataID int) error {
 }
 
 func (r *Repo) DeleteMetadataJobMap(ctx context.Context, metadataID int, mapDescription string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM metadata_job_map WHERE metadata_id = $1 AND description = $2
 	`, metadataID, mapDescription)
 	if err != nil {
@@ -317,7 +319,7 @@ func (r *Repo) DeleteMetadataJobMap(ctx context.Context, metadataID int, mapDesc
 }
 
 func (r *Repo) DeleteMetadataServiceMap(ctx context.Context, metadataID int, mapDescription string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		DELETE FROM metadata_service_map WHERE metadata_id = $1 AND description = $2
 	`, metadataID, mapDescription)
 	if err != nil {
@@ -337,7 +339,7 @@ func (r *Repo) DeleteMetadataServiceMap(ctx context.Context, metadataID int, map
 }
 
 func (r *Repo) JobMetadataMapsByJobID(ctx context.Context, jobID int) ([]MetadataJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       metadata_id, 
 		       environment_id, 
@@ -374,7 +376,7 @@ func (r *Repo) JobMetadataMapsByJobID(ctx context.Context, jobID int) ([]Metadat
 }
 
 func (r *Repo) MetadataJobMaps(ctx context.Context) ([]MetadataJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			description,
 			metadata_id,
@@ -411,7 +413,7 @@ func (r *Repo) MetadataJobMaps(ctx context.Context) ([]MetadataJobMap, error) {
 }
 
 func (r *Repo) MetadataServiceMaps(ctx context.Context) ([]MetadataServiceMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select 
 			description,
 			metadata_id,
@@ -448,7 +450,7 @@ func (r *Repo) MetadataServiceMaps(ctx context.Context) ([]MetadataServiceMap, e
 }
 
 func (r *Repo) JobMetadataMapsByMetadataID(ctx context.Context, metadataID int) ([]MetadataJobMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       metadata_id, 
 		       environment_id, 
@@ -485,7 +487,7 @@ func (r *Repo) JobMetadataMapsByMetadataID(ctx context.Context, metadataID int)
 }
 
 func (r *Repo) ServiceMetadataMapsByMetadataID(ctx context.Context, metadataID int) ([]MetadataServiceMap, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select description, 
 		       metadata_id, 
 		       environment_id, 
@@ -522,7 +524,7 @@ func (r *Repo) ServiceMetadataMapsByMetadataID(ctx context.Context, metadataID i
 }
 
 func (r *Repo) JobMetadata(ctx context.Context, jobID int) ([]MetadataJob, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		WITH env_data AS (
 			select j.id as job_id, 
 			       environment_id, 
@@ -543,6 +545,7 @@ func (r *Repo) JobMetadata(ctx context.Context, jobID int) ([]MetadataJob, error
 		       mjm.artifact_id as map_artifact_id,
 		       mjm.namespace_id as map_namespace_id,
 		       mjm.job_id as map_job_id,
+		       mjm.cluster_id as map_cluster_id,
 		       mjm.stacking_order as stacking_order,
 		       m.created_at,
 		       m.updated_at
@@ -590,7 +593,7 @@ func (r *Repo) JobMetadata(ctx context.Context, jobID int) ([]MetadataJob, error
 }
 
 func (r *Repo) ServiceMetadata(ctx context.Context, serviceID int) ([]MetadataService, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		WITH env_data AS (
 			select s.id as service_id, 
 			       environment_id, 
@@ -611,6 +614,7 @@ func (r *Repo) ServiceMetadata(ctx context.Context, serviceID int) ([]MetadataSe
 		       msm.artifact_id as map_artifact_id,
 		       msm.namespace_id as map_namespace_id,
 		       msm.service_id as map_service_id,
+		       msm.cluster_id as map_cluster_id,
 		       msm.stacking_order as stacking_order,
 		       m.created_at,
 		       m.updated_at
@@ -663,7 +667,7 @@ func (r *Repo) CreateMetadataJobMap(ctx context.Context, model *MetadataJobMap)
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO metadata_job_map(
 					description,
 					metadata_id,
@@ -702,7 +706,7 @@ func (r *Repo) CreateMetadataServiceMap(ctx context.Context, model *MetadataServ
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO metadata_service_map(
 					description,
 					metadata_id,
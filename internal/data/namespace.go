@@ -10,16 +10,18 @@ import (
 )
 
 type Namespace struct {
-	ID               int          `db:"id"`
-	Name             string       `db:"name"`
-	Alias            string       `db:"alias"`
-	EnvironmentID    int          `db:"environment_id"`
-	EnvironmentName  string       `db:"environment_name"`
-	RequestedVersion string       `db:"requested_version"`
-	ExplicitDeploy   bool         `db:"explicit_deploy"`
-	ClusterID        int          `db:"cluster_id"`
-	CreatedAt        sql.NullTime `db:"created_at"`
-	UpdatedAt        sql.NullTime `db:"updated_at"`
+	ID                int          `db:"id"`
+	Name              string       `db:"name"`
+	Alias             string       `db:"alias"`
+	EnvironmentID     int          `db:"environment_id"`
+	EnvironmentName   string       `db:"environment_name"`
+	RequestedVersion  string       `db:"requested_version"`
+	ExplicitDeploy    bool         `db:"explicit_deploy"`
+	ClusterID         int          `db:"cluster_id"`
+	RequiredApprovals int          `db:"required_approvals"`
+	DeployOrder       int          `db:"deploy_order"`
+	CreatedAt         sql.NullTime `db:"created_at"`
+	UpdatedAt         sql.NullTime `db:"updated_at"`
 }
 
 type Namespaces []Namespace
@@ -67,7 +69,7 @@ func (n Namespaces) FilterNamespaces(filter func(namespace Namespace) bool) (Nam
 func (r *Repo) NamespaceByName(ctx context.Context, name string) (*Namespace, error) {
 	var namespace Namespace
 
-	row := r.db.QueryRowxContext(ctx, "select * from namespace where name = $1", name)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from namespace where name = $1", name)
 	err := row.StructScan(&namespace)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -82,7 +84,7 @@ func (r *Repo) NamespaceByName(ctx context.Context, name string) (*Namespace, er
 func (r *Repo) NamespaceByID(ctx context.Context, id int) (*Namespace, error) {
 	var namespace Namespace
 
-	row := r.db.QueryRowxContext(ctx, "select * from namespace where id = $1", id)
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from namespace where id = $1", id)
 	err := row.StructScan(&namespace)
 	if err != nil {
 		if goErrors.Is(err, sql.ErrNoRows) {
@@ -115,12 +117,14 @@ func (r *Repo) namespaces(ctx context.Context, whereArgs ...WhereArg) (Namespace
 		       ns.requested_version, 
 		       ns.explicit_deploy, 
 		       ns.cluster_id,
+		       ns.required_approvals,
+		       ns.deploy_order,
 		       ns.created_at,
 		       ns.updated_at,
 		       e.name as environment_name 
 		from namespace ns left join environment e on ns.environment_id = e.id
 		`, whereArgs)
-	rows, err := r.db.QueryxContext(ctx, esql+"order by ns.requested_version desc", args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+"order by ns.requested_version desc", args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -140,15 +144,19 @@ func (r *Repo) namespaces(ctx context.Context, whereArgs ...WhereArg) (Namespace
 func (r *Repo) UpdateNamespace(ctx context.Context, namespace *Namespace) error {
 	namespace.UpdatedAt.Time = time.Now().UTC()
 	namespace.UpdatedAt.Valid = true
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update namespace set 
 			requested_version = $1,
 			explicit_deploy = $2,
-			updated_at = $3
-		where id = $4
+			required_approvals = $3,
+			deploy_order = $4,
+			updated_at = $5
+		where id = $6
 	`,
 		namespace.RequestedVersion,
 		namespace.ExplicitDeploy,
+		namespace.RequiredApprovals,
+		namespace.DeployOrder,
 		namespace.UpdatedAt,
 		namespace.ID)
 	if err != nil {
@@ -173,9 +181,9 @@ func (r *Repo) CreateNamespace(ctx context.Context, ns *Namespace) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
-	INSERT INTO namespace(name, alias, environment_id, requested_version, explicit_deploy, cluster_id, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+	INSERT INTO namespace(name, alias, environment_id, requested_version, explicit_deploy, cluster_id, required_approvals, deploy_order, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at
 	`,
 		ns.Name,
@@ -184,6 +192,8 @@ func (r *Repo) CreateNamespace(ctx context.Context, ns *Namespace) error {
 		ns.RequestedVersion,
 		ns.ExplicitDeploy,
 		ns.ClusterID,
+		ns.RequiredApprovals,
+		ns.DeployOrder,
 		ns.CreatedAt).
 		StructScan(ns)
 
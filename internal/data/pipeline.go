@@ -0,0 +1,263 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+// Pipeline is an ordered list of environment stages that a build is promoted through
+type Pipeline struct {
+	ID          int            `db:"id"`
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	Stages      json.Object    `db:"stages"`
+	CreatedAt   sql.NullTime   `db:"created_at"`
+	UpdatedAt   sql.NullTime   `db:"updated_at"`
+}
+
+type Pipelines []Pipeline
+
+func (r *Repo) Pipelines(ctx context.Context) (Pipelines, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, "select * from pipeline order by name")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var pipelines Pipelines
+	for rows.Next() {
+		var pipeline Pipeline
+		err = rows.StructScan(&pipeline)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	return pipelines, nil
+}
+
+func (r *Repo) PipelineByID(ctx context.Context, id int) (*Pipeline, error) {
+	var pipeline Pipeline
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from pipeline where id = $1", id)
+	err := row.StructScan(&pipeline)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("pipeline with id: %d not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &pipeline, nil
+}
+
+func (r *Repo) PipelineByName(ctx context.Context, name string) (*Pipeline, error) {
+	var pipeline Pipeline
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from pipeline where name = $1", name)
+	err := row.StructScan(&pipeline)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("pipeline with name: %s not found", name)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &pipeline, nil
+}
+
+func (r *Repo) CreatePipeline(ctx context.Context, p *Pipeline) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into pipeline(name, description, stages, created_at, updated_at)
+		values ($1, $2, $3, $4, $4)
+		returning *
+	`,
+		p.Name,
+		p.Description,
+		p.Stages,
+		now).StructScan(p)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) UpdatePipeline(ctx context.Context, p *Pipeline) error {
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		update pipeline set 
+			name = $1,
+			description = $2,
+			stages = $3,
+			updated_at = $4
+		where id = $5
+		returning *
+	`,
+		p.Name,
+		p.Description,
+		p.Stages,
+		time.Now().UTC(),
+		p.ID).StructScan(p)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return NotFoundErrorf("pipeline with id: %d not found", p.ID)
+		}
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeletePipeline(ctx context.Context, id int) error {
+	return r.deleteWithQuery(ctx, "pipeline", fmt.Sprintf("id = %d", id))
+}
+
+type PipelineRunState string
+
+const (
+	PipelineRunStateRunning          PipelineRunState = "running"
+	PipelineRunStateAwaitingApproval PipelineRunState = "awaiting_approval"
+	PipelineRunStateSoaking          PipelineRunState = "soaking"
+	PipelineRunStateCompleted        PipelineRunState = "completed"
+	PipelineRunStateFailed           PipelineRunState = "failed"
+	PipelineRunStateCancelled        PipelineRunState = "cancelled"
+)
+
+// PipelineRun is one build going through a pipeline, Stages is a copy of the pipeline's stages with their progress
+// so changing the pipeline doesn't change a run that's already started
+type PipelineRun struct {
+	ID          uuid.UUID        `db:"id"`
+	PipelineID  int              `db:"pipeline_id"`
+	State       PipelineRunState `db:"state"`
+	Stage       int              `db:"stage"`
+	Stages      json.Object      `db:"stages"`
+	Artifacts   json.Object      `db:"artifacts"`
+	User        string           `db:"user"`
+	StartedBy   string           `db:"started_by"`
+	ForceDeploy bool             `db:"force_deploy"`
+	Message     sql.NullString   `db:"message"`
+	SoakUntil   sql.NullTime     `db:"soak_until"`
+	CreatedAt   sql.NullTime     `db:"created_at"`
+	UpdatedAt   sql.NullTime     `db:"updated_at"`
+}
+
+// Active is true while the run can still move to another stage
+func (pr PipelineRun) Active() bool {
+	switch pr.State {
+	case PipelineRunStateRunning, PipelineRunStateAwaitingApproval, PipelineRunStateSoaking:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Repo) CreatePipelineRun(ctx context.Context, run *PipelineRun) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into pipeline_run(pipeline_id, state, stage, stages, artifacts, "user", started_by, force_deploy, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		returning *
+	`,
+		run.PipelineID,
+		run.State,
+		run.Stage,
+		run.Stages,
+		run.Artifacts,
+		run.User,
+		run.StartedBy,
+		run.ForceDeploy,
+		now).StructScan(run)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) PipelineRunByID(ctx context.Context, id uuid.UUID) (*PipelineRun, error) {
+	var run PipelineRun
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from pipeline_run where id = $1", id)
+	err := row.StructScan(&run)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("pipeline run with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &run, nil
+}
+
+// PipelineRuns returns up to limit runs, newest first
+func (r *Repo) PipelineRuns(ctx context.Context, limit int, whereArgs ...WhereArg) ([]PipelineRun, error) {
+	esql, args := CheckWhereArgs("select * from pipeline_run", whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s order by created_at desc limit %d", esql, limit), args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var runs []PipelineRun
+	for rows.Next() {
+		var run PipelineRun
+		err = rows.StructScan(&run)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// ActivePipelineRuns returns the runs that are waiting on their deployments or soak time, a run that's awaiting approval
+// only moves when it's approved
+func (r *Repo) ActivePipelineRuns(ctx context.Context) ([]PipelineRun, error) {
+	return r.PipelineRuns(ctx, maxActivePipelineRuns, WhereIn("state", []interface{}{PipelineRunStateRunning, PipelineRunStateSoaking}))
+}
+
+const maxActivePipelineRuns = 1000
+
+// UpdatePipelineRun saves the run's progress if it's still in the state and stage it was read in, it returns a
+// NotFoundError when something else moved it first
+func (r *Repo) UpdatePipelineRun(ctx context.Context, run *PipelineRun, fromState PipelineRunState, fromStage int) error {
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		update pipeline_run set 
+			state = $1,
+			stage = $2,
+			stages = $3,
+			message = $4,
+			soak_until = $5,
+			updated_at = $6
+		where id = $7 and state = $8 and stage = $9
+		returning *
+	`,
+		run.State,
+		run.Stage,
+		run.Stages,
+		run.Message,
+		run.SoakUntil,
+		time.Now().UTC(),
+		run.ID,
+		fromState,
+		fromStage).StructScan(run)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return NotFoundErrorf("pipeline run with id: %s, state: %s and stage: %d not found", run.ID.String(), fromState, fromStage)
+		}
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
@@ -46,7 +46,7 @@ func (r *Repo) RequestServiceArtifactByEnvironment(ctx context.Context, serviceN
 		return nil, errors.Wrap(err)
 	}
 	esql = r.db.Rebind(esql)
-	rows, err := r.db.QueryxContext(ctx, esql, args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql, args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -88,7 +88,7 @@ func (r *Repo) RequestJobArtifactByEnvironment(ctx context.Context, jobName, art
 		return nil, errors.Wrap(err)
 	}
 	esql = r.db.Rebind(esql)
-	rows, err := r.db.QueryxContext(ctx, esql, args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql, args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -0,0 +1,145 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+type RolloutState string
+
+const (
+	// RolloutStateCanary is waiting on the canary deployments to finish
+	RolloutStateCanary RolloutState = "canary"
+	// RolloutStateVerifying is waiting on the metrics check or a promote/abort request
+	RolloutStateVerifying RolloutState = "verifying"
+	// RolloutStateAborting is retried by the verifier until the canary deployments are cancelled or rolled back
+	RolloutStateAborting RolloutState = "aborting"
+	RolloutStatePromoted RolloutState = "promoted"
+	RolloutStateAborted   RolloutState = "aborted"
+)
+
+const (
+	RolloutStageCanary    = "canary"
+	RolloutStagePromotion = "promotion"
+	RolloutStageRollback  = "rollback"
+)
+
+// Rollout links the deployments of a canary plan, the plan is what's queued once the canary is promoted
+type Rollout struct {
+	ID              uuid.UUID      `db:"id"`
+	EnvironmentID   int            `db:"environment_id"`
+	State           RolloutState   `db:"state"`
+	Plan            json.Object    `db:"plan"`
+	User            string         `db:"user"`
+	Message         sql.NullString `db:"message"`
+	VerifyStartedAt sql.NullTime   `db:"verify_started_at"`
+	CreatedAt       sql.NullTime   `db:"created_at"`
+	UpdatedAt       sql.NullTime   `db:"updated_at"`
+}
+
+func (r *Repo) CreateRollout(ctx context.Context, rollout *Rollout) error {
+	now := time.Now().UTC()
+	rollout.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	rollout.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	if len(rollout.State) == 0 {
+		rollout.State = RolloutStateCanary
+	}
+
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into rollout(environment_id, state, plan, "user", created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6)
+		returning (id)
+		`, rollout.EnvironmentID, rollout.State, rollout.Plan, rollout.User, rollout.CreatedAt, rollout.UpdatedAt).
+		Scan(&rollout.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) RolloutByID(ctx context.Context, id uuid.UUID) (*Rollout, error) {
+	var rollout Rollout
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from rollout where id = $1", id)
+	err := row.StructScan(&rollout)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("rollout with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &rollout, nil
+}
+
+// ActiveRollouts returns the rollouts that haven't been promoted or aborted
+func (r *Repo) ActiveRollouts(ctx context.Context) ([]Rollout, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, "select * from rollout where state in ($1, $2, $3) order by created_at",
+		RolloutStateCanary, RolloutStateVerifying, RolloutStateAborting)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var rollouts []Rollout
+	for rows.Next() {
+		var rollout Rollout
+		err = rows.StructScan(&rollout)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	return rollouts, nil
+}
+
+// UpdateRolloutState moves the rollout from one of the from states to the supplied state, it returns a NotFoundError
+// when the rollout isn't in one of them so only one caller wins a transition
+func (r *Repo) UpdateRolloutState(ctx context.Context, id uuid.UUID, state RolloutState, message string, from ...RolloutState) (*Rollout, error) {
+	var rollout Rollout
+
+	var verifyStartedAt sql.NullTime
+	now := time.Now().UTC()
+	if state == RolloutStateVerifying {
+		verifyStartedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	esql, args, err := sqlx.In(`
+		update rollout set state = ?,
+		                   message = coalesce(?, message),
+		                   verify_started_at = coalesce(?, verify_started_at),
+		                   updated_at = ?
+		where id = ? and state in (?)
+		returning *
+		`, state, sql.NullString{String: message, Valid: len(message) > 0}, verifyStartedAt, now, id, from)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	row := r.conn(ctx).QueryRowxContext(ctx, r.db.Rebind(esql), args...)
+	err = row.StructScan(&rollout)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("rollout with id: %s and state in %v not found", id.String(), from)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &rollout, nil
+}
+
+func (r *Repo) DeploymentsByRolloutID(ctx context.Context, rolloutID uuid.UUID) ([]DeploymentHistory, error) {
+	return r.Deployments(ctx, true, maxRolloutDeployments, Where("d.rollout_id", rolloutID))
+}
+
+const maxRolloutDeployments = 1000
@@ -48,7 +48,7 @@ type Service struct {
 }
 
 func (r *Repo) UpdateDeployedServiceVersion(ctx context.Context, id int, version string) error {
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update service 
 		set deployed_version = $1, 
 		    updated_at = $2 
@@ -70,7 +70,7 @@ func (r *Repo) UpdateDeployedServiceVersion(ctx context.Context, id int, version
 }
 
 func (r *Repo) DeployedServicesByNamespaceID(ctx context.Context, namespaceID int) (DeployServices, error) {
-	rows, err := r.db.QueryxContext(ctx, `
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
 		select s.id as service_id,
 		   a.service_port,
 		   e.id as environment_id,
@@ -129,7 +129,7 @@ func (r *Repo) ServiceArtifacts(ctx context.Context, namespaceIDs []int) (Reques
 		return nil, errors.Wrap(err)
 	}
 	esql = r.db.Rebind(esql)
-	rows, err := r.db.QueryxContext(ctx, esql, args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql, args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -148,7 +148,7 @@ func (r *Repo) ServiceArtifacts(ctx context.Context, namespaceIDs []int) (Reques
 func (r *Repo) ServiceByName(ctx context.Context, name string, namespace string) (*Service, error) {
 	var service Service
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select s.id, 
 		       s.name, 
 		       s.namespace_id, 
@@ -178,7 +178,7 @@ func (r *Repo) ServiceByName(ctx context.Context, name string, namespace string)
 func (r *Repo) ServiceByID(ctx context.Context, id int) (*Service, error) {
 	var service Service
 
-	row := r.db.QueryRowxContext(ctx, `
+	row := r.conn(ctx).QueryRowxContext(ctx, `
 		select s.id, 
 		       s.name, 
 		       s.namespace_id, 
@@ -230,7 +230,7 @@ func (r *Repo) Services(ctx context.Context, whereArgs ...WhereArg) ([]Service,
 		    left join namespace n on s.namespace_id = n.id
 			left join artifact a on s.artifact_id = a.id
 		`, whereArgs)
-	rows, err := r.db.QueryxContext(ctx, esql+"order by s.name", args...)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+"order by s.name", args...)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -251,7 +251,7 @@ func (r *Repo) UpdateService(ctx context.Context, service *Service) error {
 	service.UpdatedAt.Time = time.Now().UTC()
 	service.UpdatedAt.Valid = true
 
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update service set 
 		   	name = $1, 
 			namespace_id = $2,
@@ -289,7 +289,7 @@ func (r *Repo) UpdateServiceCount(ctx context.Context, serviceID int, count int)
 	if count > 2 || count < 0 {
 		return errors.BadRequest("service count must be between > -1 and less than 3")
 	}
-	result, err := r.db.ExecContext(ctx, `
+	result, err := r.conn(ctx).ExecContext(ctx, `
 		update service set count = $1 where id = $2
 	`, count, serviceID)
 	if err != nil {
@@ -313,7 +313,7 @@ func (r *Repo) CreateService(ctx context.Context, model *Service) error {
 		Valid: true,
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
+	err := r.conn(ctx).QueryRowxContext(ctx, `
 	INSERT INTO service(
 				namespace_id,
 				artifact_id,
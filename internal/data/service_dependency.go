@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// ServiceDependency is a service that can't be deployed until the service or job it depends on has been deployed,
+// only one of DependsOnServiceID and DependsOnJobID is set
+type ServiceDependency struct {
+	ID                   int            `db:"id"`
+	ServiceID            int            `db:"service_id"`
+	DependsOnServiceID   sql.NullInt32  `db:"depends_on_service_id"`
+	DependsOnJobID       sql.NullInt32  `db:"depends_on_job_id"`
+	CreatedAt            sql.NullTime   `db:"created_at"`
+	ServiceName          string         `db:"service_name"`
+	NamespaceID          int            `db:"namespace_id"`
+	DependsOnServiceName sql.NullString `db:"depends_on_service_name"`
+	DependsOnJobName     sql.NullString `db:"depends_on_job_name"`
+}
+
+type ServiceDependencies []ServiceDependency
+
+const serviceDependencySelect = `
+	select sd.*,
+	       s.name as service_name,
+	       s.namespace_id,
+	       ds.name as depends_on_service_name,
+	       dj.name as depends_on_job_name
+	from service_dependency sd
+	    join service s on sd.service_id = s.id
+	    left join service ds on sd.depends_on_service_id = ds.id
+	    left join job dj on sd.depends_on_job_id = dj.id
+	`
+
+func (r *Repo) serviceDependencies(ctx context.Context, whereArgs ...WhereArg) (ServiceDependencies, error) {
+	esql, args := CheckWhereArgs(serviceDependencySelect, whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+" order by sd.id", args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var dependencies ServiceDependencies
+	for rows.Next() {
+		var dependency ServiceDependency
+		err = rows.StructScan(&dependency)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	return dependencies, nil
+}
+
+func (r *Repo) ServiceDependenciesByServiceID(ctx context.Context, serviceID int) (ServiceDependencies, error) {
+	return r.serviceDependencies(ctx, Where("sd.service_id", serviceID))
+}
+
+func (r *Repo) ServiceDependenciesByNamespaceID(ctx context.Context, namespaceID int) (ServiceDependencies, error) {
+	return r.serviceDependencies(ctx, Where("s.namespace_id", namespaceID))
+}
+
+func (r *Repo) ServiceDependencyByID(ctx context.Context, id int) (*ServiceDependency, error) {
+	var dependency ServiceDependency
+
+	esql, args := CheckWhereArgs(serviceDependencySelect, []WhereArg{Where("sd.id", id)})
+	row := r.conn(ctx).QueryRowxContext(ctx, esql, args...)
+	err := row.StructScan(&dependency)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("service dependency with id: %d not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &dependency, nil
+}
+
+func (r *Repo) CreateServiceDependency(ctx context.Context, d *ServiceDependency) error {
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into service_dependency(service_id, depends_on_service_id, depends_on_job_id, created_at)
+		values ($1, $2, $3, $4)
+		returning id, created_at
+	`,
+		d.ServiceID,
+		d.DependsOnServiceID,
+		d.DependsOnJobID,
+		time.Now().UTC()).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteServiceDependency(ctx context.Context, id int) error {
+	return r.deleteWithQuery(ctx, "service_dependency", fmt.Sprintf("id = %d", id))
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/unanet/go/pkg/errors"
+)
+
+type txKey struct{}
+
+// queryer is what the repo queries with, either the db or the transaction in the context
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
+// WithTx calls fn in a transaction. The repo's methods called with the context fn is given are part of the
+// transaction, which is committed when fn returns nil and rolled back otherwise. When the context already has a
+// transaction fn joins it
+func (r *Repo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.WrapTx(tx, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) conn(ctx context.Context) queryer {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
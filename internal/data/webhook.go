@@ -0,0 +1,396 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	goErrors "errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+)
+
+// Webhook is an environment (or namespace) level subscription to deployment events
+type Webhook struct {
+	ID            int           `db:"id"`
+	EnvironmentID int           `db:"environment_id"`
+	NamespaceID   sql.NullInt32 `db:"namespace_id"`
+	URL           string        `db:"url"`
+	Secret        string        `db:"secret"`
+	Disabled      bool          `db:"disabled"`
+	CreatedAt     sql.NullTime  `db:"created_at"`
+	UpdatedAt     sql.NullTime  `db:"updated_at"`
+}
+
+type Webhooks []Webhook
+
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryStatePending   WebhookDeliveryState = "pending"
+	WebhookDeliveryStateDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryStateFailed    WebhookDeliveryState = "failed"
+)
+
+type WebhookDelivery struct {
+	ID               uuid.UUID            `db:"id"`
+	WebhookID        sql.NullInt32        `db:"webhook_id"`
+	DeploymentID     uuid.NullUUID        `db:"deployment_id"`
+	URL              string               `db:"url"`
+	Event            string               `db:"event"`
+	Payload          json.Object          `db:"payload"`
+	State            WebhookDeliveryState `db:"state"`
+	Attempts         int                  `db:"attempts"`
+	NextAttemptAt    sql.NullTime         `db:"next_attempt_at"`
+	LastResponseCode sql.NullInt32        `db:"last_response_code"`
+	LastError        sql.NullString       `db:"last_error"`
+	DeliveredAt      sql.NullTime         `db:"delivered_at"`
+	CreatedAt        sql.NullTime         `db:"created_at"`
+	UpdatedAt        sql.NullTime         `db:"updated_at"`
+	// Secret is the subscription's secret, it's only populated for deliveries claimed for sending
+	Secret string `db:"secret"`
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+type WebhookDeliveryAttempt struct {
+	ID           int64          `db:"id"`
+	DeliveryID   uuid.UUID      `db:"delivery_id"`
+	Attempt      int            `db:"attempt"`
+	ResponseCode sql.NullInt32  `db:"response_code"`
+	Error        sql.NullString `db:"error"`
+	DurationMS   int            `db:"duration_ms"`
+	CreatedAt    sql.NullTime   `db:"created_at"`
+}
+
+type WebhookDeliveryAttempts []WebhookDeliveryAttempt
+
+func (r *Repo) Webhooks(ctx context.Context) (Webhooks, error) {
+	return r.webhooks(ctx)
+}
+
+// WebhooksByNamespace returns the enabled webhooks for the environment that either apply to every namespace or to the one supplied
+func (r *Repo) WebhooksByNamespace(ctx context.Context, environmentID int, namespaceID int) (Webhooks, error) {
+	return r.webhooks(ctx,
+		Where("environment_id", environmentID),
+		Where("disabled", false),
+		WhereRaw("(namespace_id is null or namespace_id = ?)", namespaceID))
+}
+
+func (r *Repo) webhooks(ctx context.Context, whereArgs ...WhereArg) (Webhooks, error) {
+	esql, args := CheckWhereArgs("select * from webhook", whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, esql+" order by id", args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var webhooks Webhooks
+	for rows.Next() {
+		var webhook Webhook
+		err = rows.StructScan(&webhook)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (r *Repo) WebhookByID(ctx context.Context, id int) (*Webhook, error) {
+	var webhook Webhook
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from webhook where id = $1", id)
+	err := row.StructScan(&webhook)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("webhook with id: %d not found", id)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &webhook, nil
+}
+
+func (r *Repo) CreateWebhook(ctx context.Context, w *Webhook) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into webhook(environment_id, namespace_id, url, secret, disabled, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $6)
+		returning *
+	`,
+		w.EnvironmentID,
+		w.NamespaceID,
+		w.URL,
+		w.Secret,
+		w.Disabled,
+		now).StructScan(w)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateWebhook updates the webhook, the secret is left alone when it's empty
+func (r *Repo) UpdateWebhook(ctx context.Context, w *Webhook) error {
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		update webhook set 
+			environment_id = $1, 
+			namespace_id = $2, 
+			url = $3, 
+			secret = coalesce(nullif($4, ''), secret), 
+			disabled = $5, 
+			updated_at = $6
+		where id = $7
+		returning *
+	`,
+		w.EnvironmentID,
+		w.NamespaceID,
+		w.URL,
+		w.Secret,
+		w.Disabled,
+		time.Now().UTC(),
+		w.ID).StructScan(w)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return NotFoundErrorf("webhook with id: %d not found", w.ID)
+		}
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteWebhook(ctx context.Context, id int) error {
+	return r.deleteWithQuery(ctx, "webhook", fmt.Sprintf("id = %d", id))
+}
+
+func (r *Repo) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	now := time.Now().UTC()
+	err := r.conn(ctx).QueryRowxContext(ctx, `
+		insert into webhook_delivery(webhook_id, deployment_id, url, event, payload, state, next_attempt_at, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+		returning *
+	`,
+		d.WebhookID,
+		d.DeploymentID,
+		d.URL,
+		d.Event,
+		d.Payload,
+		WebhookDeliveryStatePending,
+		now).StructScan(d)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due, they're pushed out by lease
+// so another api instance doesn't send the same delivery while it's being sent
+func (r *Repo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (WebhookDeliveries, error) {
+	now := time.Now().UTC()
+	rows, err := r.conn(ctx).QueryxContext(ctx, `
+		with claimed as (
+			update webhook_delivery set next_attempt_at = $1, updated_at = $2
+			where id in (
+				select id from webhook_delivery 
+				where state = $3 and next_attempt_at <= $2 
+				order by next_attempt_at
+				limit $4
+				for update skip locked
+			)
+			returning *
+		)
+		select c.*, coalesce(w.secret, '') as secret from claimed c
+		    left join webhook w on c.webhook_id = w.id
+	`, now.Add(lease), now, WebhookDeliveryStatePending, limit)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var deliveries WebhookDeliveries
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err = rows.StructScan(&delivery)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimWebhookDelivery claims a single delivery so it can be sent right away
+func (r *Repo) ClaimWebhookDelivery(ctx context.Context, id uuid.UUID, lease time.Duration) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	now := time.Now().UTC()
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		with claimed as (
+			update webhook_delivery set next_attempt_at = $1, updated_at = $2
+			where id = $3 and state = $4 and next_attempt_at <= $2
+			returning *
+		)
+		select c.*, coalesce(w.secret, '') as secret from claimed c
+		    left join webhook w on c.webhook_id = w.id
+	`, now.Add(lease), now, id, WebhookDeliveryStatePending)
+	err := row.StructScan(&delivery)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("due webhook delivery with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &delivery, nil
+}
+
+// RecordWebhookDeliveryAttempt stores the attempt and updates the delivery with the outcome
+func (r *Repo) RecordWebhookDeliveryAttempt(ctx context.Context, d *WebhookDelivery, a *WebhookDeliveryAttempt) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	a.CreatedAt = sql.NullTime{
+		Time:  now,
+		Valid: true,
+	}
+	err = tx.QueryRowxContext(ctx, `
+		insert into webhook_delivery_attempt(delivery_id, attempt, response_code, error, duration_ms, created_at)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id
+	`, d.ID, a.Attempt, a.ResponseCode, a.Error, a.DurationMS, a.CreatedAt).Scan(&a.ID)
+	if err != nil {
+		return errors.WrapTx(tx, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		update webhook_delivery set 
+			state = $1, 
+			attempts = $2, 
+			next_attempt_at = $3, 
+			last_response_code = $4, 
+			last_error = $5, 
+			delivered_at = $6, 
+			updated_at = $7
+		where id = $8
+	`, d.State, d.Attempts, d.NextAttemptAt, a.ResponseCode, a.Error, d.DeliveredAt, now, d.ID)
+	if err != nil {
+		return errors.WrapTx(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.WrapTx(tx, err)
+	}
+
+	return nil
+}
+
+// RedeliverWebhookDelivery puts the delivery back in the pending state so it's sent again
+func (r *Repo) RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	now := time.Now().UTC()
+	row := r.conn(ctx).QueryRowxContext(ctx, `
+		update webhook_delivery set state = $1, attempts = 0, next_attempt_at = $2, delivered_at = null, updated_at = $2
+		where id = $3
+		returning *
+	`, WebhookDeliveryStatePending, now, id)
+	err := row.StructScan(&delivery)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("webhook delivery with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &delivery, nil
+}
+
+func (r *Repo) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	row := r.conn(ctx).QueryRowxContext(ctx, "select * from webhook_delivery where id = $1", id)
+	err := row.StructScan(&delivery)
+	if err != nil {
+		if goErrors.Is(err, sql.ErrNoRows) {
+			return nil, NotFoundErrorf("webhook delivery with id: %s not found", id.String())
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	return &delivery, nil
+}
+
+// WebhookDeliveries returns the latest deliveries matching the where args, the payload isn't selected
+// since it can be a whole deployment plan, use WebhookDeliveryByID to get it
+func (r *Repo) WebhookDeliveries(ctx context.Context, limit int, whereArgs ...WhereArg) (WebhookDeliveries, error) {
+	esql, args := CheckWhereArgs(`
+		select id, webhook_id, deployment_id, url, event, state, attempts, next_attempt_at,
+		       last_response_code, last_error, delivered_at, created_at, updated_at
+		from webhook_delivery`, whereArgs)
+	rows, err := r.conn(ctx).QueryxContext(ctx, fmt.Sprintf("%s order by created_at desc limit %d", esql, limit), args...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var deliveries WebhookDeliveries
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err = rows.StructScan(&delivery)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// DeleteWebhookDeliveriesBefore deletes the finished deliveries that haven't been updated since before,
+// their attempts are deleted with them
+func (r *Repo) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		delete from webhook_delivery where state != $1 and updated_at < $2
+	`, WebhookDeliveryStatePending, before)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	return affected, nil
+}
+
+func (r *Repo) WebhookDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) (WebhookDeliveryAttempts, error) {
+	rows, err := r.conn(ctx).QueryxContext(ctx, "select * from webhook_delivery_attempt where delivery_id = $1 order by id", deliveryID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var attempts WebhookDeliveryAttempts
+	for rows.Next() {
+		var attempt WebhookDeliveryAttempt
+		err = rows.StructScan(&attempt)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, nil
+}
@@ -51,6 +51,20 @@ func OrWhere(key string, value interface{}) WhereArg {
 	}
 }
 
+// WhereCompare uses the supplied comparison operator, ex: WhereCompare("d.created_at", ">=", t)
+func WhereCompare(key string, operator string, value interface{}) WhereArg {
+	return func(clause *WhereClause) {
+		clause.AddClause(fmt.Sprintf("%s%s?", key, operator), ANDLogicalOperator, value)
+	}
+}
+
+// WhereRaw adds the clause as is, each ? is replaced with the matching value
+func WhereRaw(sql string, values ...interface{}) WhereArg {
+	return func(clause *WhereClause) {
+		clause.AddClause(sql, ANDLogicalOperator, values...)
+	}
+}
+
 type Clause struct {
 	operator LogicalOperator
 	value    string
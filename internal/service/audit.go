@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/unanet/go/pkg/log"
+)
+
+type auditRequestKey struct{}
+
+const (
+	// AdminAuditUser is recorded for requests made with the admin token
+	AdminAuditUser = "admin"
+	// UnknownAuditUser is recorded when the token's claims don't identify a user
+	UnknownAuditUser = "unknown"
+)
+
+// AuditRequest is who made a request and where, it's recorded with each change the request makes
+type AuditRequest struct {
+	User      string
+	RequestID string
+	Method    string
+	Route     string
+}
+
+// WithAuditUser adds the authenticated user to the context
+func WithAuditUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, auditRequestKey{}, user)
+}
+
+// SharedAuditUser is true when the user doesn't identify a single person
+func SharedAuditUser(user string) bool {
+	return strings.EqualFold(user, AdminAuditUser) || strings.EqualFold(user, UnknownAuditUser)
+}
+
+// AuditRequestFromContext returns the request the context belongs to. The route is the pattern that matched, e.g.
+// /services/{service}, the user is empty when the change wasn't made through the api
+func AuditRequestFromContext(ctx context.Context) AuditRequest {
+	user, _ := ctx.Value(auditRequestKey{}).(string)
+	request := AuditRequest{
+		User:      user,
+		RequestID: log.GetReqID(ctx),
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		request.Method = rctx.RouteMethod
+		request.Route = rctx.RoutePattern()
+	}
+
+	return request
+}
@@ -0,0 +1,206 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	auditEntityMetadata             = "metadata"
+	auditEntityMetadataServiceMap   = "metadata_service_map"
+	auditEntityMetadataJobMap       = "metadata_job_map"
+	auditEntityDefinition           = "definition"
+	auditEntityDefinitionServiceMap = "definition_service_map"
+	auditEntityDefinitionJobMap     = "definition_job_map"
+	auditEntityDefinitionType       = "definition_type"
+	auditEntityService              = "service"
+	auditEntityJob                  = "job"
+	auditEntityNamespace            = "namespace"
+	auditEntityEnvironment          = "environment"
+	auditEntityCluster              = "cluster"
+	auditEntityFeed                 = "feed"
+	auditEntityEnvironmentFeedMap   = "environment_feed_map"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 500
+
+	// auditSystemUser is recorded when a change isn't made by an authenticated request
+	auditSystemUser = "system"
+)
+
+// Audit searches the audit trail, the newest entries first. Results are paged with an opaque cursor
+func (m *Manager) Audit(ctx context.Context, q eve.AuditQuery) (*eve.AuditPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	var whereArgs []data.WhereArg
+	if q.Entity != "" {
+		whereArgs = append(whereArgs, data.Where("entity", q.Entity))
+	}
+
+	if q.EntityID != "" {
+		whereArgs = append(whereArgs, data.Where("entity_id", q.EntityID))
+	}
+
+	if q.User != "" {
+		whereArgs = append(whereArgs, data.Where(`"user"`, q.User))
+	}
+
+	if q.Action != "" {
+		whereArgs = append(whereArgs, data.Where("action", q.Action))
+	}
+
+	if q.RequestID != "" {
+		whereArgs = append(whereArgs, data.Where("request_id", q.RequestID))
+	}
+
+	if q.From != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("created_at", ">=", q.From.UTC()))
+	}
+
+	if q.To != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("created_at", "<", q.To.UTC()))
+	}
+
+	if q.Cursor != "" {
+		id, err := decodeAuditCursor(q.Cursor)
+		if err != nil {
+			return nil, errors.BadRequest("invalid cursor")
+		}
+		whereArgs = append(whereArgs, data.WhereCompare("id", "<", id))
+	}
+
+	// we fetch one more than the limit to know if there's another page
+	dbEntries, err := m.repo.AuditEntries(ctx, limit+1, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	page := eve.AuditPage{
+		Entries: make([]eve.AuditEntry, 0),
+	}
+
+	if len(dbEntries) > limit {
+		dbEntries = dbEntries[:limit]
+		page.NextCursor = encodeAuditCursor(dbEntries[limit-1].ID)
+	}
+
+	for _, x := range dbEntries {
+		page.Entries = append(page.Entries, fromDataAuditEntry(x))
+	}
+	return &page, nil
+}
+
+// audit records a change along with who made it, before is nil when the entity was created and after is nil when it
+// was deleted. It's called in the transaction that made the change so the change isn't saved without its entry
+func (m *Manager) audit(ctx context.Context, entity string, entityID interface{}, action eve.AuditAction, before, after interface{}) error {
+	entry := newAuditEntry(ctx, entity, entityID, action, before, after)
+	if err := m.repo.CreateAuditEntry(ctx, &entry); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func newAuditEntry(ctx context.Context, entity string, entityID interface{}, action eve.AuditAction, before, after interface{}) data.AuditEntry {
+	request := service.AuditRequestFromContext(ctx)
+	if request.User == "" {
+		request.User = auditSystemUser
+	}
+
+	return data.AuditEntry{
+		Entity:    entity,
+		EntityID:  fmt.Sprint(entityID),
+		Action:    string(action),
+		User:      request.User,
+		RequestID: sql.NullString{String: request.RequestID, Valid: len(request.RequestID) > 0},
+		Method:    sql.NullString{String: request.Method, Valid: len(request.Method) > 0},
+		Route:     sql.NullString{String: request.Route, Valid: len(request.Route) > 0},
+		Before:    auditValue(before),
+		After:     auditValue(after),
+	}
+}
+
+// auditNotFound is the before value of an entity that doesn't exist, any other error is returned
+func auditNotFound(err error) (interface{}, error) {
+	if _, ok := err.(data.NotFoundError); ok {
+		return nil, nil
+	}
+	return nil, errors.Wrap(err)
+}
+
+// upsertAction is the action an upsert took, depending on whether the entity already existed
+func upsertAction(before interface{}) eve.AuditAction {
+	if before == nil {
+		return eve.AuditActionCreate
+	}
+	return eve.AuditActionUpdate
+}
+
+func auditValue(v interface{}) json.Object {
+	if v == nil {
+		return nil
+	}
+	return json.StructToJsonObjectOrEmpty(v)
+}
+
+func auditMapID(id int, description string) string {
+	return fmt.Sprintf("%d/%s", id, description)
+}
+
+func encodeAuditCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeAuditCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	id, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return id, nil
+}
+
+func fromDataAuditEntry(dbModel data.AuditEntry) eve.AuditEntry {
+	entry := eve.AuditEntry{
+		ID:        dbModel.ID,
+		Entity:    dbModel.Entity,
+		EntityID:  dbModel.EntityID,
+		Action:    eve.AuditAction(dbModel.Action),
+		User:      dbModel.User,
+		RequestID: dbModel.RequestID.String,
+		Method:    dbModel.Method.String,
+		Route:     dbModel.Route.String,
+		CreatedAt: dbModel.CreatedAt.Time,
+	}
+
+	if before := dbModel.Before.AsMapOrEmpty(); len(before) > 0 {
+		entry.Before = before
+	}
+
+	if after := dbModel.After.AsMapOrEmpty(); len(after) > 0 {
+		entry.After = after
+	}
+
+	return entry
+}
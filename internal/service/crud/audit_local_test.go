@@ -0,0 +1,52 @@
+// +build local
+
+package crud_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/internal/config"
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/internal/service/crud"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func getManager(t *testing.T) *crud.Manager {
+	db, err := data.GetDBWithTimeout(config.GetDBConfig().DbConnectionString(), 10*time.Second)
+	require.NoError(t, err)
+	return crud.NewManager(data.NewRepo(db), nil)
+}
+
+func TestManager_AuditFeedMutations(t *testing.T) {
+	m := getManager(t)
+	ctx := service.WithAuditUser(context.Background(), "jdoe")
+
+	feed := eve.Feed{
+		ID:       100000 + int(time.Now().Unix()%100000),
+		Name:     "audit-test",
+		FeedType: "generic",
+	}
+	require.NoError(t, m.CreateFeed(ctx, &feed))
+
+	feed.Alias = "audit"
+	require.NoError(t, m.UpdateFeed(ctx, &feed))
+	require.NoError(t, m.DeleteFeed(ctx, feed.ID))
+
+	page, err := m.Audit(context.Background(), eve.AuditQuery{Entity: "feed", EntityID: fmt.Sprint(feed.ID)})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 3)
+
+	// the newest entry is first
+	for i, action := range []eve.AuditAction{eve.AuditActionDelete, eve.AuditActionUpdate, eve.AuditActionCreate} {
+		require.Equal(t, action, page.Entries[i].Action)
+		require.Equal(t, "jdoe", page.Entries[i].User)
+	}
+	require.Equal(t, "audit", page.Entries[1].After["alias"])
+	require.Equal(t, "", page.Entries[1].Before["alias"])
+}
@@ -0,0 +1,96 @@
+package crud
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestManager_fromDataAuditEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   eve.AuditEntry
+	}{
+		{
+			name:  "created",
+			after: eve.Feed{ID: 1, Name: "int"},
+			want: eve.AuditEntry{
+				Entity:   auditEntityFeed,
+				EntityID: "1",
+				Action:   eve.AuditActionCreate,
+				After:    map[string]interface{}{"id": float64(1), "name": "int", "promotion_order": float64(0), "feed_type": "", "alias": ""},
+			},
+		},
+		{
+			name:   "deleted",
+			before: eve.Feed{ID: 1, Name: "int"},
+			want: eve.AuditEntry{
+				Entity:   auditEntityFeed,
+				EntityID: "1",
+				Action:   eve.AuditActionDelete,
+				Before:   map[string]interface{}{"id": float64(1), "name": "int", "promotion_order": float64(0), "feed_type": "", "alias": ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromDataAuditEntry(data.AuditEntry{
+				Entity:   auditEntityFeed,
+				EntityID: "1",
+				Action:   string(tt.want.Action),
+				Before:   auditValue(tt.before),
+				After:    auditValue(tt.after),
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fromDataAuditEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_auditCursor(t *testing.T) {
+	id, err := decodeAuditCursor(encodeAuditCursor(42))
+	if err != nil || id != 42 {
+		t.Errorf("decodeAuditCursor() = %v, %v, want 42", id, err)
+	}
+
+	if _, err = decodeAuditCursor("not a cursor"); err == nil {
+		t.Errorf("decodeAuditCursor() expected an error")
+	}
+}
+
+func TestManager_newAuditEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "authenticated",
+			ctx:  service.WithAuditUser(context.Background(), "jdoe"),
+			want: "jdoe",
+		},
+		{
+			name: "not through the api",
+			ctx:  context.Background(),
+			want: auditSystemUser,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAuditEntry(tt.ctx, auditEntityFeed, 1, eve.AuditActionDelete, eve.Feed{ID: 1}, nil)
+			if got.User != tt.want {
+				t.Errorf("newAuditEntry() user = %v, want %v", got.User, tt.want)
+			}
+			if got.EntityID != "1" || got.Before == nil || got.After != nil {
+				t.Errorf("newAuditEntry() = %v", got)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package crud
 
 import (
 	"context"
+	"strconv"
+
 	"github.com/unanet/eve/internal/data"
 	"github.com/unanet/eve/pkg/eve"
 	"github.com/unanet/go/pkg/errors"
@@ -17,33 +19,63 @@ func (m *Manager) Clusters(ctx context.Context) (models []eve.Cluster, err error
 }
 
 func (m *Manager) CreateCluster(ctx context.Context, model *eve.Cluster) error {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataCluster(*model)
+		if err := m.repo.CreateCluster(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	dbModel := toDataCluster(*model)
-	if err := m.repo.CreateCluster(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
-
-	model.ID = dbModel.ID
-	model.CreatedAt = dbModel.CreatedAt.Time
-	model.UpdatedAt = dbModel.UpdatedAt.Time
+		model.ID = dbModel.ID
+		model.CreatedAt = dbModel.CreatedAt.Time
+		model.UpdatedAt = dbModel.UpdatedAt.Time
 
-	return nil
+		return m.audit(ctx, auditEntityCluster, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) UpdateCluster(ctx context.Context, model *eve.Cluster) (err error) {
-	dbModel := toDataCluster(*model)
-	if err := m.repo.UpdateCluster(ctx, &dbModel); err != nil {
-		return err
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		var before interface{}
+		if id, err := strconv.Atoi(model.ID); err == nil {
+			if before, err = m.auditCluster(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		dbModel := toDataCluster(*model)
+		if err := m.repo.UpdateCluster(ctx, &dbModel); err != nil {
+			return err
+		}
 
-	model.CreatedAt = dbModel.CreatedAt.Time
-	model.UpdatedAt = dbModel.UpdatedAt.Time
+		model.CreatedAt = dbModel.CreatedAt.Time
+		model.UpdatedAt = dbModel.UpdatedAt.Time
 
-	return nil
+		return m.audit(ctx, auditEntityCluster, model.ID, eve.AuditActionUpdate, before, model)
+	})
 }
 
 func (m *Manager) DeleteCluster(ctx context.Context, id int) (err error) {
-	return m.repo.DeleteCluster(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditCluster(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = m.repo.DeleteCluster(ctx, id); err != nil {
+			return err
+		}
+
+		return m.audit(ctx, auditEntityCluster, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditCluster is the cluster before it's changed, nil when it doesn't exist
+func (m *Manager) auditCluster(ctx context.Context, id int) (interface{}, error) {
+	dbModel, err := m.repo.ClusterByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataClusterToCluster(*dbModel), nil
 }
 
 func fromDataClusterList(clusters []data.Cluster) []eve.Cluster {
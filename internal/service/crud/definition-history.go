@@ -0,0 +1,133 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	defaultDefinitionHistoryLimit = 100
+	maxDefinitionHistoryLimit     = 500
+)
+
+// DefinitionHistory returns the definition's revisions, the newest first. Results are paged with an opaque cursor
+func (m *Manager) DefinitionHistory(ctx context.Context, q eve.DefinitionHistoryQuery) (*eve.DefinitionHistoryPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultDefinitionHistoryLimit
+	}
+	if limit > maxDefinitionHistoryLimit {
+		limit = maxDefinitionHistoryLimit
+	}
+
+	whereArgs := []data.WhereArg{data.Where("definition_id", q.DefinitionID)}
+	if q.Cursor != "" {
+		revision, err := decodeRevisionCursor(q.Cursor)
+		if err != nil {
+			return nil, errors.BadRequest("invalid cursor")
+		}
+		whereArgs = append(whereArgs, data.WhereCompare("revision", "<", revision))
+	}
+
+	// we fetch one more than the limit to know if there's another page
+	dbResults, err := m.repo.DefinitionHistory(ctx, limit+1, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	if len(dbResults) == 0 && q.Cursor == "" {
+		return nil, errors.NotFoundf("definition: %d doesn't have any history", q.DefinitionID)
+	}
+
+	page := eve.DefinitionHistoryPage{
+		History: make([]eve.DefinitionHistory, 0),
+	}
+
+	if len(dbResults) > limit {
+		dbResults = dbResults[:limit]
+		page.NextCursor = encodeRevisionCursor(dbResults[limit-1].Revision)
+	}
+
+	page.History = append(page.History, fromDataDefinitionHistoryList(dbResults)...)
+	return &page, nil
+}
+
+// DiffDefinitionHistory compares the data of two of the definition's revisions, the current data is used when the to
+// revision is 0
+func (m *Manager) DiffDefinitionHistory(ctx context.Context, definitionID int, fromRevision int, toRevision int) (*eve.DefinitionDiff, error) {
+	from, err := m.repo.DefinitionHistoryByRevision(ctx, definitionID, fromRevision)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var to map[string]interface{}
+	if toRevision == 0 {
+		definition, dErr := m.repo.GetDefinition(ctx, definitionID)
+		if dErr != nil {
+			return nil, service.CheckForNotFoundError(dErr)
+		}
+		to = definition.Data.AsMapOrEmpty()
+	} else {
+		toHistory, hErr := m.repo.DefinitionHistoryByRevision(ctx, definitionID, toRevision)
+		if hErr != nil {
+			return nil, service.CheckForNotFoundError(hErr)
+		}
+		to = toHistory.Data.AsMapOrEmpty()
+	}
+
+	diff := eve.NewDefinitionDiff(fromDataDefinitionHistory(*from), toRevision, to)
+	return &diff, nil
+}
+
+// RestoreDefinition replaces the definition's data with the data of one of its revisions, the restore is added to the
+// history as a new revision
+func (m *Manager) RestoreDefinition(ctx context.Context, definitionID int, revision int) (*eve.Definition, error) {
+	definition, err := m.repo.GetDefinition(ctx, definitionID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	history, err := m.repo.DefinitionHistoryByRevision(ctx, definitionID, revision)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	restored := fromDataDefinition(*definition)
+	restored.Data = history.Data.AsMapOrEmpty()
+	if err = m.CreateDefinition(ctx, &restored); err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
+
+func fromDataDefinitionHistory(dbModel data.DefinitionHistory) eve.DefinitionHistory {
+	deletedTime := &dbModel.Deleted.Time
+	if !dbModel.Deleted.Valid {
+		deletedTime = nil
+	}
+
+	return eve.DefinitionHistory{
+		Revision:     dbModel.Revision,
+		DefinitionID: dbModel.DefinitionID,
+		Description:  dbModel.Description,
+		Data:         dbModel.Data.AsMapOrEmpty(),
+		Created:      dbModel.Created.Time,
+		CreatedBy:    dbModel.CreatedBy,
+		Deleted:      deletedTime,
+		DeletedBy:    dbModel.DeletedBy,
+	}
+}
+
+func fromDataDefinitionHistoryList(dbModels []data.DefinitionHistory) []eve.DefinitionHistory {
+	var list []eve.DefinitionHistory
+	for _, x := range dbModels {
+		list = append(list, fromDataDefinitionHistory(x))
+	}
+	return list
+}
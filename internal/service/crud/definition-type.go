@@ -2,6 +2,10 @@ package crud
 
 import (
 	"context"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
 	"github.com/unanet/eve/internal/data"
 	"github.com/unanet/eve/pkg/eve"
 )
@@ -16,29 +20,89 @@ func (m *Manager) DefinitionTypes(ctx context.Context) (models []eve.DefinitionT
 }
 
 func (m *Manager) CreateDefinitionType(ctx context.Context, model *eve.DefinitionType) error {
-	dbModel := toDataDefinitionType(*model)
-	if err := m.repo.CreateDefinitionType(ctx, &dbModel); err != nil {
-		return err
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataDefinitionType(*model)
+		if err := m.repo.CreateDefinitionType(ctx, &dbModel); err != nil {
+			return err
+		}
 
-	model.ID = dbModel.ID
-	return nil
+		model.ID = dbModel.ID
+
+		return m.audit(ctx, auditEntityDefinitionType, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) UpdateDefinitionType(ctx context.Context, model *eve.DefinitionType) (err error) {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionType(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+
+		dbModel := toDataDefinitionType(*model)
+		if err := m.repo.UpdateDefinitionType(ctx, &dbModel); err != nil {
+			return err
+		}
+
+		model.CreatedAt = dbModel.CreatedAt.Time
+
+		return m.audit(ctx, auditEntityDefinitionType, model.ID, eve.AuditActionUpdate, before, model)
+	})
+}
+
+// DefinitionSchemas returns the schemas the merged definition results are validated with when a plan is created
+func (m *Manager) DefinitionSchemas(ctx context.Context) (eve.DefinitionSchemas, error) {
+	dbModels, err := m.repo.DefinitionTypes(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
 
-	dbModel := toDataDefinitionType(*model)
-	if err := m.repo.UpdateDefinitionType(ctx, &dbModel); err != nil {
-		return err
+	schemas := make(eve.DefinitionSchemas)
+	for _, x := range fromDataDefinitionTypeList(dbModels) {
+		if len(x.Schema) == 0 {
+			continue
+		}
+		schemas[x.ResultKey()] = append(schemas[x.ResultKey()], x.Schema)
 	}
+	return schemas, nil
+}
 
-	model.CreatedAt = dbModel.CreatedAt.Time
+// validateDefinitionData validates the data of a single definition with the schema of its type
+func (m *Manager) validateDefinitionData(ctx context.Context, definitionTypeID int, definitionData map[string]interface{}) error {
+	definitionType, err := m.repo.DefinitionTypeByID(ctx, definitionTypeID)
+	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return errors.BadRequestf("definition type: %d not found", definitionTypeID)
+		}
+		return errors.Wrap(err)
+	}
 
-	return nil
+	schema := eve.DefinitionSchema(definitionType.Schema.AsMapOrEmpty())
+	return eve.NewSchemaValidationError("the definition doesn't match the schema of its definition type", schema.ValidatePartial(definitionData))
 }
 
 func (m *Manager) DeleteDefinitionType(ctx context.Context, id int) (err error) {
-	return m.repo.DeleteDefinitionType(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionType(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = m.repo.DeleteDefinitionType(ctx, id); err != nil {
+			return err
+		}
+
+		return m.audit(ctx, auditEntityDefinitionType, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditDefinitionType is the definition type before it's changed, nil when it doesn't exist
+func (m *Manager) auditDefinitionType(ctx context.Context, id int) (interface{}, error) {
+	dbModel, err := m.repo.DefinitionTypeByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataDefinitionTypeToDefinitionType(*dbModel), nil
 }
 
 func fromDataDefinitionTypeList(artifacts []data.DefinitionType) []eve.DefinitionType {
@@ -58,6 +122,7 @@ func fromDataDefinitionTypeToDefinitionType(dbM data.DefinitionType) eve.Definit
 		Version:         dbM.Version,
 		Kind:            dbM.Kind,
 		DefinitionOrder: dbM.DefinitionOrder,
+		Schema:          dbM.Schema.AsMapOrEmpty(),
 		CreatedAt:       dbM.CreatedAt.Time,
 		UpdatedAt:       dbM.UpdatedAt.Time,
 	}
@@ -72,5 +137,6 @@ func toDataDefinitionType(dbM eve.DefinitionType) data.DefinitionType {
 		Version:         dbM.Version,
 		Kind:            dbM.Kind,
 		DefinitionOrder: dbM.DefinitionOrder,
+		Schema:          json.FromMapOrEmpty(dbM.Schema),
 	}
 }
@@ -209,47 +209,137 @@ func (m Manager) Definitions(ctx context.Context) ([]eve.Definition, error) {
 }
 
 func (m Manager) UpsertMergeDefinition(ctx context.Context, def *eve.Definition) error {
-	dataDefinition := toDataDefinition(*def)
-	err := m.repo.UpsertMergeDefinition(ctx, &dataDefinition)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		// the data is merged with the existing definition's top level keys, which is what's validated
+		definitionTypeID, merged := def.DefinitionTypeID, map[string]interface{}(def.Data)
+		existing, err := m.existingDefinition(ctx, def.Description)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			definitionTypeID = existing.DefinitionTypeID
+			merged = existing.Data.AsMapOrEmpty()
+			for k, v := range def.Data {
+				merged[k] = v
+			}
+		}
+
+		if err = m.validateDefinitionData(ctx, definitionTypeID, merged); err != nil {
+			return err
+		}
+
+		dataDefinition := toDataDefinition(*def)
+		err = m.repo.UpsertMergeDefinition(ctx, &dataDefinition)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		def.UpdatedAt = dataDefinition.UpdatedAt.Time
+		def.CreatedAt = dataDefinition.CreatedAt.Time
+		def.ID = dataDefinition.ID
+		def.Data = dataDefinition.Data.AsMapOrEmpty()
 
-	def.UpdatedAt = dataDefinition.UpdatedAt.Time
-	def.CreatedAt = dataDefinition.CreatedAt.Time
-	def.ID = dataDefinition.ID
-	def.Data = dataDefinition.Data.AsMapOrEmpty()
-	return nil
+		before := auditExistingDefinition(existing)
+		return m.audit(ctx, auditEntityDefinition, def.ID, upsertAction(before), before, def)
+	})
 }
 
 func (m Manager) CreateDefinition(ctx context.Context, def *eve.Definition) error {
-	dataDefinition := toDataDefinition(*def)
-	err := m.repo.UpsertDefinition(ctx, &dataDefinition)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		definitionTypeID := def.DefinitionTypeID
+		existing, err := m.existingDefinition(ctx, def.Description)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			definitionTypeID = existing.DefinitionTypeID
+		}
+
+		if err = m.validateDefinitionData(ctx, definitionTypeID, def.Data); err != nil {
+			return err
+		}
+
+		dataDefinition := toDataDefinition(*def)
+		err = m.repo.UpsertDefinition(ctx, &dataDefinition)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		def.UpdatedAt = dataDefinition.UpdatedAt.Time
+		def.CreatedAt = dataDefinition.CreatedAt.Time
+		def.ID = dataDefinition.ID
+
+		before := auditExistingDefinition(existing)
+		return m.audit(ctx, auditEntityDefinition, def.ID, upsertAction(before), before, def)
+	})
+}
+
+// existingDefinition returns the definition an upsert would update, the update keeps its definition type
+func (m Manager) existingDefinition(ctx context.Context, description string) (*data.Definition, error) {
+	definition, err := m.repo.GetDefinitionByDescription(ctx, description)
 	if err != nil {
-		return errors.Wrap(err)
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err)
 	}
+	return definition, nil
+}
 
-	def.UpdatedAt = dataDefinition.UpdatedAt.Time
-	def.CreatedAt = dataDefinition.CreatedAt.Time
-	def.ID = dataDefinition.ID
-	return nil
+// auditExistingDefinition is the definition an upsert changed, nil when it was created
+func auditExistingDefinition(existing *data.Definition) interface{} {
+	if existing == nil {
+		return nil
+	}
+	return fromDataDefinition(*existing)
 }
 
 func (m Manager) DeleteDefinitionKey(ctx context.Context, id int, key string) (eve.Definition, error) {
-	definition, err := m.repo.DeleteDefinitionKey(ctx, id, key)
+	var after eve.Definition
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinition(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		definition, err := m.repo.DeleteDefinitionKey(ctx, id, key)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		after = fromDataDefinition(*definition)
+		return m.audit(ctx, auditEntityDefinition, id, eve.AuditActionUpdate, before, after)
+	})
 	if err != nil {
-		return eve.Definition{}, service.CheckForNotFoundError(err)
+		return eve.Definition{}, err
 	}
 
-	return fromDataDefinition(*definition), nil
+	return after, nil
 }
 
 func (m Manager) DeleteDefinition(ctx context.Context, id int) error {
-	err := m.repo.DeleteDefinition(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinition(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteDefinition(ctx, id)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		return m.audit(ctx, auditEntityDefinition, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditDefinition is the definition before it's changed, nil when it doesn't exist
+func (m Manager) auditDefinition(ctx context.Context, id int) (interface{}, error) {
+	definition, err := m.repo.GetDefinition(ctx, id)
 	if err != nil {
-		return service.CheckForNotFoundError(err)
+		return auditNotFound(err)
 	}
-	return nil
+	return fromDataDefinition(*definition), nil
 }
 
 func (m Manager) GetDefinition(ctx context.Context, id string) (*eve.Definition, error) {
@@ -271,24 +361,53 @@ func (m Manager) GetDefinition(ctx context.Context, id string) (*eve.Definition,
 }
 
 func (m Manager) UpsertDefinitionServiceMap(ctx context.Context, serviceMap *eve.DefinitionServiceMap) error {
-	dataDefinitionServiceMap := toDataDefinitionServiceMap(*serviceMap)
-	err := m.repo.UpsertDefinitionServiceMap(ctx, &dataDefinitionServiceMap)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionServiceMap(ctx, serviceMap.DefinitionID, serviceMap.Description)
+		if err != nil {
+			return err
+		}
+
+		dataDefinitionServiceMap := toDataDefinitionServiceMap(*serviceMap)
+		err = m.repo.UpsertDefinitionServiceMap(ctx, &dataDefinitionServiceMap)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		serviceMap.UpdatedAt = dataDefinitionServiceMap.UpdatedAt.Time
+		serviceMap.CreatedAt = dataDefinitionServiceMap.CreatedAt.Time
 
-	serviceMap.UpdatedAt = dataDefinitionServiceMap.UpdatedAt.Time
-	serviceMap.CreatedAt = dataDefinitionServiceMap.CreatedAt.Time
-	return nil
+		return m.audit(ctx, auditEntityDefinitionServiceMap, auditMapID(serviceMap.DefinitionID, serviceMap.Description), upsertAction(before), before, serviceMap)
+	})
 }
 
 func (m Manager) DeleteDefinitionServiceMap(ctx context.Context, definitionID int, description string) error {
-	err := m.repo.DeleteDefinitionServiceMap(ctx, definitionID, description)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionServiceMap(ctx, definitionID, description)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteDefinitionServiceMap(ctx, definitionID, description)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		return m.audit(ctx, auditEntityDefinitionServiceMap, auditMapID(definitionID, description), eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditDefinitionServiceMap is the service map before it's changed, nil when it doesn't exist
+func (m Manager) auditDefinitionServiceMap(ctx context.Context, definitionID int, description string) (interface{}, error) {
+	maps, err := m.repo.ServiceDefinitionMapsByDefinitionID(ctx, definitionID)
 	if err != nil {
-		return service.CheckForNotFoundError(err)
+		return auditNotFound(err)
 	}
-
-	return nil
+	for _, x := range fromDataDefinitionServiceMaps(maps) {
+		if x.Description == description {
+			return x, nil
+		}
+	}
+	return nil, nil
 }
 
 func (m Manager) ServiceDefinitionMapsByDefinitionID(ctx context.Context, id int) ([]eve.DefinitionServiceMap, error) {
@@ -301,24 +420,53 @@ func (m Manager) ServiceDefinitionMapsByDefinitionID(ctx context.Context, id int
 }
 
 func (m Manager) UpsertDefinitionJobMap(ctx context.Context, e *eve.DefinitionJobMap) error {
-	dataDefinitionJobMap := toDataDefinitionJobMap(*e)
-	err := m.repo.UpsertDefinitionJobMap(ctx, &dataDefinitionJobMap)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionJobMap(ctx, e.DefinitionID, e.Description)
+		if err != nil {
+			return err
+		}
+
+		dataDefinitionJobMap := toDataDefinitionJobMap(*e)
+		err = m.repo.UpsertDefinitionJobMap(ctx, &dataDefinitionJobMap)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		e.UpdatedAt = dataDefinitionJobMap.UpdatedAt.Time
+		e.CreatedAt = dataDefinitionJobMap.CreatedAt.Time
 
-	e.UpdatedAt = dataDefinitionJobMap.UpdatedAt.Time
-	e.CreatedAt = dataDefinitionJobMap.CreatedAt.Time
-	return nil
+		return m.audit(ctx, auditEntityDefinitionJobMap, auditMapID(e.DefinitionID, e.Description), upsertAction(before), before, e)
+	})
 }
 
 func (m Manager) DeleteDefinitionJobMap(ctx context.Context, definitionID int, description string) error {
-	err := m.repo.DeleteDefinitionJobMap(ctx, definitionID, description)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditDefinitionJobMap(ctx, definitionID, description)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteDefinitionJobMap(ctx, definitionID, description)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		return m.audit(ctx, auditEntityDefinitionJobMap, auditMapID(definitionID, description), eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditDefinitionJobMap is the job map before it's changed, nil when it doesn't exist
+func (m Manager) auditDefinitionJobMap(ctx context.Context, definitionID int, description string) (interface{}, error) {
+	maps, err := m.repo.JobDefinitionMapsByDefinitionID(ctx, definitionID)
 	if err != nil {
-		return service.CheckForNotFoundError(err)
+		return auditNotFound(err)
 	}
-
-	return nil
+	for _, x := range fromDataDefinitionJobMaps(maps) {
+		if x.Description == description {
+			return x, nil
+		}
+	}
+	return nil, nil
 }
 
 func (m Manager) JobDefinitionMapsByDefinitionID(ctx context.Context, id int) ([]eve.DefinitionJobMap, error) {
@@ -331,6 +479,11 @@ func (m Manager) JobDefinitionMapsByDefinitionID(ctx context.Context, id int) ([
 }
 
 func (m *Manager) JobDefinitionResults(ctx context.Context, id int) (eve.DefinitionResults, error) {
+	return m.jobDefinitionResults(ctx, id, nil)
+}
+
+// jobDefinitionResults merges the job's definitions, see serviceDefinitionResults
+func (m *Manager) jobDefinitionResults(ctx context.Context, id int, proposed map[int]json.Object) (eve.DefinitionResults, error) {
 	definitionData, err := m.repo.JobDefinition(ctx, id)
 	if err != nil {
 		return nil, service.CheckForNotFoundError(err)
@@ -338,17 +491,14 @@ func (m *Manager) JobDefinitionResults(ctx context.Context, id int) (eve.Definit
 
 	var definitionResults []eve.DefinitionResult
 	for _, x := range definitionData {
-		var defSpecData = make(map[string]interface{})
-		if err := gojson.Unmarshal(x.Data, &defSpecData); err != nil {
+		if value, ok := proposed[x.DefinitionID]; ok {
+			x.Data = value
+		}
+		result, err := toDefinitionResult(x.DefinitionOrder, x.DefinitionClass, x.DefinitionVersion, x.DefinitionKind, x.Data)
+		if err != nil {
 			return nil, errors.Wrapf("failed to parse the job deployment definition: %s", err)
 		}
-		definitionResults = append(definitionResults, eve.DefinitionResult{
-			Order:   x.DefinitionOrder,
-			Class:   x.DefinitionClass,
-			Version: x.DefinitionVersion,
-			Kind:    x.DefinitionKind,
-			Data:    defSpecData,
-		})
+		definitionResults = append(definitionResults, result)
 	}
 
 	mergedResults, err := m.mergeDefinitionData(definitionResults)
@@ -372,6 +522,12 @@ func (m *Manager) ServiceDefinitions(ctx context.Context, id int) ([]eve.Definit
 }
 
 func (m *Manager) ServiceDefinitionResults(ctx context.Context, id int) (eve.DefinitionResults, error) {
+	return m.serviceDefinitionResults(ctx, id, nil)
+}
+
+// serviceDefinitionResults merges the service's definitions, proposed data is used in place of the current data of the
+// definition with its id
+func (m *Manager) serviceDefinitionResults(ctx context.Context, id int, proposed map[int]json.Object) (eve.DefinitionResults, error) {
 	definitionData, err := m.repo.ServiceDefinition(ctx, id)
 	if err != nil {
 		return nil, service.CheckForNotFoundError(err)
@@ -379,17 +535,14 @@ func (m *Manager) ServiceDefinitionResults(ctx context.Context, id int) (eve.Def
 
 	var definitionResults []eve.DefinitionResult
 	for _, x := range definitionData {
-		var defSpecData = make(map[string]interface{})
-		if err := gojson.Unmarshal(x.Data, &defSpecData); err != nil {
+		if value, ok := proposed[x.DefinitionID]; ok {
+			x.Data = value
+		}
+		result, err := toDefinitionResult(x.DefinitionOrder, x.DefinitionClass, x.DefinitionVersion, x.DefinitionKind, x.Data)
+		if err != nil {
 			return nil, errors.Wrapf("failed to parse the service deployment definition: %s", err)
 		}
-		definitionResults = append(definitionResults, eve.DefinitionResult{
-			Order:   x.DefinitionOrder,
-			Class:   x.DefinitionClass,
-			Version: x.DefinitionVersion,
-			Kind:    x.DefinitionKind,
-			Data:    defSpecData,
-		})
+		definitionResults = append(definitionResults, result)
 	}
 
 	mergedResults, err := m.mergeDefinitionData(definitionResults)
@@ -404,6 +557,21 @@ func (m *Manager) ServiceDefinitionResults(ctx context.Context, id int) (eve.Def
 
 }
 
+// toDefinitionResult parses a mapped definition's data into the result it's merged into
+func toDefinitionResult(order, class, version, kind string, definitionData []byte) (eve.DefinitionResult, error) {
+	var defSpecData = make(map[string]interface{})
+	if err := gojson.Unmarshal(definitionData, &defSpecData); err != nil {
+		return eve.DefinitionResult{}, err
+	}
+	return eve.DefinitionResult{
+		Order:   order,
+		Class:   class,
+		Version: version,
+		Kind:    kind,
+		Data:    defSpecData,
+	}, nil
+}
+
 func (m Manager) mergeDefinitionData(defResults []eve.DefinitionResult) (eve.DefinitionResults, error) {
 
 	var result = make(map[string]interface{})
@@ -2,14 +2,25 @@ package crud
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/unanet/go/pkg/errors"
 
+	"github.com/unanet/eve/internal/data"
 	"github.com/unanet/eve/internal/service"
 	"github.com/unanet/eve/pkg/eve"
 )
 
+const (
+	defaultDeploymentLimit = 25
+	maxDeploymentLimit     = 100
+)
+
 func (m *Manager) Deployment(ctx context.Context, id string) (*eve.Deployment, error) {
 	uID, err := uuid.FromString(id)
 	if err != nil {
@@ -22,5 +33,256 @@ func (m *Manager) Deployment(ctx context.Context, id string) (*eve.Deployment, e
 	}
 
 	deployment := eve.ToDeployment(*d)
+	m.deploymentResult(ctx, &deployment, *d)
+
+	results, err := m.repo.DeploymentResultsByDeploymentID(ctx, uID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	deployment.Results = fromDataDeploymentResults(results)
+
+	approvals, err := m.repo.DeploymentApprovalsByDeploymentID(ctx, uID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	deployment.Approvals = eve.ToDeploymentApprovals(approvals)
+
 	return &deployment, nil
 }
+
+func (m *Manager) DeploymentResults(ctx context.Context, id string) ([]eve.DeploymentResult, error) {
+	uID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid deployment id")
+	}
+
+	_, err = m.repo.DeploymentByID(ctx, uID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	results, err := m.repo.DeploymentResultsByDeploymentID(ctx, uID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return fromDataDeploymentResults(results), nil
+}
+
+func fromDataDeploymentResult(dr data.DeploymentResult) eve.DeploymentResult {
+	result := eve.DeploymentResult{
+		ID:               dr.ID,
+		DeploymentID:     dr.DeploymentID,
+		ArtifactID:       dr.ArtifactID,
+		ArtifactName:     dr.ArtifactName,
+		Name:             dr.Name,
+		RequestedVersion: dr.RequestedVersion.String,
+		PreviousVersion:  dr.PreviousVersion.String,
+		NewVersion:       dr.NewVersion.String,
+		Result:           eve.ParseDeployArtifactResult(dr.Result),
+		ExitCode:         dr.ExitCode,
+		CreatedAt:        dr.CreatedAt.Time,
+		UpdatedAt:        dr.UpdatedAt.Time,
+	}
+
+	if dr.ServiceID.Valid {
+		serviceID := int(dr.ServiceID.Int32)
+		result.ServiceID = &serviceID
+	}
+
+	if dr.JobID.Valid {
+		jobID := int(dr.JobID.Int32)
+		result.JobID = &jobID
+	}
+
+	return result
+}
+
+func fromDataDeploymentResults(results data.DeploymentResults) []eve.DeploymentResult {
+	var list []eve.DeploymentResult
+	for _, x := range results {
+		list = append(list, fromDataDeploymentResult(x))
+	}
+	return list
+}
+
+// Deployments searches the deployment history, results are paged with an opaque cursor
+func (m *Manager) Deployments(ctx context.Context, q eve.DeploymentQuery) (*eve.DeploymentPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultDeploymentLimit
+	}
+	if limit > maxDeploymentLimit {
+		return nil, errors.BadRequestf("limit must be less than or equal to %d", maxDeploymentLimit)
+	}
+
+	var ascending bool
+	switch q.Sort {
+	case "", eve.DeploymentSortDesc:
+	case eve.DeploymentSortAsc:
+		ascending = true
+	default:
+		return nil, errors.BadRequest("invalid sort, must be asc or desc")
+	}
+
+	var whereArgs []data.WhereArg
+	if q.Environment != "" {
+		if intID, err := strconv.Atoi(q.Environment); err == nil {
+			whereArgs = append(whereArgs, data.Where("d.environment_id", intID))
+		} else {
+			whereArgs = append(whereArgs, data.Where("e.name", q.Environment))
+		}
+	}
+
+	if q.Namespace != "" {
+		if intID, err := strconv.Atoi(q.Namespace); err == nil {
+			whereArgs = append(whereArgs, data.Where("d.namespace_id", intID))
+		} else {
+			whereArgs = append(whereArgs, data.Where("ns.name", q.Namespace))
+		}
+	}
+
+	if q.User != "" {
+		whereArgs = append(whereArgs, data.Where(`d."user"`, q.User))
+	}
+
+	if q.State != "" {
+		state := eve.ParseDeploymentState(data.DeploymentState(q.State))
+		if state == eve.DeploymentStateUnknown {
+			return nil, errors.BadRequestf("invalid deployment state: %s", q.State)
+		}
+		whereArgs = append(whereArgs, data.Where("d.state", q.State))
+	}
+
+	if q.CronID != "" {
+		cronID, err := uuid.FromString(q.CronID)
+		if err != nil {
+			return nil, errors.BadRequest("invalid cron id")
+		}
+		whereArgs = append(whereArgs, data.WhereRaw("d.id in (select deployment_id from deployment_cron_job where deployment_cron_id = ?)", cronID))
+	}
+
+	if q.RolloutID != "" {
+		rolloutID, err := uuid.FromString(q.RolloutID)
+		if err != nil {
+			return nil, errors.BadRequest("invalid rollout id")
+		}
+		whereArgs = append(whereArgs, data.Where("d.rollout_id", rolloutID))
+	}
+
+	if q.PipelineRunID != "" {
+		runID, err := uuid.FromString(q.PipelineRunID)
+		if err != nil {
+			return nil, errors.BadRequest("invalid pipeline run id")
+		}
+		whereArgs = append(whereArgs, data.Where("d.pipeline_run_id", runID))
+	}
+
+	if q.From != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("d.created_at", ">=", q.From.UTC()))
+	}
+
+	if q.To != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("d.created_at", "<", q.To.UTC()))
+	}
+
+	if q.Cursor != "" {
+		createdAt, id, err := decodeDeploymentCursor(q.Cursor)
+		if err != nil {
+			return nil, errors.BadRequest("invalid cursor")
+		}
+		operator := "<"
+		if ascending {
+			operator = ">"
+		}
+		whereArgs = append(whereArgs, data.WhereRaw(fmt.Sprintf("(d.created_at, d.id) %s (?, ?)", operator), createdAt, id))
+	}
+
+	// we fetch one more than the limit to know if there's another page
+	dDeployments, err := m.repo.Deployments(ctx, ascending, limit+1, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	page := eve.DeploymentPage{
+		Deployments: make([]eve.Deployment, 0),
+	}
+
+	if len(dDeployments) > limit {
+		dDeployments = dDeployments[:limit]
+		last := dDeployments[limit-1]
+		page.NextCursor = encodeDeploymentCursor(last.CreatedAt.Time, last.ID)
+	}
+
+	// the list has the results recorded in the db, the plan is only downloaded for the deployments that don't have any
+	// (ex: they finished before the results were recorded)
+	var ids []interface{}
+	for _, x := range dDeployments {
+		ids = append(ids, x.ID)
+	}
+
+	results, err := m.repo.DeploymentResultsByDeploymentIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	resultsByID := make(map[uuid.UUID]data.DeploymentResults)
+	for _, x := range results {
+		resultsByID[x.DeploymentID] = append(resultsByID[x.DeploymentID], x)
+	}
+
+	for _, x := range dDeployments {
+		deployment := eve.ToDeploymentHistory(x)
+		deployment.Results = fromDataDeploymentResults(resultsByID[x.ID])
+		if len(deployment.Results) == 0 {
+			m.deploymentResult(ctx, &deployment, x.Deployment)
+		}
+		page.Deployments = append(page.Deployments, deployment)
+	}
+
+	return &page, nil
+}
+
+// deploymentResult fills in the plan result from the plan location, a plan that can't be read
+// (ex: the object expired) doesn't fail the request, the error is returned with the deployment instead
+func (m *Manager) deploymentResult(ctx context.Context, deployment *eve.Deployment, d data.Deployment) {
+	if len(d.PlanLocation) == 0 || string(d.PlanLocation) == "null" {
+		return
+	}
+
+	plan, err := eve.UnMarshalNSDeploymentFromLocationBody(ctx, m.downloader, d.PlanLocation)
+	if err != nil {
+		deployment.ResultError = err.Error()
+		return
+	}
+
+	deployment.Result = plan.Result()
+}
+
+func encodeDeploymentCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", createdAt.UnixNano(), id)))
+}
+
+func decodeDeploymentCursor(cursor string) (time.Time, uuid.UUID, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.Wrap(err)
+	}
+
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, errors.Wrapf("invalid cursor: %s", cursor)
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.Wrap(err)
+	}
+
+	id, err := uuid.FromString(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.Wrap(err)
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
@@ -2,6 +2,7 @@ package crud
 
 import (
 	"context"
+	"fmt"
 	"github.com/unanet/go/pkg/errors"
 
 	"github.com/unanet/eve/internal/data"
@@ -19,26 +20,39 @@ func (m *Manager) EnvironmentFeedMaps(ctx context.Context) (models []eve.Environ
 }
 
 func (m *Manager) CreateEnvironmentFeedMap(ctx context.Context, model *eve.EnvironmentFeedMap) error {
-	dbModel := toDataEnvironmentFeedMap(*model)
-	if err := m.repo.CreateEnvironmentFeedMap(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataEnvironmentFeedMap(*model)
+		if err := m.repo.CreateEnvironmentFeedMap(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityEnvironmentFeedMap, environmentFeedMapID(*model), eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) UpdateEnvironmentFeedMap(ctx context.Context, model *eve.EnvironmentFeedMap) (err error) {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataEnvironmentFeedMap(*model)
+		if err := m.repo.UpdateEnvironmentFeedMap(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	dbModel := toDataEnvironmentFeedMap(*model)
-	if err := m.repo.UpdateEnvironmentFeedMap(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
-
-	return nil
+		return m.audit(ctx, auditEntityEnvironmentFeedMap, environmentFeedMapID(*model), eve.AuditActionUpdate, model, model)
+	})
 }
 
 func (m *Manager) DeleteEnvironmentFeedMap(ctx context.Context, model *eve.EnvironmentFeedMap) (err error) {
-	return m.repo.DeleteEnvironmentFeedMap(ctx, model.EnvironmentID, model.FeedID)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := m.repo.DeleteEnvironmentFeedMap(ctx, model.EnvironmentID, model.FeedID); err != nil {
+			return err
+		}
+
+		return m.audit(ctx, auditEntityEnvironmentFeedMap, environmentFeedMapID(*model), eve.AuditActionDelete, model, nil)
+	})
+}
+
+func environmentFeedMapID(model eve.EnvironmentFeedMap) string {
+	return fmt.Sprintf("%d/%d", model.EnvironmentID, model.FeedID)
 }
 
 func fromDataEnvironmentFeedMapList(feedMaps []data.EnvironmentFeedMap) []eve.EnvironmentFeedMap {
@@ -2,6 +2,7 @@ package crud
 
 import (
 	"context"
+	"database/sql"
 	"strconv"
 
 	"github.com/unanet/go/pkg/errors"
@@ -38,44 +39,75 @@ func (m *Manager) Environment(ctx context.Context, id string) (*eve.Environment,
 }
 
 func (m *Manager) UpdateEnvironment(ctx context.Context, e *eve.Environment) (*eve.Environment, error) {
-	dEnvironment := toDataEnvironment(*e)
-	err := m.repo.UpdateEnvironment(ctx, &dEnvironment)
+	var e2 eve.Environment
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditEnvironment(ctx, e.ID)
+		if err != nil {
+			return err
+		}
+
+		dEnvironment := toDataEnvironment(*e)
+		err = m.repo.UpdateEnvironment(ctx, &dEnvironment)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		e2 = fromDataEnvironment(dEnvironment)
+		return m.audit(ctx, auditEntityEnvironment, e2.ID, eve.AuditActionUpdate, before, e2)
+	})
 	if err != nil {
-		return nil, service.CheckForNotFoundError(err)
+		return nil, err
 	}
 
-	e2 := fromDataEnvironment(dEnvironment)
 	return &e2, nil
 }
 
 func (m *Manager) CreateEnvironment(ctx context.Context, model *eve.Environment) error {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbEnvironment := toDataEnvironment(*model)
+		if err := m.repo.CreateEnvironment(ctx, &dbEnvironment); err != nil {
+			return errors.Wrap(err)
+		}
 
-	dbEnvironment := toDataEnvironment(*model)
-	if err := m.repo.CreateEnvironment(ctx, &dbEnvironment); err != nil {
-		return errors.Wrap(err)
-	}
-
-	model.ID = dbEnvironment.ID
+		model.ID = dbEnvironment.ID
 
-	return nil
+		return m.audit(ctx, auditEntityEnvironment, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) DeleteEnvironment(ctx context.Context, id int) (err error) {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditEnvironment(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if err := m.repo.DeleteEnvironment(ctx, id); err != nil {
-		return service.CheckForNotFoundError(err)
-	}
+		if err := m.repo.DeleteEnvironment(ctx, id); err != nil {
+			return service.CheckForNotFoundError(err)
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityEnvironment, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditEnvironment is the environment before it's changed, nil when it doesn't exist
+func (m *Manager) auditEnvironment(ctx context.Context, id int) (interface{}, error) {
+	d, err := m.repo.EnvironmentByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataEnvironment(*d), nil
 }
 
 func fromDataEnvironment(environment data.Environment) eve.Environment {
 	return eve.Environment{
-		ID:          environment.ID,
-		Name:        environment.Name,
-		Alias:       environment.Alias,
-		Description: environment.Description,
-		UpdatedAt:   environment.UpdatedAt.Time,
+		ID:                environment.ID,
+		Name:              environment.Name,
+		Alias:             environment.Alias,
+		Description:       environment.Description,
+		DeploymentTimeout: int(environment.DeploymentTimeout.Int32),
+		RequiredApprovals: environment.RequiredApprovals,
+		UpdatedAt:         environment.UpdatedAt.Time,
 	}
 }
 
@@ -93,5 +125,10 @@ func toDataEnvironment(environment eve.Environment) data.Environment {
 		Name:        environment.Name,
 		Alias:       environment.Alias,
 		Description: environment.Description,
+		DeploymentTimeout: sql.NullInt32{
+			Int32: int32(environment.DeploymentTimeout),
+			Valid: environment.DeploymentTimeout > 0,
+		},
+		RequiredApprovals: environment.RequiredApprovals,
 	}
 }
@@ -0,0 +1,161 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// ServiceMetadataExplanation is the service's merged metadata along with the metadata documents each key comes from
+func (m *Manager) ServiceMetadataExplanation(ctx context.Context, id int) (*eve.MetadataExplanation, error) {
+	metadata, err := m.repo.ServiceMetadata(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var sources []eve.MetadataSource
+	for _, x := range metadata {
+		sources = append(sources, eve.MetadataSource{
+			MetadataID:     x.MetadataID,
+			Description:    x.MetadataDescription,
+			MapDescription: x.MapDescription,
+			Scope: eve.MapScope{
+				EnvironmentID: int(x.MapEnvironmentID.Int32),
+				NamespaceID:   int(x.MapNamespaceID.Int32),
+				ClusterID:     int(x.MapClusterID.Int32),
+				ArtifactID:    int(x.MapArtifactID.Int32),
+				ServiceID:     int(x.MapServiceID.Int32),
+			},
+			StackingOrder: x.StackingOrder,
+			Metadata:      x.Metadata.AsMapOrEmpty(),
+		})
+	}
+
+	explanation := eve.NewMetadataExplanation(m.mergeMetadataSources(sources), sources)
+	return &explanation, nil
+}
+
+// JobMetadataExplanation is the job's merged metadata along with the metadata documents each key comes from
+func (m *Manager) JobMetadataExplanation(ctx context.Context, id int) (*eve.MetadataExplanation, error) {
+	metadata, err := m.repo.JobMetadata(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var sources []eve.MetadataSource
+	for _, x := range metadata {
+		sources = append(sources, eve.MetadataSource{
+			MetadataID:     x.MetadataID,
+			Description:    x.MetadataDescription,
+			MapDescription: x.MapDescription,
+			Scope: eve.MapScope{
+				EnvironmentID: int(x.MapEnvironmentId.Int32),
+				NamespaceID:   int(x.MapNamespaceId.Int32),
+				ClusterID:     int(x.MapClusterID.Int32),
+				ArtifactID:    int(x.MapArtifactId.Int32),
+				JobID:         int(x.MapJobId.Int32),
+			},
+			StackingOrder: x.StackingOrder,
+			Metadata:      x.Metadata.AsMapOrEmpty(),
+		})
+	}
+
+	explanation := eve.NewMetadataExplanation(m.mergeMetadataSources(sources), sources)
+	return &explanation, nil
+}
+
+// ServiceDefinitionExplanations are the service's merged definition results along with the definitions merged into
+// each of them
+func (m *Manager) ServiceDefinitionExplanations(ctx context.Context, id int) ([]eve.DefinitionExplanation, error) {
+	definitionData, err := m.repo.ServiceDefinition(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var definitionResults []eve.DefinitionResult
+	var sources []eve.DefinitionSource
+	for _, x := range definitionData {
+		result, err := toDefinitionResult(x.DefinitionOrder, x.DefinitionClass, x.DefinitionVersion, x.DefinitionKind, x.Data)
+		if err != nil {
+			return nil, errors.Wrapf("failed to parse the service deployment definition: %s", err)
+		}
+		definitionResults = append(definitionResults, result)
+		sources = append(sources, eve.DefinitionSource{
+			DefinitionID:     x.DefinitionID,
+			DefinitionTypeID: x.DefinitionTypeID,
+			Description:      x.DefinitionDescription,
+			MapDescription:   x.MapDescription,
+			Scope: eve.MapScope{
+				EnvironmentID: int(x.MapEnvironmentID.Int32),
+				NamespaceID:   int(x.MapNamespaceID.Int32),
+				ClusterID:     int(x.MapClusterID.Int32),
+				ArtifactID:    int(x.MapArtifactID.Int32),
+				ServiceID:     int(x.MapServiceID.Int32),
+			},
+			StackingOrder: x.StackingOrder,
+			Result:        result.Key(),
+			// parsed again, the merge can change the maps it's given
+			Data: x.Data.AsMapOrEmpty(),
+		})
+	}
+
+	mergedResults, err := m.mergeDefinitionData(definitionResults)
+	if err != nil {
+		return nil, errors.Wrapf("failed to merge the service deployment definitions: %s", err)
+	}
+
+	return eve.NewDefinitionExplanations(m.defaultServiceDefinitions(mergedResults), sources), nil
+}
+
+// JobDefinitionExplanations are the job's merged definition results along with the definitions merged into each of
+// them
+func (m *Manager) JobDefinitionExplanations(ctx context.Context, id int) ([]eve.DefinitionExplanation, error) {
+	definitionData, err := m.repo.JobDefinition(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var definitionResults []eve.DefinitionResult
+	var sources []eve.DefinitionSource
+	for _, x := range definitionData {
+		result, err := toDefinitionResult(x.DefinitionOrder, x.DefinitionClass, x.DefinitionVersion, x.DefinitionKind, x.Data)
+		if err != nil {
+			return nil, errors.Wrapf("failed to parse the job deployment definition: %s", err)
+		}
+		definitionResults = append(definitionResults, result)
+		sources = append(sources, eve.DefinitionSource{
+			DefinitionID:     x.DefinitionID,
+			DefinitionTypeID: x.DefinitionTypeID,
+			Description:      x.DefinitionDescription,
+			MapDescription:   x.MapDescription,
+			Scope: eve.MapScope{
+				EnvironmentID: int(x.MapEnvironmentId.Int32),
+				NamespaceID:   int(x.MapNamespaceId.Int32),
+				ClusterID:     int(x.MapClusterID.Int32),
+				ArtifactID:    int(x.MapArtifactId.Int32),
+				JobID:         int(x.MapJobId.Int32),
+			},
+			StackingOrder: x.StackingOrder,
+			Result:        result.Key(),
+			Data:          x.Data.AsMapOrEmpty(),
+		})
+	}
+
+	mergedResults, err := m.mergeDefinitionData(definitionResults)
+	if err != nil {
+		return nil, errors.Wrapf("failed to merge the job deployment definitions: %s", err)
+	}
+
+	return eve.NewDefinitionExplanations(m.defaultJobDefinitions(mergedResults), sources), nil
+}
+
+func (m *Manager) mergeMetadataSources(sources []eve.MetadataSource) eve.MetadataField {
+	var collectedMetadata []eve.MetadataField
+	for _, x := range sources {
+		collectedMetadata = append(collectedMetadata, x.Metadata)
+	}
+	return m.mergeMetadata(collectedMetadata)
+}
@@ -17,26 +17,55 @@ func (m *Manager) Feeds(ctx context.Context) (models []eve.Feed, err error) {
 }
 
 func (m *Manager) CreateFeed(ctx context.Context, model *eve.Feed) error {
-	dbModel := toDataFeed(*model)
-	if err := m.repo.CreateFeed(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataFeed(*model)
+		if err := m.repo.CreateFeed(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	model.ID = dbModel.ID
+		model.ID = dbModel.ID
 
-	return nil
+		return m.audit(ctx, auditEntityFeed, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) UpdateFeed(ctx context.Context, model *eve.Feed) (err error) {
-	dbModel := toDataFeed(*model)
-	if err := m.repo.UpdateFeed(ctx, &dbModel); err != nil {
-		return err
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditFeed(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+
+		dbModel := toDataFeed(*model)
+		if err := m.repo.UpdateFeed(ctx, &dbModel); err != nil {
+			return err
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityFeed, model.ID, eve.AuditActionUpdate, before, model)
+	})
 }
 func (m *Manager) DeleteFeed(ctx context.Context, id int) (err error) {
-	return m.repo.DeleteFeed(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditFeed(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = m.repo.DeleteFeed(ctx, id); err != nil {
+			return err
+		}
+
+		return m.audit(ctx, auditEntityFeed, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditFeed is the feed before it's changed, nil when it doesn't exist
+func (m *Manager) auditFeed(ctx context.Context, id int) (interface{}, error) {
+	dbModel, err := m.repo.FeedByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataFeed(*dbModel), nil
 }
 
 func fromDataFeed(dbModel data.Feed) eve.Feed {
@@ -0,0 +1,127 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func toDataFreezeWindow(w eve.FreezeWindow) data.FreezeWindow {
+	window := data.FreezeWindow{
+		ID:            w.ID,
+		EnvironmentID: w.EnvironmentID,
+		NamespaceID: sql.NullInt32{
+			Int32: int32(w.NamespaceID),
+			Valid: w.NamespaceID > 0,
+		},
+		Description: w.Description,
+		Schedule: sql.NullString{
+			String: w.Schedule,
+			Valid:  len(w.Schedule) > 0,
+		},
+		Duration: sql.NullInt32{
+			Int32: int32(w.Duration),
+			Valid: w.Duration > 0,
+		},
+		Disabled: w.Disabled,
+	}
+
+	if w.StartsAt != nil {
+		window.StartsAt = sql.NullTime{Time: w.StartsAt.UTC(), Valid: true}
+	}
+
+	if w.EndsAt != nil {
+		window.EndsAt = sql.NullTime{Time: w.EndsAt.UTC(), Valid: true}
+	}
+
+	return window
+}
+
+// FreezeWindows returns the freeze windows, environmentID can be an int or the environment name
+func (m *Manager) FreezeWindows(ctx context.Context, environmentID string) ([]eve.FreezeWindow, error) {
+	var whereArgs []data.WhereArg
+	if len(environmentID) > 0 {
+		env, err := m.Environment(ctx, environmentID)
+		if err != nil {
+			return nil, err
+		}
+		whereArgs = append(whereArgs, data.Where("environment_id", env.ID))
+	}
+
+	windows, err := m.repo.FreezeWindows(ctx, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return eve.ToFreezeWindows(windows), nil
+}
+
+func (m *Manager) FreezeWindow(ctx context.Context, id int) (*eve.FreezeWindow, error) {
+	window, err := m.repo.FreezeWindowByID(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	result := eve.ToFreezeWindow(*window)
+	return &result, nil
+}
+
+func (m *Manager) CreateFreezeWindow(ctx context.Context, model *eve.FreezeWindow) error {
+	if err := m.validateFreezeWindowNamespace(ctx, model); err != nil {
+		return err
+	}
+
+	window := toDataFreezeWindow(*model)
+	if err := m.repo.CreateFreezeWindow(ctx, &window); err != nil {
+		return errors.Wrap(err)
+	}
+
+	*model = eve.ToFreezeWindow(window)
+	return nil
+}
+
+func (m *Manager) UpdateFreezeWindow(ctx context.Context, model *eve.FreezeWindow) (*eve.FreezeWindow, error) {
+	if err := m.validateFreezeWindowNamespace(ctx, model); err != nil {
+		return nil, err
+	}
+
+	window := toDataFreezeWindow(*model)
+	if err := m.repo.UpdateFreezeWindow(ctx, &window); err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	result := eve.ToFreezeWindow(window)
+	return &result, nil
+}
+
+func (m *Manager) DeleteFreezeWindow(ctx context.Context, id int) error {
+	if err := m.repo.DeleteFreezeWindow(ctx, id); err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	return nil
+}
+
+// a namespace window has to be in the window's environment, otherwise it would never apply
+func (m *Manager) validateFreezeWindowNamespace(ctx context.Context, model *eve.FreezeWindow) error {
+	if model.NamespaceID == 0 {
+		return nil
+	}
+
+	ns, err := m.Namespace(ctx, strconv.Itoa(model.NamespaceID))
+	if err != nil {
+		return err
+	}
+
+	if ns.EnvironmentID != model.EnvironmentID {
+		return errors.BadRequestf("namespace: %s is not in environment: %d", ns.Name, model.EnvironmentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// MetadataImpact previews a change to a metadata document without saving it. Each service and job the document is
+// mapped to is returned with what would change in its merged metadata
+func (m *Manager) MetadataImpact(ctx context.Context, p eve.MetadataProposal) (*eve.ImpactAnalysis, error) {
+	analysis := eve.NewImpactAnalysis()
+
+	existing, err := m.repo.GetMetadataByDescription(ctx, p.Description)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	value := map[string]interface{}(p.Value)
+	if p.Merge {
+		value = existing.Value.AsMapOrEmpty()
+		for k, v := range p.Value {
+			value[k] = v
+		}
+	}
+	proposed := map[int]eve.MetadataField{existing.ID: value}
+
+	serviceIDs, err := m.repo.ServiceIDsByMetadataID(ctx, existing.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, id := range serviceIDs {
+		dService, err := m.repo.ServiceByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		before, err := m.serviceMetadata(ctx, id, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := m.serviceMetadata(ctx, id, proposed)
+		if err != nil {
+			return nil, err
+		}
+
+		impact := eve.NewMetadataImpact(dService.Name, before, after)
+		impact.ServiceID = id
+		impact.Namespace = dService.NamespaceName
+		analysis.Services = append(analysis.Services, impact)
+	}
+
+	jobIDs, err := m.repo.JobIDsByMetadataID(ctx, existing.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, id := range jobIDs {
+		dJob, err := m.repo.JobByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		before, err := m.jobMetadata(ctx, id, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := m.jobMetadata(ctx, id, proposed)
+		if err != nil {
+			return nil, err
+		}
+
+		impact := eve.NewMetadataImpact(dJob.Name, before, after)
+		impact.JobID = id
+		impact.Namespace = dJob.NamespaceName
+		analysis.Jobs = append(analysis.Jobs, impact)
+	}
+
+	return &analysis, nil
+}
+
+// DefinitionImpact previews a change to a definition without saving it, see MetadataImpact. The proposed data is
+// validated with the schema of the definition's type the same way it is when it's saved
+func (m *Manager) DefinitionImpact(ctx context.Context, p eve.DefinitionProposal) (*eve.ImpactAnalysis, error) {
+	analysis := eve.NewImpactAnalysis()
+
+	existing, err := m.existingDefinition(ctx, p.Description)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.NotFoundf("definition: %s not found", p.Description)
+	}
+
+	value := p.Data
+	if p.Merge {
+		value = existing.Data.AsMapOrEmpty()
+		for k, v := range p.Data {
+			value[k] = v
+		}
+	}
+
+	if err = m.validateDefinitionData(ctx, existing.DefinitionTypeID, value); err != nil {
+		return nil, err
+	}
+
+	proposedData, err := json.FromMap(value)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	proposed := map[int]json.Object{existing.ID: proposedData}
+
+	serviceIDs, err := m.repo.ServiceIDsByDefinitionID(ctx, existing.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, id := range serviceIDs {
+		dService, err := m.repo.ServiceByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		before, err := m.serviceDefinitionResults(ctx, id, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := m.serviceDefinitionResults(ctx, id, proposed)
+		if err != nil {
+			return nil, err
+		}
+
+		impact := eve.NewDefinitionImpact(dService.Name, before, after)
+		impact.ServiceID = id
+		impact.Namespace = dService.NamespaceName
+		analysis.Services = append(analysis.Services, impact)
+	}
+
+	jobIDs, err := m.repo.JobIDsByDefinitionID(ctx, existing.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, id := range jobIDs {
+		dJob, err := m.repo.JobByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		before, err := m.jobDefinitionResults(ctx, id, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := m.jobDefinitionResults(ctx, id, proposed)
+		if err != nil {
+			return nil, err
+		}
+
+		impact := eve.NewDefinitionImpact(dJob.Name, before, after)
+		impact.JobID = id
+		impact.Namespace = dJob.NamespaceName
+		analysis.Jobs = append(analysis.Jobs, impact)
+	}
+
+	return &analysis, nil
+}
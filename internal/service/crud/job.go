@@ -92,14 +92,27 @@ func (m *Manager) Job(ctx context.Context, id string, namespace string) (*eve.Jo
 }
 
 func (m *Manager) UpdateJob(ctx context.Context, j *eve.Job) (*eve.Job, error) {
-	d := toDataJob(*j)
+	var j2 eve.Job
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditJob(ctx, j.ID)
+		if err != nil {
+			return err
+		}
+
+		d := toDataJob(*j)
 
-	err := m.repo.UpdateJob(ctx, &d)
+		err = m.repo.UpdateJob(ctx, &d)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		j2 = fromDataJob(d)
+		return m.audit(ctx, auditEntityJob, j2.ID, eve.AuditActionUpdate, before, j2)
+	})
 	if err != nil {
-		return nil, service.CheckForNotFoundError(err)
+		return nil, err
 	}
 
-	j2 := fromDataJob(d)
 	return &j2, nil
 }
 
@@ -113,16 +126,38 @@ func (m *Manager) Jobs(ctx context.Context) (models []eve.Job, err error) {
 }
 
 func (m *Manager) CreateJob(ctx context.Context, model *eve.Job) error {
-	dbModel := toDataJob(*model)
-	if err := m.repo.CreateJob(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataJob(*model)
+		if err := m.repo.CreateJob(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	model.ID = dbModel.ID
+		model.ID = dbModel.ID
 
-	return nil
+		return m.audit(ctx, auditEntityJob, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) DeleteJob(ctx context.Context, id int) (err error) {
-	return m.repo.DeleteJob(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditJob(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = m.repo.DeleteJob(ctx, id); err != nil {
+			return err
+		}
+
+		return m.audit(ctx, auditEntityJob, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditJob is the job before it's changed, nil when it doesn't exist
+func (m *Manager) auditJob(ctx context.Context, id int) (interface{}, error) {
+	d, err := m.repo.JobByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataJob(*d), nil
 }
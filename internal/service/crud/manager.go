@@ -3,16 +3,19 @@ package crud
 import (
 	"github.com/unanet/eve/internal/data"
 	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/eve/pkg/storage"
 )
 
-func NewManager(r *data.Repo) *Manager {
+func NewManager(r *data.Repo, downloader storage.Downloader) *Manager {
 	return &Manager{
-		repo: r,
+		repo:       r,
+		downloader: downloader,
 	}
 }
 
 type Manager struct {
-	repo *data.Repo
+	repo       *data.Repo
+	downloader storage.Downloader
 }
 
 // TODO: Handle this with Data default defs applied to everything (service/jobs)
@@ -0,0 +1,181 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// ServiceManifests renders the documents the service would be deployed with at the version, the deployed version is used
+// when it's empty
+func (m *Manager) ServiceManifests(ctx context.Context, serviceID int, version string) (*eve.Manifests, error) {
+	svc, err := m.repo.ServiceByID(ctx, serviceID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	deployServices, err := m.repo.DeployedServicesByNamespaceID(ctx, svc.NamespaceID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var spec *eve.DeployService
+	for _, x := range deployServices {
+		if x.ServiceID != svc.ID {
+			continue
+		}
+		spec = &eve.DeployService{
+			ServiceID:        x.ServiceID,
+			ServicePort:      x.ServicePort,
+			MetricsPort:      x.MetricsPort,
+			ServiceName:      x.ServiceName,
+			Count:            x.Count,
+			SuccessExitCodes: x.SuccessExitCodes,
+			DeployArtifact: &eve.DeployArtifact{
+				ArtifactID:       x.ArtifactID,
+				ArtifactName:     x.ArtifactName,
+				RequestedVersion: x.RequestedVersion,
+				DeployedVersion:  x.DeployedVersion.String,
+				ImageTag:         x.ImageTag,
+			},
+		}
+	}
+
+	if spec == nil {
+		return nil, errors.NotFoundf("service: %d not found", serviceID)
+	}
+
+	if spec.AvailableVersion, err = manifestVersion(version, spec.DeployedVersion); err != nil {
+		return nil, err
+	}
+
+	metadata, err := m.ServiceMetadata(ctx, svc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := m.ServiceDefinitionResults(ctx, svc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata, definitions, err = m.renderManifestTemplates(ctx, svc.NamespaceID, spec.ServiceName, spec.DeployArtifact, metadata, definitions); err != nil {
+		return nil, err
+	}
+	spec.Metadata = metadata
+
+	manifests, err := eve.RenderManifests(spec, metadata, definitions, svc.NamespaceName)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &manifests, nil
+}
+
+// JobManifests renders the documents the job would be deployed with at the version, the deployed version is used when
+// it's empty
+func (m *Manager) JobManifests(ctx context.Context, jobID int, version string) (*eve.Manifests, error) {
+	job, err := m.repo.JobByID(ctx, jobID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	deployJobs, err := m.repo.DeployedJobsByNamespaceID(ctx, job.NamespaceID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var spec *eve.DeployJob
+	for _, x := range deployJobs {
+		if x.JobID != job.ID {
+			continue
+		}
+		spec = &eve.DeployJob{
+			JobID:            x.JobID,
+			JobName:          x.JobName,
+			SuccessExitCodes: x.SuccessExitCodes,
+			DeployArtifact: &eve.DeployArtifact{
+				ArtifactID:       x.ArtifactID,
+				ArtifactName:     x.ArtifactName,
+				RequestedVersion: x.RequestedVersion,
+				DeployedVersion:  x.DeployedVersion.String,
+				ImageTag:         x.ImageTag,
+			},
+		}
+	}
+
+	if spec == nil {
+		return nil, errors.NotFoundf("job: %d not found", jobID)
+	}
+
+	if spec.AvailableVersion, err = manifestVersion(version, spec.DeployedVersion); err != nil {
+		return nil, err
+	}
+
+	metadata, err := m.JobMetadata(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := m.JobDefinitionResults(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata, definitions, err = m.renderManifestTemplates(ctx, job.NamespaceID, spec.JobName, spec.DeployArtifact, metadata, definitions); err != nil {
+		return nil, err
+	}
+	spec.Metadata = metadata
+
+	manifests, err := eve.RenderManifests(spec, metadata, definitions, job.NamespaceName)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &manifests, nil
+}
+
+func manifestVersion(version string, deployedVersion string) (string, error) {
+	if len(version) > 0 {
+		return version, nil
+	}
+	if len(deployedVersion) == 0 {
+		return "", errors.BadRequest("it hasn't been deployed, a version is required")
+	}
+	return deployedVersion, nil
+}
+
+// renderManifestTemplates renders the templated metadata and definition values the same way they are when a plan is
+// created
+func (m *Manager) renderManifestTemplates(ctx context.Context, namespaceID int, name string, a *eve.DeployArtifact, metadata eve.MetadataField, definitions eve.DefinitionResults) (eve.MetadataField, eve.DefinitionResults, error) {
+	ns, err := m.repo.NamespaceByID(ctx, namespaceID)
+	if err != nil {
+		return nil, nil, service.CheckForNotFoundError(err)
+	}
+
+	env, err := m.repo.EnvironmentByID(ctx, ns.EnvironmentID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+
+	cluster, err := m.repo.ClusterByID(ctx, ns.ClusterID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+
+	values := eve.NewTemplateValues(name, a, &eve.NamespaceRequest{Name: ns.Name, ClusterName: cluster.Name}, env.Name, metadata)
+	renderedMetadata, err := values.RenderMetadata(metadata)
+	if err != nil {
+		return nil, nil, errors.BadRequestf("failed to render the metadata, %s", err)
+	}
+
+	renderedDefinitions, err := values.RenderDefinitions(definitions)
+	if err != nil {
+		return nil, nil, errors.BadRequestf("failed to render the definitions, %s", err)
+	}
+
+	return renderedMetadata, renderedDefinitions, nil
+}
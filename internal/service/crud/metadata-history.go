@@ -2,19 +2,188 @@ package crud
 
 import (
 	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
 	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
 	"github.com/unanet/eve/pkg/eve"
 	"github.com/unanet/go/pkg/errors"
 	"github.com/unanet/go/pkg/json"
 )
 
-func (m *Manager) MetadataHistory(ctx context.Context) (models []eve.MetadataHistory, err error) {
-	dbResults, err := m.repo.MetadataHistory(ctx)
+const (
+	defaultMetadataHistoryLimit = 100
+	maxMetadataHistoryLimit     = 500
+)
+
+// MetadataHistory searches the metadata history, the newest revisions first. Results are paged with an opaque cursor
+func (m *Manager) MetadataHistory(ctx context.Context, q eve.MetadataHistoryQuery) (*eve.MetadataHistoryPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultMetadataHistoryLimit
+	}
+	if limit > maxMetadataHistoryLimit {
+		limit = maxMetadataHistoryLimit
+	}
+
+	var whereArgs []data.WhereArg
+	if q.Metadata != "" {
+		if intID, err := strconv.Atoi(q.Metadata); err == nil {
+			whereArgs = append(whereArgs, data.Where("metadata_id", intID))
+		} else {
+			whereArgs = append(whereArgs, data.Where("description", q.Metadata))
+		}
+	}
+
+	if q.From != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("created", ">=", q.From.UTC()))
+	}
+
+	if q.To != nil {
+		whereArgs = append(whereArgs, data.WhereCompare("created", "<", q.To.UTC()))
+	}
+
+	if q.Cursor != "" {
+		revision, err := decodeRevisionCursor(q.Cursor)
+		if err != nil {
+			return nil, errors.BadRequest("invalid cursor")
+		}
+		whereArgs = append(whereArgs, data.WhereCompare("revision", "<", revision))
+	}
+
+	// we fetch one more than the limit to know if there's another page
+	dbResults, err := m.repo.MetadataHistory(ctx, limit+1, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	page := eve.MetadataHistoryPage{
+		History: make([]eve.MetadataHistory, 0),
+	}
+
+	if len(dbResults) > limit {
+		dbResults = dbResults[:limit]
+		page.NextCursor = encodeRevisionCursor(dbResults[limit-1].Revision)
+	}
+
+	page.History = append(page.History, fromDataMetadataHistoryList(dbResults)...)
+	return &page, nil
+}
+
+// DiffMetadataHistory compares each key of two of the metadata's revisions, the current value is used when the to
+// revision is 0
+func (m *Manager) DiffMetadataHistory(ctx context.Context, metadataID int, fromRevision int, toRevision int) (*eve.MetadataDiff, error) {
+	from, err := m.repo.MetadataHistoryByRevision(ctx, metadataID, fromRevision)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var to map[string]interface{}
+	if toRevision == 0 {
+		metadata, mErr := m.repo.GetMetadata(ctx, metadataID)
+		if mErr != nil {
+			return nil, service.CheckForNotFoundError(mErr)
+		}
+		to = metadata.Value.AsMapOrEmpty()
+	} else {
+		toHistory, hErr := m.repo.MetadataHistoryByRevision(ctx, metadataID, toRevision)
+		if hErr != nil {
+			return nil, service.CheckForNotFoundError(hErr)
+		}
+		to = toHistory.Value.AsMapOrEmpty()
+	}
+
+	diff := eve.NewMetadataDiff(fromDataMetadataHistory(*from), toRevision, to)
+	return &diff, nil
+}
+
+// RestoreMetadata replaces the metadata's value with the value it had at the time, a metadata document that's been
+// deleted since is created again. The restore is added to the history as a new revision
+func (m *Manager) RestoreMetadata(ctx context.Context, metadataID int, at time.Time) (*eve.Metadata, error) {
+	history, err := m.repo.MetadataHistoryAt(ctx, metadataID, at.UTC())
 	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil, errors.BadRequest(err.Error())
+		}
 		return nil, errors.Wrap(err)
 	}
 
-	return fromDataMetadataHistoryList(dbResults), nil
+	restored := eve.Metadata{
+		Description: history.Description,
+		Value:       history.Value.AsMapOrEmpty(),
+	}
+	if err = m.CreateMetadata(ctx, &restored); err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
+
+// ServiceMetadataAt is the service's metadata as it was at the time, the value each of the service's current maps had
+// at the time is merged in the same stacking order. Metadata that didn't exist yet is skipped
+func (m *Manager) ServiceMetadataAt(ctx context.Context, id int, at time.Time) (eve.MetadataField, error) {
+	metadata, err := m.repo.ServiceMetadata(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var metadataIDs []int
+	for _, x := range metadata {
+		metadataIDs = append(metadataIDs, x.MetadataID)
+	}
+
+	return m.metadataAt(ctx, metadataIDs, at)
+}
+
+// JobMetadataAt is the job's metadata as it was at the time, see ServiceMetadataAt
+func (m *Manager) JobMetadataAt(ctx context.Context, id int, at time.Time) (eve.MetadataField, error) {
+	metadata, err := m.repo.JobMetadata(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var metadataIDs []int
+	for _, x := range metadata {
+		metadataIDs = append(metadataIDs, x.MetadataID)
+	}
+
+	return m.metadataAt(ctx, metadataIDs, at)
+}
+
+func (m *Manager) metadataAt(ctx context.Context, metadataIDs []int, at time.Time) (eve.MetadataField, error) {
+	var collectedMetadata []eve.MetadataField
+	for _, x := range metadataIDs {
+		history, err := m.repo.MetadataHistoryAt(ctx, x, at.UTC())
+		if err != nil {
+			if _, ok := err.(data.NotFoundError); ok {
+				continue
+			}
+			return nil, errors.Wrap(err)
+		}
+		collectedMetadata = append(collectedMetadata, history.Value.AsMapOrEmpty())
+	}
+
+	return m.mergeMetadata(collectedMetadata), nil
+}
+
+// encodeRevisionCursor is the cursor of the page after the revision, the history is paged newest first
+func encodeRevisionCursor(revision int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(revision)))
+}
+
+func decodeRevisionCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	revision, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return revision, nil
 }
 
 func fromDataMetadataHistory(dbModel data.MetadataHistory) eve.MetadataHistory {
@@ -24,6 +193,7 @@ func fromDataMetadataHistory(dbModel data.MetadataHistory) eve.MetadataHistory {
 	}
 
 	return eve.MetadataHistory{
+		Revision:    dbModel.Revision,
 		MetadataId:  dbModel.MetadataId,
 		Description: dbModel.Description,
 		Value:       dbModel.Value.AsMapOrEmpty(),
@@ -44,6 +214,7 @@ func fromDataMetadataHistoryList(dbModels []data.MetadataHistory) []eve.Metadata
 
 func toDataMetadataHistory(model eve.MetadataHistory) data.MetadataHistory {
 	return data.MetadataHistory{
+		Revision:    model.Revision,
 		MetadataId:  model.MetadataId,
 		Description: model.Description,
 		Value:       json.FromMapOrEmpty(model.Value),
@@ -170,6 +170,7 @@ func fromDataMetadataService(m data.MetadataService) eve.MetadataServiceMap {
 		EnvironmentID: int(m.MapEnvironmentID.Int32),
 		ArtifactID:    int(m.MapArtifactID.Int32),
 		NamespaceID:   int(m.MapNamespaceID.Int32),
+		ClusterID:     int(m.MapClusterID.Int32),
 		ServiceID:     int(m.MapServiceID.Int32),
 		StackingOrder: m.StackingOrder,
 		CreatedAt:     m.CreatedAt.Time,
@@ -194,39 +195,69 @@ func (m Manager) Metadata(ctx context.Context) ([]eve.Metadata, error) {
 }
 
 func (m Manager) CreateMetadata(ctx context.Context, metadata *eve.Metadata) error {
-	dataMetadata := toDataMetadata(*metadata)
-	err := m.repo.UpsertMetadata(ctx, &dataMetadata)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataByDescription(ctx, metadata.Description)
+		if err != nil {
+			return err
+		}
 
-	metadata.UpdatedAt = dataMetadata.UpdatedAt.Time
-	metadata.CreatedAt = dataMetadata.CreatedAt.Time
-	metadata.ID = dataMetadata.ID
-	return nil
+		dataMetadata := toDataMetadata(*metadata)
+		err = m.repo.UpsertMetadata(ctx, &dataMetadata)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		metadata.UpdatedAt = dataMetadata.UpdatedAt.Time
+		metadata.CreatedAt = dataMetadata.CreatedAt.Time
+		metadata.ID = dataMetadata.ID
+
+		return m.audit(ctx, auditEntityMetadata, metadata.ID, upsertAction(before), before, metadata)
+	})
 }
 
 func (m Manager) UpsertMergeMetadata(ctx context.Context, metadata *eve.Metadata) error {
-	dataMetadata := toDataMetadata(*metadata)
-	err := m.repo.UpsertMergeMetadata(ctx, &dataMetadata)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataByDescription(ctx, metadata.Description)
+		if err != nil {
+			return err
+		}
+
+		dataMetadata := toDataMetadata(*metadata)
+		err = m.repo.UpsertMergeMetadata(ctx, &dataMetadata)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		metadata.UpdatedAt = dataMetadata.UpdatedAt.Time
+		metadata.CreatedAt = dataMetadata.CreatedAt.Time
+		metadata.ID = dataMetadata.ID
+		metadata.Value = dataMetadata.Value.AsMapOrEmpty()
 
-	metadata.UpdatedAt = dataMetadata.UpdatedAt.Time
-	metadata.CreatedAt = dataMetadata.CreatedAt.Time
-	metadata.ID = dataMetadata.ID
-	metadata.Value = dataMetadata.Value.AsMapOrEmpty()
-	return nil
+		return m.audit(ctx, auditEntityMetadata, metadata.ID, upsertAction(before), before, metadata)
+	})
 }
 
 func (m Manager) DeleteMetadataKey(ctx context.Context, id int, key string) (eve.Metadata, error) {
-	metadata, err := m.repo.DeleteMetadataKey(ctx, id, key)
+	var after eve.Metadata
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadata(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		metadata, err := m.repo.DeleteMetadataKey(ctx, id, key)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		after = fromDataMetadata(*metadata)
+		return m.audit(ctx, auditEntityMetadata, id, eve.AuditActionUpdate, before, after)
+	})
 	if err != nil {
-		return eve.Metadata{}, service.CheckForNotFoundError(err)
+		return eve.Metadata{}, err
 	}
 
-	return fromDataMetadata(*metadata), nil
+	return after, nil
 }
 
 func (m *Manager) GetMetadata(ctx context.Context, id string) (*eve.Metadata, error) {
@@ -248,11 +279,37 @@ func (m *Manager) GetMetadata(ctx context.Context, id string) (*eve.Metadata, er
 }
 
 func (m *Manager) DeleteMetadata(ctx context.Context, id int) error {
-	err := m.repo.DeleteMetadata(ctx, id)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadata(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteMetadata(ctx, id)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		return m.audit(ctx, auditEntityMetadata, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditMetadata is the metadata before it's changed, nil when it doesn't exist
+func (m *Manager) auditMetadata(ctx context.Context, id int) (interface{}, error) {
+	metadata, err := m.repo.GetMetadata(ctx, id)
 	if err != nil {
-		return service.CheckForNotFoundError(err)
+		return auditNotFound(err)
 	}
-	return nil
+	return fromDataMetadata(*metadata), nil
+}
+
+// auditMetadataByDescription is the metadata an upsert changes, nil when it's created
+func (m *Manager) auditMetadataByDescription(ctx context.Context, description string) (interface{}, error) {
+	metadata, err := m.repo.GetMetadataByDescription(ctx, description)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataMetadata(*metadata), nil
 }
 
 func (m *Manager) JobMetadataMaps(ctx context.Context, id int) ([]eve.MetadataJobMap, error) {
@@ -292,25 +349,29 @@ func (m *Manager) JobMetadataMapsByMetadataID(ctx context.Context, id int) ([]ev
 }
 
 func (m *Manager) CreateMetadataJobMap(ctx context.Context, model *eve.MetadataJobMap) error {
-	dbModel := toDataMetadataJobMap(*model)
-	if err := m.repo.CreateMetadataJobMap(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataMetadataJobMap(*model)
+		if err := m.repo.CreateMetadataJobMap(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	model.CreatedAt = dbModel.CreatedAt.Time
+		model.CreatedAt = dbModel.CreatedAt.Time
 
-	return nil
+		return m.audit(ctx, auditEntityMetadataJobMap, auditMapID(model.MetadataID, model.Description), eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) CreateMetadataServiceMap(ctx context.Context, model *eve.MetadataServiceMap) error {
-	dbModel := toDataMetadataServiceMap(*model)
-	if err := m.repo.CreateMetadataServiceMap(ctx, &dbModel); err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbModel := toDataMetadataServiceMap(*model)
+		if err := m.repo.CreateMetadataServiceMap(ctx, &dbModel); err != nil {
+			return errors.Wrap(err)
+		}
 
-	model.CreatedAt = dbModel.CreatedAt.Time
+		model.CreatedAt = dbModel.CreatedAt.Time
 
-	return nil
+		return m.audit(ctx, auditEntityMetadataServiceMap, auditMapID(model.MetadataID, model.Description), eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) MetadataJobMaps(ctx context.Context) ([]eve.MetadataJobMap, error) {
@@ -332,48 +393,112 @@ func (m *Manager) MetadataServiceMaps(ctx context.Context) ([]eve.MetadataServic
 }
 
 func (m *Manager) UpsertMetadataJobMap(ctx context.Context, e *eve.MetadataJobMap) error {
-	dataMetadataJobMap := toDataMetadataJobMap(*e)
-	err := m.repo.UpsertMetadataJobMap(ctx, &dataMetadataJobMap)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataJobMap(ctx, e.MetadataID, e.Description)
+		if err != nil {
+			return err
+		}
+
+		dataMetadataJobMap := toDataMetadataJobMap(*e)
+		err = m.repo.UpsertMetadataJobMap(ctx, &dataMetadataJobMap)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		e.UpdatedAt = dataMetadataJobMap.UpdatedAt.Time
+		e.CreatedAt = dataMetadataJobMap.CreatedAt.Time
 
-	e.UpdatedAt = dataMetadataJobMap.UpdatedAt.Time
-	e.CreatedAt = dataMetadataJobMap.CreatedAt.Time
-	return nil
+		return m.audit(ctx, auditEntityMetadataJobMap, auditMapID(e.MetadataID, e.Description), upsertAction(before), before, e)
+	})
 }
 
 func (m *Manager) UpsertMetadataServiceMap(ctx context.Context, serviceMap *eve.MetadataServiceMap) error {
-	dataMetadataServiceMap := toDataMetadataServiceMap(*serviceMap)
-	err := m.repo.UpsertMetadataServiceMap(ctx, &dataMetadataServiceMap)
-	if err != nil {
-		return errors.Wrap(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataServiceMap(ctx, serviceMap.MetadataID, serviceMap.Description)
+		if err != nil {
+			return err
+		}
 
-	serviceMap.UpdatedAt = dataMetadataServiceMap.UpdatedAt.Time
-	serviceMap.CreatedAt = dataMetadataServiceMap.CreatedAt.Time
-	return nil
+		dataMetadataServiceMap := toDataMetadataServiceMap(*serviceMap)
+		err = m.repo.UpsertMetadataServiceMap(ctx, &dataMetadataServiceMap)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		serviceMap.UpdatedAt = dataMetadataServiceMap.UpdatedAt.Time
+		serviceMap.CreatedAt = dataMetadataServiceMap.CreatedAt.Time
+
+		return m.audit(ctx, auditEntityMetadataServiceMap, auditMapID(serviceMap.MetadataID, serviceMap.Description), upsertAction(before), before, serviceMap)
+	})
 }
 
 func (m *Manager) DeleteMetadataJobMap(ctx context.Context, metadataID int, description string) error {
-	err := m.repo.DeleteMetadataJobMap(ctx, metadataID, description)
-	if err != nil {
-		return service.CheckForNotFoundError(err)
-	}
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataJobMap(ctx, metadataID, description)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteMetadataJobMap(ctx, metadataID, description)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityMetadataJobMap, auditMapID(metadataID, description), eve.AuditActionDelete, before, nil)
+	})
 }
 
 func (m *Manager) DeleteMetadataServiceMap(ctx context.Context, metadataID int, description string) error {
-	err := m.repo.DeleteMetadataServiceMap(ctx, metadataID, description)
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditMetadataServiceMap(ctx, metadataID, description)
+		if err != nil {
+			return err
+		}
+
+		err = m.repo.DeleteMetadataServiceMap(ctx, metadataID, description)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		return m.audit(ctx, auditEntityMetadataServiceMap, auditMapID(metadataID, description), eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditMetadataJobMap is the job map before it's changed, nil when it doesn't exist
+func (m *Manager) auditMetadataJobMap(ctx context.Context, metadataID int, description string) (interface{}, error) {
+	maps, err := m.repo.JobMetadataMapsByMetadataID(ctx, metadataID)
 	if err != nil {
-		return service.CheckForNotFoundError(err)
+		return auditNotFound(err)
 	}
+	for _, x := range fromDataMetadataJobMaps(maps) {
+		if x.Description == description {
+			return x, nil
+		}
+	}
+	return nil, nil
+}
 
-	return nil
+// auditMetadataServiceMap is the service map before it's changed, nil when it doesn't exist
+func (m *Manager) auditMetadataServiceMap(ctx context.Context, metadataID int, description string) (interface{}, error) {
+	maps, err := m.repo.ServiceMetadataMapsByMetadataID(ctx, metadataID)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	for _, x := range fromDataMetadataServiceMaps(maps) {
+		if x.Description == description {
+			return x, nil
+		}
+	}
+	return nil, nil
 }
 
 func (m *Manager) ServiceMetadata(ctx context.Context, id int) (eve.MetadataField, error) {
+	return m.serviceMetadata(ctx, id, nil)
+}
+
+// serviceMetadata merges the service's metadata, a proposed value is used in place of the current value of the
+// metadata document with its id
+func (m *Manager) serviceMetadata(ctx context.Context, id int, proposed map[int]eve.MetadataField) (eve.MetadataField, error) {
 	metadata, err := m.repo.ServiceMetadata(ctx, id)
 	if err != nil {
 		return nil, service.CheckForNotFoundError(err)
@@ -381,6 +506,10 @@ func (m *Manager) ServiceMetadata(ctx context.Context, id int) (eve.MetadataFiel
 
 	var collectedMetadata []eve.MetadataField
 	for _, x := range metadata {
+		if value, ok := proposed[x.MetadataID]; ok {
+			collectedMetadata = append(collectedMetadata, value)
+			continue
+		}
 		collectedMetadata = append(collectedMetadata, x.Metadata.AsMapOrEmpty())
 	}
 
@@ -388,6 +517,11 @@ func (m *Manager) ServiceMetadata(ctx context.Context, id int) (eve.MetadataFiel
 }
 
 func (m *Manager) JobMetadata(ctx context.Context, id int) (eve.MetadataField, error) {
+	return m.jobMetadata(ctx, id, nil)
+}
+
+// jobMetadata merges the job's metadata, see serviceMetadata
+func (m *Manager) jobMetadata(ctx context.Context, id int, proposed map[int]eve.MetadataField) (eve.MetadataField, error) {
 	metadata, err := m.repo.JobMetadata(ctx, id)
 	if err != nil {
 		return nil, service.CheckForNotFoundError(err)
@@ -395,6 +529,10 @@ func (m *Manager) JobMetadata(ctx context.Context, id int) (eve.MetadataField, e
 
 	var collectedMetadata []eve.MetadataField
 	for _, x := range metadata {
+		if value, ok := proposed[x.MetadataID]; ok {
+			collectedMetadata = append(collectedMetadata, value)
+			continue
+		}
 		collectedMetadata = append(collectedMetadata, x.Metadata.AsMapOrEmpty())
 	}
 
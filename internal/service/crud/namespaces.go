@@ -13,16 +13,18 @@ import (
 
 func fromDataNamespace(namespace data.Namespace) eve.Namespace {
 	return eve.Namespace{
-		ID:               namespace.ID,
-		Name:             namespace.Name,
-		Alias:            namespace.Alias,
-		EnvironmentID:    namespace.EnvironmentID,
-		EnvironmentName:  namespace.EnvironmentName,
-		RequestedVersion: namespace.RequestedVersion,
-		ExplicitDeploy:   namespace.ExplicitDeploy,
-		ClusterID:        namespace.ClusterID,
-		CreatedAt:        namespace.CreatedAt.Time,
-		UpdatedAt:        namespace.UpdatedAt.Time,
+		ID:                namespace.ID,
+		Name:              namespace.Name,
+		Alias:             namespace.Alias,
+		EnvironmentID:     namespace.EnvironmentID,
+		EnvironmentName:   namespace.EnvironmentName,
+		RequestedVersion:  namespace.RequestedVersion,
+		ExplicitDeploy:    namespace.ExplicitDeploy,
+		ClusterID:         namespace.ClusterID,
+		RequiredApprovals: namespace.RequiredApprovals,
+		DeployOrder:       namespace.DeployOrder,
+		CreatedAt:         namespace.CreatedAt.Time,
+		UpdatedAt:         namespace.UpdatedAt.Time,
 	}
 }
 
@@ -79,46 +81,77 @@ func (m *Manager) Namespace(ctx context.Context, id string) (*eve.Namespace, err
 }
 
 func (m *Manager) UpdateNamespace(ctx context.Context, n *eve.Namespace) (*eve.Namespace, error) {
-	dNamespace := toDataNamespace(*n)
-	err := m.repo.UpdateNamespace(ctx, &dNamespace)
+	var n2 eve.Namespace
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditNamespace(ctx, n.ID)
+		if err != nil {
+			return err
+		}
+
+		dNamespace := toDataNamespace(*n)
+		err = m.repo.UpdateNamespace(ctx, &dNamespace)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		n2 = fromDataNamespace(dNamespace)
+		return m.audit(ctx, auditEntityNamespace, n2.ID, eve.AuditActionUpdate, before, n2)
+	})
 	if err != nil {
-		return nil, service.CheckForNotFoundError(err)
+		return nil, err
 	}
 
-	n2 := fromDataNamespace(dNamespace)
 	return &n2, nil
 }
 
 func (m *Manager) CreateNamespace(ctx context.Context, model *eve.Namespace) error {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbNamespace := toDataNamespace(*model)
+		if err := m.repo.CreateNamespace(ctx, &dbNamespace); err != nil {
+			return errors.Wrap(err)
+		}
 
-	dbNamespace := toDataNamespace(*model)
-	if err := m.repo.CreateNamespace(ctx, &dbNamespace); err != nil {
-		return errors.Wrap(err)
-	}
-
-	model.ID = dbNamespace.ID
+		model.ID = dbNamespace.ID
 
-	return nil
+		return m.audit(ctx, auditEntityNamespace, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) DeleteNamespace(ctx context.Context, id int) (err error) {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditNamespace(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if err := m.repo.DeleteNamespace(ctx, id); err != nil {
-		return service.CheckForNotFoundError(err)
-	}
+		if err := m.repo.DeleteNamespace(ctx, id); err != nil {
+			return service.CheckForNotFoundError(err)
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityNamespace, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditNamespace is the namespace before it's changed, nil when it doesn't exist
+func (m *Manager) auditNamespace(ctx context.Context, id int) (interface{}, error) {
+	d, err := m.repo.NamespaceByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataNamespace(*d), nil
 }
 
 func toDataNamespace(namespace eve.Namespace) data.Namespace {
 	return data.Namespace{
-		ID:               namespace.ID,
-		Name:             namespace.Name,
-		Alias:            namespace.Alias,
-		EnvironmentID:    namespace.EnvironmentID,
-		EnvironmentName:  namespace.EnvironmentName,
-		RequestedVersion: namespace.RequestedVersion,
-		ExplicitDeploy:   namespace.ExplicitDeploy,
-		ClusterID:        namespace.ClusterID,
+		ID:                namespace.ID,
+		Name:              namespace.Name,
+		Alias:             namespace.Alias,
+		EnvironmentID:     namespace.EnvironmentID,
+		EnvironmentName:   namespace.EnvironmentName,
+		RequestedVersion:  namespace.RequestedVersion,
+		ExplicitDeploy:    namespace.ExplicitDeploy,
+		ClusterID:         namespace.ClusterID,
+		RequiredApprovals: namespace.RequiredApprovals,
+		DeployOrder:       namespace.DeployOrder,
 	}
 }
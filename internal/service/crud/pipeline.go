@@ -0,0 +1,211 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const maxPipelineRuns = 100
+
+func toDataPipeline(p eve.Pipeline) (data.Pipeline, error) {
+	stages, err := json.StructToJsonObject(p.Stages)
+	if err != nil {
+		return data.Pipeline{}, errors.Wrap(err)
+	}
+
+	return data.Pipeline{
+		ID:   p.ID,
+		Name: p.Name,
+		Description: sql.NullString{
+			String: p.Description,
+			Valid:  len(p.Description) > 0,
+		},
+		Stages: stages,
+	}, nil
+}
+
+func fromDataPipeline(p data.Pipeline) (*eve.Pipeline, error) {
+	pipeline, err := eve.ToPipeline(p)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &pipeline, nil
+}
+
+func (m *Manager) Pipelines(ctx context.Context) ([]eve.Pipeline, error) {
+	pipelines, err := m.repo.Pipelines(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var list []eve.Pipeline
+	for _, x := range pipelines {
+		pipeline, err := fromDataPipeline(x)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *pipeline)
+	}
+
+	return list, nil
+}
+
+// Pipeline returns the pipeline, id can be an int or the pipeline name
+func (m *Manager) Pipeline(ctx context.Context, id string) (*eve.Pipeline, error) {
+	var pipeline *data.Pipeline
+	if intID, err := strconv.Atoi(id); err == nil {
+		pipeline, err = m.repo.PipelineByID(ctx, intID)
+		if err != nil {
+			return nil, service.CheckForNotFoundError(err)
+		}
+	} else {
+		pipeline, err = m.repo.PipelineByName(ctx, id)
+		if err != nil {
+			return nil, service.CheckForNotFoundError(err)
+		}
+	}
+
+	return fromDataPipeline(*pipeline)
+}
+
+func (m *Manager) CreatePipeline(ctx context.Context, model *eve.Pipeline) error {
+	if err := m.validatePipelineStages(ctx, model); err != nil {
+		return err
+	}
+
+	pipeline, err := toDataPipeline(*model)
+	if err != nil {
+		return err
+	}
+
+	if err = m.repo.CreatePipeline(ctx, &pipeline); err != nil {
+		return errors.Wrap(err)
+	}
+
+	result, err := fromDataPipeline(pipeline)
+	if err != nil {
+		return err
+	}
+	*model = *result
+	return nil
+}
+
+func (m *Manager) UpdatePipeline(ctx context.Context, model *eve.Pipeline) (*eve.Pipeline, error) {
+	if err := m.validatePipelineStages(ctx, model); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := toDataPipeline(*model)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.repo.UpdatePipeline(ctx, &pipeline); err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	return fromDataPipeline(pipeline)
+}
+
+func (m *Manager) DeletePipeline(ctx context.Context, id int) error {
+	if err := m.repo.DeletePipeline(ctx, id); err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	return nil
+}
+
+// every stage has to be an environment and its namespaces have to be in that environment
+func (m *Manager) validatePipelineStages(ctx context.Context, model *eve.Pipeline) error {
+	for i, x := range model.Stages {
+		env, err := m.repo.EnvironmentByName(ctx, x.Environment)
+		if err != nil {
+			if _, ok := err.(data.NotFoundError); ok {
+				return errors.BadRequestf("stage: %d, environment: %s not found", i, x.Environment)
+			}
+			return errors.Wrap(err)
+		}
+
+		namespaces, err := m.repo.NamespacesByEnvironmentID(ctx, env.ID)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		for _, alias := range x.Namespaces {
+			if !namespaces.Contains(alias) {
+				return errors.BadRequestf("stage: %d, namespace: %s not found in environment: %s", i, alias, x.Environment)
+			}
+		}
+	}
+
+	return nil
+}
+
+func fromDataPipelineRun(r data.PipelineRun) (*eve.PipelineRun, error) {
+	run, err := eve.ToPipelineRun(r)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &run, nil
+}
+
+// PipelineRuns returns the newest runs, pipelineID can be an int or the pipeline name
+func (m *Manager) PipelineRuns(ctx context.Context, pipelineID string, state string) ([]eve.PipelineRun, error) {
+	var whereArgs []data.WhereArg
+	if len(pipelineID) > 0 {
+		pipeline, err := m.Pipeline(ctx, pipelineID)
+		if err != nil {
+			return nil, err
+		}
+		whereArgs = append(whereArgs, data.Where("pipeline_id", pipeline.ID))
+	}
+
+	if len(state) > 0 {
+		switch eve.PipelineRunState(state) {
+		case eve.PipelineRunStateRunning, eve.PipelineRunStateAwaitingApproval, eve.PipelineRunStateSoaking,
+			eve.PipelineRunStateCompleted, eve.PipelineRunStateFailed, eve.PipelineRunStateCancelled:
+		default:
+			return nil, errors.BadRequestf("invalid pipeline run state: %s", state)
+		}
+		whereArgs = append(whereArgs, data.Where("state", state))
+	}
+
+	runs, err := m.repo.PipelineRuns(ctx, maxPipelineRuns, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var list []eve.PipelineRun
+	for _, x := range runs {
+		run, err := fromDataPipelineRun(x)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *run)
+	}
+
+	return list, nil
+}
+
+func (m *Manager) PipelineRun(ctx context.Context, id string) (*eve.PipelineRun, error) {
+	runID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.BadRequest("invalid pipeline run id")
+	}
+
+	run, err := m.repo.PipelineRunByID(ctx, runID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	return fromDataPipelineRun(*run)
+}
@@ -0,0 +1,46 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// Rollout returns the rollout with its deployments, the stages are in the order they were queued
+func (m *Manager) Rollout(ctx context.Context, id string) (*eve.Rollout, error) {
+	rolloutID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid rollout id")
+	}
+
+	r, err := m.repo.RolloutByID(ctx, rolloutID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	rollout := eve.ToRollout(*r)
+
+	var plan struct {
+		Canary *eve.CanaryOptions `json:"canary"`
+	}
+	if err = json.Unmarshal(r.Plan, &plan); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	rollout.Canary = plan.Canary
+
+	deployments, err := m.repo.DeploymentsByRolloutID(ctx, rolloutID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, x := range deployments {
+		rollout.Deployments = append(rollout.Deployments, eve.ToDeploymentHistory(x))
+	}
+
+	return &rollout, nil
+}
@@ -0,0 +1,110 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func (m *Manager) ServiceDependencies(ctx context.Context, serviceID int) ([]eve.ServiceDependency, error) {
+	if _, err := m.repo.ServiceByID(ctx, serviceID); err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	dependencies, err := m.repo.ServiceDependenciesByServiceID(ctx, serviceID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return eve.ToServiceDependencies(dependencies), nil
+}
+
+// CreateServiceDependency adds the dependency if the service and the service or job it depends on are in the same
+// namespace and it doesn't create a dependency cycle
+func (m *Manager) CreateServiceDependency(ctx context.Context, model *eve.ServiceDependency) error {
+	svc, err := m.repo.ServiceByID(ctx, model.ServiceID)
+	if err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	dependency := data.ServiceDependency{
+		ServiceID: svc.ID,
+	}
+
+	var dependsOn string
+	if model.DependsOnJobID > 0 {
+		job, jErr := m.repo.JobByID(ctx, model.DependsOnJobID)
+		if jErr != nil {
+			if _, ok := jErr.(data.NotFoundError); ok {
+				return errors.BadRequestf("job: %d not found", model.DependsOnJobID)
+			}
+			return errors.Wrap(jErr)
+		}
+		if job.NamespaceID != svc.NamespaceID {
+			return errors.BadRequestf("job: %s isn't in the same namespace as service: %s", job.Name, svc.Name)
+		}
+		dependency.DependsOnJobID = sql.NullInt32{Int32: int32(job.ID), Valid: true}
+		dependsOn = eve.JobNode(job.Name)
+	} else {
+		if model.DependsOnServiceID == svc.ID {
+			return errors.BadRequestf("service: %s can't depend on itself", svc.Name)
+		}
+		other, sErr := m.repo.ServiceByID(ctx, model.DependsOnServiceID)
+		if sErr != nil {
+			if _, ok := sErr.(data.NotFoundError); ok {
+				return errors.BadRequestf("service: %d not found", model.DependsOnServiceID)
+			}
+			return errors.Wrap(sErr)
+		}
+		if other.NamespaceID != svc.NamespaceID {
+			return errors.BadRequestf("service: %s isn't in the same namespace as service: %s", other.Name, svc.Name)
+		}
+		dependency.DependsOnServiceID = sql.NullInt32{Int32: int32(other.ID), Valid: true}
+		dependsOn = eve.ServiceNode(other.Name)
+	}
+
+	existing, err := m.repo.ServiceDependenciesByNamespaceID(ctx, svc.NamespaceID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	graph := eve.DependencyGraphFromData(existing)
+	graph.Add(eve.ServiceNode(svc.Name), dependsOn)
+	if cycle := graph.Cycle(nil); cycle != nil {
+		return errors.BadRequest(eve.DependencyCycleError{Cycle: cycle}.Error())
+	}
+
+	if err = m.repo.CreateServiceDependency(ctx, &dependency); err != nil {
+		return errors.Wrap(err)
+	}
+
+	created, err := m.repo.ServiceDependencyByID(ctx, dependency.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	*model = eve.ToServiceDependency(*created)
+	return nil
+}
+
+func (m *Manager) DeleteServiceDependency(ctx context.Context, serviceID int, id int) error {
+	dependency, err := m.repo.ServiceDependencyByID(ctx, id)
+	if err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	if dependency.ServiceID != serviceID {
+		return errors.NotFoundf("service dependency: %d not found for service: %d", id, serviceID)
+	}
+
+	if err = m.repo.DeleteServiceDependency(ctx, id); err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	return nil
+}
@@ -102,34 +102,63 @@ func (m *Manager) Services(ctx context.Context) ([]eve.Service, error) {
 }
 
 func (m *Manager) UpdateService(ctx context.Context, s *eve.Service) (*eve.Service, error) {
-	dService := toDataService(*s)
+	var s2 eve.Service
+	err := m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditService(ctx, s.ID)
+		if err != nil {
+			return err
+		}
+
+		dService := toDataService(*s)
 
-	err := m.repo.UpdateService(ctx, &dService)
+		err = m.repo.UpdateService(ctx, &dService)
+		if err != nil {
+			return service.CheckForNotFoundError(err)
+		}
+
+		s2 = fromDataService(dService)
+		return m.audit(ctx, auditEntityService, s2.ID, eve.AuditActionUpdate, before, s2)
+	})
 	if err != nil {
-		return nil, service.CheckForNotFoundError(err)
+		return nil, err
 	}
 
-	s2 := fromDataService(dService)
 	return &s2, nil
 }
 
 func (m *Manager) CreateService(ctx context.Context, model *eve.Service) error {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		dbService := toDataService(*model)
+		if err := m.repo.CreateService(ctx, &dbService); err != nil {
+			return errors.Wrap(err)
+		}
 
-	dbService := toDataService(*model)
-	if err := m.repo.CreateService(ctx, &dbService); err != nil {
-		return errors.Wrap(err)
-	}
-
-	model.ID = dbService.ID
+		model.ID = dbService.ID
 
-	return nil
+		return m.audit(ctx, auditEntityService, model.ID, eve.AuditActionCreate, nil, model)
+	})
 }
 
 func (m *Manager) DeleteService(ctx context.Context, id int) (err error) {
+	return m.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := m.auditService(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if err := m.repo.DeleteService(ctx, id); err != nil {
-		return service.CheckForNotFoundError(err)
-	}
+		if err := m.repo.DeleteService(ctx, id); err != nil {
+			return service.CheckForNotFoundError(err)
+		}
 
-	return nil
+		return m.audit(ctx, auditEntityService, id, eve.AuditActionDelete, before, nil)
+	})
+}
+
+// auditService is the service before it's changed, nil when it doesn't exist
+func (m *Manager) auditService(ctx context.Context, id int) (interface{}, error) {
+	d, err := m.repo.ServiceByID(ctx, id)
+	if err != nil {
+		return auditNotFound(err)
+	}
+	return fromDataService(*d), nil
 }
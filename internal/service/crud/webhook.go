@@ -0,0 +1,240 @@
+package crud
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strconv"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+)
+
+func fromDataWebhook(m data.Webhook) eve.Webhook {
+	return eve.Webhook{
+		ID:            m.ID,
+		EnvironmentID: m.EnvironmentID,
+		NamespaceID:   int(m.NamespaceID.Int32),
+		URL:           m.URL,
+		Disabled:      m.Disabled,
+		CreatedAt:     m.CreatedAt.Time,
+		UpdatedAt:     m.UpdatedAt.Time,
+	}
+}
+
+func fromDataWebhooks(webhooks data.Webhooks) []eve.Webhook {
+	var list []eve.Webhook
+	for _, x := range webhooks {
+		list = append(list, fromDataWebhook(x))
+	}
+	return list
+}
+
+func toDataWebhook(m eve.Webhook) data.Webhook {
+	return data.Webhook{
+		ID:            m.ID,
+		EnvironmentID: m.EnvironmentID,
+		NamespaceID: sql.NullInt32{
+			Int32: int32(m.NamespaceID),
+			Valid: m.NamespaceID > 0,
+		},
+		URL:      m.URL,
+		Secret:   m.Secret,
+		Disabled: m.Disabled,
+	}
+}
+
+func fromDataWebhookDelivery(m data.WebhookDelivery) eve.WebhookDelivery {
+	delivery := eve.WebhookDelivery{
+		ID:               m.ID,
+		WebhookID:        int(m.WebhookID.Int32),
+		URL:              m.URL,
+		Event:            m.Event,
+		State:            eve.WebhookDeliveryState(m.State),
+		Attempts:         m.Attempts,
+		LastResponseCode: int(m.LastResponseCode.Int32),
+		LastError:        m.LastError.String,
+		CreatedAt:        m.CreatedAt.Time,
+		UpdatedAt:        m.UpdatedAt.Time,
+	}
+
+	if m.DeploymentID.Valid {
+		delivery.DeploymentID = &m.DeploymentID.UUID
+	}
+
+	if m.State == data.WebhookDeliveryStatePending && m.NextAttemptAt.Valid {
+		delivery.NextAttemptAt = &m.NextAttemptAt.Time
+	}
+
+	if m.DeliveredAt.Valid {
+		delivery.DeliveredAt = &m.DeliveredAt.Time
+	}
+
+	// the payload isn't selected for the list of deliveries
+	if len(m.Payload) > 0 {
+		delivery.Payload = m.Payload.AsMapOrEmpty()
+	}
+
+	return delivery
+}
+
+func fromDataWebhookDeliveryAttempts(attempts data.WebhookDeliveryAttempts) []eve.WebhookDeliveryAttempt {
+	var list []eve.WebhookDeliveryAttempt
+	for _, x := range attempts {
+		list = append(list, eve.WebhookDeliveryAttempt{
+			Attempt:      x.Attempt,
+			ResponseCode: int(x.ResponseCode.Int32),
+			Error:        x.Error.String,
+			DurationMS:   x.DurationMS,
+			CreatedAt:    x.CreatedAt.Time,
+		})
+	}
+	return list
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (m *Manager) Webhooks(ctx context.Context) ([]eve.Webhook, error) {
+	dWebhooks, err := m.repo.Webhooks(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return fromDataWebhooks(dWebhooks), nil
+}
+
+func (m *Manager) Webhook(ctx context.Context, id int) (*eve.Webhook, error) {
+	dWebhook, err := m.repo.WebhookByID(ctx, id)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	webhook := fromDataWebhook(*dWebhook)
+	return &webhook, nil
+}
+
+// CreateWebhook creates the webhook, a secret is generated when one isn't supplied and it's only
+// ever returned here
+func (m *Manager) CreateWebhook(ctx context.Context, model *eve.Webhook) error {
+	if len(model.Secret) == 0 {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		model.Secret = secret
+	}
+
+	dWebhook := toDataWebhook(*model)
+	if err := m.repo.CreateWebhook(ctx, &dWebhook); err != nil {
+		return errors.Wrap(err)
+	}
+
+	secret := model.Secret
+	*model = fromDataWebhook(dWebhook)
+	model.Secret = secret
+
+	return nil
+}
+
+// UpdateWebhook updates the webhook, the secret is left alone unless a new one is supplied
+func (m *Manager) UpdateWebhook(ctx context.Context, model *eve.Webhook) (*eve.Webhook, error) {
+	dWebhook := toDataWebhook(*model)
+	if err := m.repo.UpdateWebhook(ctx, &dWebhook); err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	webhook := fromDataWebhook(dWebhook)
+	return &webhook, nil
+}
+
+func (m *Manager) DeleteWebhook(ctx context.Context, id int) error {
+	if err := m.repo.DeleteWebhook(ctx, id); err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	return nil
+}
+
+func (m *Manager) WebhookDeliveries(ctx context.Context, q eve.WebhookDeliveryQuery) ([]eve.WebhookDelivery, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	if limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+
+	var whereArgs []data.WhereArg
+	if len(q.DeploymentID) > 0 {
+		uID, err := uuid.FromString(q.DeploymentID)
+		if err != nil {
+			return nil, errors.BadRequest("invalid deployment id")
+		}
+		whereArgs = append(whereArgs, data.Where("deployment_id", uID))
+	}
+
+	if len(q.WebhookID) > 0 {
+		intID, err := strconv.Atoi(q.WebhookID)
+		if err != nil {
+			return nil, errors.BadRequest("invalid webhook id")
+		}
+		whereArgs = append(whereArgs, data.Where("webhook_id", intID))
+	}
+
+	if len(q.State) > 0 {
+		switch eve.WebhookDeliveryState(q.State) {
+		case eve.WebhookDeliveryStatePending, eve.WebhookDeliveryStateDelivered, eve.WebhookDeliveryStateFailed:
+		default:
+			return nil, errors.BadRequestf("invalid state: %s", q.State)
+		}
+		whereArgs = append(whereArgs, data.Where("state", q.State))
+	}
+
+	dDeliveries, err := m.repo.WebhookDeliveries(ctx, limit, whereArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var list []eve.WebhookDelivery
+	for _, x := range dDeliveries {
+		list = append(list, fromDataWebhookDelivery(x))
+	}
+	return list, nil
+}
+
+// WebhookDelivery returns the delivery along with every attempt made to send it
+func (m *Manager) WebhookDelivery(ctx context.Context, id string) (*eve.WebhookDelivery, error) {
+	uID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid webhook delivery id")
+	}
+
+	dDelivery, err := m.repo.WebhookDeliveryByID(ctx, uID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	attempts, err := m.repo.WebhookDeliveryAttempts(ctx, uID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	delivery := fromDataWebhookDelivery(*dDelivery)
+	delivery.AttemptLog = fromDataWebhookDeliveryAttempts(attempts)
+	return &delivery, nil
+}
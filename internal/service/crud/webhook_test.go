@@ -0,0 +1,29 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+)
+
+func TestFromDataWebhookDelivery_Payload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload json.Object
+		want    bool
+	}{
+		{name: "listed without a payload", payload: nil, want: false},
+		{name: "single with a payload", payload: json.Object(`{"deployment_id":"x"}`), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromDataWebhookDelivery(data.WebhookDelivery{Payload: tt.payload})
+			if (got.Payload != nil) != tt.want {
+				t.Errorf("fromDataWebhookDelivery() payload = %v, want payload: %v", got.Payload, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	// historySize is how many events are kept so a client that reconnects with a Last-Event-ID doesn't miss any
+	historySize = 500
+	// subscriptionBuffer is how many events a slow subscriber can fall behind before events are dropped for it
+	subscriptionBuffer = 64
+)
+
+type Filter func(e eve.DeploymentEvent) bool
+
+// Handler is called with every published event, it's called synchronously so it shouldn't block
+type Handler func(e eve.DeploymentEvent)
+
+// Bus fans deployment events out to subscribers, it's in process so a subscriber only sees the events
+// published by the api instance it's connected to
+type Bus struct {
+	sync.Mutex
+	lastID   int64
+	history  []eve.DeploymentEvent
+	subs     map[*Subscription]struct{}
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	bus    *Bus
+	filter Filter
+	c      chan eve.DeploymentEvent
+}
+
+// Events is closed when the subscription is closed
+func (s *Subscription) Events() <-chan eve.DeploymentEvent {
+	return s.c
+}
+
+func (s *Subscription) Close() {
+	s.bus.Lock()
+	defer s.bus.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.c)
+	}
+}
+
+// Publish assigns the event an id and sends it to every matching subscriber, it never blocks on a subscriber
+func (b *Bus) Publish(e eve.DeploymentEvent) {
+	e, handlers := b.publish(e)
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+func (b *Bus) publish(e eve.DeploymentEvent) (eve.DeploymentEvent, []Handler) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+		}
+	}
+
+	return e, b.handlers
+}
+
+// Handle registers a handler that's called with every event published after it's registered
+func (b *Bus) Handle(h Handler) {
+	b.Lock()
+	defer b.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Subscribe returns a subscription for the events matching the filter, along with the kept events
+// published after lastEventID (0 skips the history)
+func (b *Bus) Subscribe(filter Filter, lastEventID int64) (*Subscription, []eve.DeploymentEvent) {
+	b.Lock()
+	defer b.Unlock()
+
+	s := &Subscription{
+		bus:    b,
+		filter: filter,
+		c:      make(chan eve.DeploymentEvent, subscriptionBuffer),
+	}
+	b.subs[s] = struct{}{}
+
+	var missed []eve.DeploymentEvent
+	if lastEventID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastEventID && (filter == nil || filter(e)) {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	return s, missed
+}
@@ -0,0 +1,61 @@
+package events_test
+
+import (
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/internal/service/events"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestBus_Filter(t *testing.T) {
+	b := events.NewBus()
+	id := uuid.NewV4()
+	s, _ := b.Subscribe(func(e eve.DeploymentEvent) bool {
+		return e.DeploymentID == id
+	}, 0)
+	defer s.Close()
+
+	b.Publish(eve.DeploymentEvent{DeploymentID: uuid.NewV4(), Type: eve.DeploymentEventQueued})
+	b.Publish(eve.DeploymentEvent{DeploymentID: id, Type: eve.DeploymentEventScheduled})
+
+	e := <-s.Events()
+	assert.Equal(t, id, e.DeploymentID)
+	assert.Equal(t, eve.DeploymentEventScheduled, e.Type)
+	assert.Len(t, s.Events(), 0)
+}
+
+func TestBus_Missed(t *testing.T) {
+	b := events.NewBus()
+	b.Publish(eve.DeploymentEvent{EnvironmentID: 1, Type: eve.DeploymentEventQueued})
+	b.Publish(eve.DeploymentEvent{EnvironmentID: 2, Type: eve.DeploymentEventQueued})
+	b.Publish(eve.DeploymentEvent{EnvironmentID: 1, Type: eve.DeploymentEventScheduled})
+
+	s, missed := b.Subscribe(func(e eve.DeploymentEvent) bool {
+		return e.EnvironmentID == 1
+	}, 1)
+	s.Close()
+
+	require.Len(t, missed, 1)
+	assert.Equal(t, int64(3), missed[0].ID)
+
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+}
+
+func TestBus_Handle(t *testing.T) {
+	b := events.NewBus()
+	var handled []eve.DeploymentEvent
+	b.Handle(func(e eve.DeploymentEvent) {
+		handled = append(handled, e)
+	})
+
+	b.Publish(eve.DeploymentEvent{DeploymentID: uuid.NewV4(), Type: eve.DeploymentEventQueued})
+
+	require.Len(t, handled, 1)
+	assert.Equal(t, int64(1), handled[0].ID)
+	assert.False(t, handled[0].CreatedAt.IsZero())
+}
@@ -0,0 +1,546 @@
+package pipelines
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/internal/service/plans"
+	"github.com/unanet/eve/internal/service/releases"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// stageStartTimeout is how long a stage can be pending before the runner assumes it was never started
+const stageStartTimeout = 5 * time.Minute
+
+// Runner moves pipeline runs through their stages, a stage is finished once its deployments complete and its soak time
+// has passed, the next stage deploys the versions the finished stage deployed after they're released to its feed
+type Runner struct {
+	log      *zap.Logger
+	timeout  time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan bool
+	repo     *data.Repo
+	plans    *plans.PlanGenerator
+	releases *releases.ReleaseSvc
+}
+
+func NewRunner(repo *data.Repo, plans *plans.PlanGenerator, releases *releases.ReleaseSvc, timeout time.Duration) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		repo:     repo,
+		plans:    plans,
+		releases: releases,
+		log:      log.Logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan bool),
+		timeout:  timeout,
+	}
+}
+
+func (r *Runner) Start() {
+	go r.start()
+	r.log.Info("pipeline runner started")
+}
+
+// StartRun creates a run of the pipeline and starts its first stage
+func (r *Runner) StartRun(ctx context.Context, pipeline *eve.Pipeline, options eve.PipelineRunOptions) (*eve.PipelineRun, error) {
+	stages := make([]eve.PipelineRunStage, len(pipeline.Stages))
+	for i, x := range pipeline.Stages {
+		stages[i] = eve.PipelineRunStage{
+			PipelineStage: x,
+			State:         eve.PipelineStageStatePending,
+		}
+	}
+
+	stagesObject, err := json.StructToJsonObject(stages)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	artifacts, err := json.StructToJsonObject(options.Artifacts)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	dataRun := data.PipelineRun{
+		PipelineID:  pipeline.ID,
+		State:       data.PipelineRunStateRunning,
+		Stages:      stagesObject,
+		Artifacts:   artifacts,
+		User:        options.User,
+		StartedBy:   service.AuditRequestFromContext(ctx).User,
+		ForceDeploy: options.ForceDeploy,
+	}
+	if err = r.repo.CreatePipelineRun(ctx, &dataRun); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	run, err := eve.ToPipelineRun(dataRun)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	r.log.Info("pipeline run started", zap.String("id", run.ID.String()), zap.String("pipeline", pipeline.Name))
+	if err = r.enterStage(ctx, &run, dataRun, 0); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (r *Runner) activeRun(ctx context.Context, id string) (*eve.PipelineRun, *data.PipelineRun, error) {
+	runID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, nil, errors.NewRestError(400, "invalid pipeline run id")
+	}
+
+	dataRun, err := r.repo.PipelineRunByID(ctx, runID)
+	if err != nil {
+		return nil, nil, service.CheckForNotFoundError(err)
+	}
+
+	if !dataRun.Active() {
+		return nil, nil, errors.NewRestError(400, "pipeline run: %s is already %s", id, dataRun.State)
+	}
+
+	run, err := eve.ToPipelineRun(*dataRun)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+
+	return &run, dataRun, nil
+}
+
+// Approve starts a stage that's waiting on its approval gate, it can't be approved by the user that started the run
+func (r *Runner) Approve(ctx context.Context, id string, approval eve.ApprovalOptions) (*eve.PipelineRun, error) {
+	run, dataRun, err := r.activeRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = approveStage(run, service.AuditRequestFromContext(ctx).User, approval.Reason); err != nil {
+		return nil, err
+	}
+
+	if err = r.enterStage(ctx, run, *dataRun, run.Stage); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// approveStage records the authenticated user's approval of the stage the run is waiting on
+func approveStage(run *eve.PipelineRun, user string, reason string) error {
+	if run.State != eve.PipelineRunStateAwaitingApproval {
+		return errors.NewRestError(400, "pipeline run: %s is %s, it isn't awaiting approval", run.ID, run.State)
+	}
+
+	if len(user) == 0 {
+		return errors.NewRestError(401, "pipeline run: %s can only be approved by an authenticated user", run.ID)
+	}
+
+	if service.SharedAuditUser(user) {
+		return errors.NewRestError(403, "pipeline run: %s can't be approved by the shared user: %s", run.ID, user)
+	}
+
+	// the user in the request body is checked too since it's who the run was started on behalf of
+	if strings.EqualFold(run.StartedBy, user) || strings.EqualFold(run.User, user) {
+		return errors.NewRestError(403, "pipeline run: %s can't be approved by the user that started it", run.ID)
+	}
+
+	stage := &run.Stages[run.Stage]
+	stage.ApprovedBy = user
+	if len(reason) > 0 {
+		stage.Message("approved by: %s, %s", user, reason)
+	} else {
+		stage.Message("approved by: %s", user)
+	}
+	return nil
+}
+
+// Cancel stops a run, the deployments its current stage already queued aren't cancelled
+func (r *Runner) Cancel(ctx context.Context, id string, options eve.CancelOptions) (*eve.PipelineRun, error) {
+	run, dataRun, err := r.activeRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.finishStage(run, eve.PipelineStageStateCancelled)
+	run.State = eve.PipelineRunStateCancelled
+	run.Message = fmt.Sprintf("cancelled by: %s", options.User)
+	run.SoakUntil = nil
+	if _, err = r.save(ctx, run, *dataRun); err != nil {
+		return nil, err
+	}
+
+	r.log.Info("pipeline run cancelled", zap.String("id", run.ID.String()), zap.String("user", options.User))
+	return run, nil
+}
+
+// save writes the run's progress, it fails with a 400 when something else moved the run since it was read
+func (r *Runner) save(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun) (*data.PipelineRun, error) {
+	stages, err := json.StructToJsonObject(run.Stages)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	dataRun := from
+	dataRun.State = data.PipelineRunState(run.State)
+	dataRun.Stage = run.Stage
+	dataRun.Stages = stages
+	dataRun.Message = sql.NullString{String: run.Message, Valid: len(run.Message) > 0}
+	dataRun.SoakUntil = sql.NullTime{}
+	if run.SoakUntil != nil {
+		dataRun.SoakUntil = sql.NullTime{Time: run.SoakUntil.UTC(), Valid: true}
+	}
+
+	if err = r.repo.UpdatePipelineRun(ctx, &dataRun, from.State, from.Stage); err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil, errors.NewRestError(400, "pipeline run: %s was changed by another request", run.ID)
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	run.UpdatedAt = dataRun.UpdatedAt.Time
+	return &dataRun, nil
+}
+
+func (r *Runner) finishStage(run *eve.PipelineRun, state eve.PipelineStageState) {
+	now := time.Now().UTC()
+	stage := &run.Stages[run.Stage]
+	stage.State = state
+	stage.FinishedAt = &now
+}
+
+func (r *Runner) fail(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun, message string) error {
+	r.finishStage(run, eve.PipelineStageStateFailed)
+	run.State = eve.PipelineRunStateFailed
+	run.Message = fmt.Sprintf("stage: %d, environment: %s failed, %s", run.Stage, run.Stages[run.Stage].Environment, message)
+	run.SoakUntil = nil
+	if _, err := r.save(ctx, run, from); err != nil {
+		return err
+	}
+
+	r.log.Warn("pipeline run failed", zap.String("id", run.ID.String()), zap.String("message", run.Message))
+	return nil
+}
+
+// enterStage moves the run to the stage, it's saved before the stage is deployed so that only one request (or runner)
+// deploys it, the stage stays pending until its deployments are queued
+func (r *Runner) enterStage(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun, i int) error {
+	now := time.Now().UTC()
+	run.Stage = i
+	run.SoakUntil = nil
+	stage := &run.Stages[i]
+	stage.StartedAt = &now
+
+	if stage.Approval && len(stage.ApprovedBy) == 0 {
+		stage.State = eve.PipelineStageStateAwaitingApproval
+		run.State = eve.PipelineRunStateAwaitingApproval
+		run.Message = fmt.Sprintf("stage: %d, environment: %s is awaiting approval", i, stage.Environment)
+		_, err := r.save(ctx, run, from)
+		return err
+	}
+
+	stage.State = eve.PipelineStageStatePending
+	run.State = eve.PipelineRunStateRunning
+	run.Message = fmt.Sprintf("stage: %d, environment: %s is running", i, stage.Environment)
+	saved, err := r.save(ctx, run, from)
+	if err != nil {
+		return err
+	}
+
+	return r.startStage(ctx, run, *saved)
+}
+
+// stageArtifacts are the versions requested for the first stage, every stage after it deploys the versions the
+// previous stage deployed
+func stageArtifacts(run *eve.PipelineRun) eve.ArtifactDefinitions {
+	var artifacts eve.ArtifactDefinitions
+	if run.Stage == 0 {
+		for _, x := range run.Artifacts {
+			artifacts = append(artifacts, x.Clone())
+		}
+		return artifacts
+	}
+
+	for _, x := range run.Stages[run.Stage-1].Artifacts {
+		artifacts = append(artifacts, &eve.ArtifactDefinition{
+			ArtifactName:     x.ArtifactName,
+			RequestedVersion: x.AvailableVersion,
+			FeedType:         x.FeedType,
+		})
+	}
+	return artifacts
+}
+
+// pinnedArtifacts are the versions a stage resolved, there's one per artifact (the highest when the namespaces
+// requested different versions)
+func pinnedArtifacts(artifacts eve.ArtifactDefinitions) eve.ArtifactDefinitions {
+	var pinned eve.ArtifactDefinitions
+	seen := make(map[string]bool)
+	for _, x := range artifacts {
+		if seen[x.ArtifactName] {
+			continue
+		}
+		seen[x.ArtifactName] = true
+		pinned = append(pinned, &eve.ArtifactDefinition{
+			ID:               x.ID,
+			ArtifactName:     x.ArtifactName,
+			AvailableVersion: x.AvailableVersion,
+			FeedType:         x.FeedType,
+		})
+	}
+	return pinned
+}
+
+// release copies the artifacts to the stage's feed when it's further along the promotion order than the feed the
+// previous stage deployed them from
+func (r *Runner) release(ctx context.Context, previous, next eve.PipelineRunStage, artifacts eve.ArtifactDefinitions) ([]eve.Release, error) {
+	previousEnv, err := r.repo.EnvironmentByName(ctx, previous.Environment)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	nextEnv, err := r.repo.EnvironmentByName(ctx, next.Environment)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	var released []eve.Release
+	for _, x := range artifacts {
+		fromFeed, fErr := r.repo.FeedByEnvironmentIDAndType(ctx, previousEnv.ID, x.FeedType)
+		if fErr != nil {
+			if _, ok := fErr.(data.NotFoundError); ok {
+				continue
+			}
+			return released, errors.Wrap(fErr)
+		}
+
+		toFeed, fErr := r.repo.FeedByEnvironmentIDAndType(ctx, nextEnv.ID, x.FeedType)
+		if fErr != nil {
+			if _, ok := fErr.(data.NotFoundError); ok {
+				continue
+			}
+			return released, errors.Wrap(fErr)
+		}
+
+		if toFeed.PromotionOrder <= fromFeed.PromotionOrder {
+			continue
+		}
+
+		result, rErr := r.releases.Release(ctx, eve.Release{
+			Type:     eve.ReleaseTypeArtifact,
+			Artifact: x.ArtifactName,
+			Version:  x.RequestedVersion,
+			FromFeed: fromFeed.Alias,
+			ToFeed:   toFeed.Alias,
+		})
+		if rErr != nil {
+			return released, fmt.Errorf("failed to release: %s:%s from: %s to: %s, %s", x.ArtifactName, x.RequestedVersion, fromFeed.Alias, toFeed.Alias, rErr)
+		}
+		released = append(released, result...)
+	}
+
+	return released, nil
+}
+
+// startStage releases and deploys the stage's artifacts, a stage that can't be deployed fails the run
+func (r *Runner) startStage(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun) error {
+	stage := &run.Stages[run.Stage]
+	artifacts := stageArtifacts(run)
+
+	// a stage that's retried after waiting for a freeze window has already released its artifacts
+	if run.Stage > 0 && len(stage.Releases) == 0 {
+		released, err := r.release(ctx, run.Stages[run.Stage-1], *stage, artifacts)
+		stage.Releases = released
+		if err != nil {
+			return r.fail(ctx, run, from, err.Error())
+		}
+	}
+
+	options := eve.DeploymentPlanOptions{
+		Artifacts:        artifacts,
+		User:             run.User,
+		Environment:      stage.Environment,
+		NamespaceAliases: stage.Namespaces,
+		Type:             eve.DeploymentPlanTypeApplication,
+		ForceDeploy:      run.ForceDeploy,
+		PipelineRunID:    &run.ID,
+	}
+	// the deployments are requested by whoever started the run, not whoever approved the stage or the background runner
+	err := r.plans.QueuePlan(service.WithAuditUser(ctx, run.StartedBy), &options)
+	if plans.Frozen(err) {
+		// the stage stays pending and is started again once it's stalled, after the freeze window ends
+		run.Message = fmt.Sprintf("stage: %d is waiting for a freeze window to end, %s", run.Stage, err.Error())
+		_, err = r.save(ctx, run, from)
+		return err
+	}
+	stage.Messages = append(stage.Messages, options.Messages...)
+	if err != nil {
+		return r.fail(ctx, run, from, err.Error())
+	}
+
+	stage.State = eve.PipelineStageStateRunning
+	stage.DeploymentIDs = options.DeploymentIDs
+	stage.Artifacts = pinnedArtifacts(options.Artifacts)
+	_, err = r.save(ctx, run, from)
+	return err
+}
+
+// advance finishes the current stage and enters the next one, the run is completed after its last stage
+func (r *Runner) advance(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun) error {
+	r.finishStage(run, eve.PipelineStageStateSucceeded)
+	run.SoakUntil = nil
+
+	if run.Stage == len(run.Stages)-1 {
+		run.State = eve.PipelineRunStateCompleted
+		run.Message = "every stage succeeded"
+		if _, err := r.save(ctx, run, from); err != nil {
+			return err
+		}
+		r.log.Info("pipeline run completed", zap.String("id", run.ID.String()))
+		return nil
+	}
+
+	return r.enterStage(ctx, run, from, run.Stage+1)
+}
+
+// stalledStage is true when the stage was entered but its deployments were never queued, e.g. eve stopped between
+// saving the stage and queueing its plan. The request (or runner) that entered it has had stageStartTimeout to queue it
+func stalledStage(run *eve.PipelineRun, now time.Time) bool {
+	stage := run.Stages[run.Stage]
+	return stage.State == eve.PipelineStageStatePending &&
+		len(stage.DeploymentIDs) == 0 &&
+		stage.StartedAt != nil &&
+		now.Sub(*stage.StartedAt) > stageStartTimeout
+}
+
+// checkStage fails the run when one of the stage's deployments didn't succeed, once they all have the stage soaks or
+// the run advances
+func (r *Runner) checkStage(ctx context.Context, run *eve.PipelineRun, from data.PipelineRun) error {
+	stage := &run.Stages[run.Stage]
+	if stage.State != eve.PipelineStageStateRunning {
+		return nil
+	}
+
+	if len(stage.DeploymentIDs) > 0 {
+		ids := make([]interface{}, len(stage.DeploymentIDs))
+		for i, x := range stage.DeploymentIDs {
+			ids[i] = x
+		}
+
+		deployments, err := r.repo.Deployments(ctx, true, len(ids), data.WhereIn("d.id", ids))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		for _, x := range deployments {
+			switch x.State {
+			case data.DeploymentStateQueued, data.DeploymentStateScheduled, data.DeploymentStatePendingApproval, data.DeploymentStateWaiting:
+				return nil
+			case data.DeploymentStateCompleted:
+			default:
+				return r.fail(ctx, run, from, fmt.Sprintf("deployment to namespace: %s was %s", x.NamespaceName.String, x.State))
+			}
+
+			results, err := r.repo.DeploymentResultsByDeploymentID(ctx, x.ID)
+			if err != nil {
+				return errors.Wrap(err)
+			}
+
+			for _, y := range results {
+				if eve.ParseDeployArtifactResult(y.Result) == eve.DeployArtifactResultFailed {
+					return r.fail(ctx, run, from, fmt.Sprintf("deployment of: %s to namespace: %s failed", y.Name, x.NamespaceName.String))
+				}
+			}
+		}
+	}
+
+	if stage.Soak > 0 {
+		soakUntil := time.Now().UTC().Add(stage.SoakDuration())
+		stage.State = eve.PipelineStageStateSoaking
+		run.State = eve.PipelineRunStateSoaking
+		run.SoakUntil = &soakUntil
+		run.Message = fmt.Sprintf("stage: %d, environment: %s is soaking until: %s", run.Stage, stage.Environment, soakUntil.Format(time.RFC3339))
+		_, err := r.save(ctx, run, from)
+		return err
+	}
+
+	return r.advance(ctx, run, from)
+}
+
+func (r *Runner) run(ctx context.Context) error {
+	runs, err := r.repo.ActivePipelineRuns(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for _, x := range runs {
+		run, err := eve.ToPipelineRun(x)
+		if err != nil {
+			r.log.Error("failed to read the pipeline run", zap.String("id", x.ID.String()), zap.Error(err))
+			continue
+		}
+
+		switch x.State {
+		case data.PipelineRunStateRunning:
+			if stalledStage(&run, time.Now().UTC()) {
+				r.log.Warn("restarting the pipeline run's stage", zap.String("id", x.ID.String()), zap.Int("stage", run.Stage))
+				err = r.startStage(ctx, &run, x)
+				break
+			}
+			err = r.checkStage(ctx, &run, x)
+		case data.PipelineRunStateSoaking:
+			if time.Now().UTC().Before(x.SoakUntil.Time) {
+				continue
+			}
+			err = r.advance(ctx, &run, x)
+		}
+
+		// one run shouldn't hold up the rest
+		if err != nil {
+			r.log.Error("failed to move the pipeline run", zap.String("id", x.ID.String()), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) start() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			r.log.Info("pipeline runner stopped")
+			close(r.done)
+			return
+		default:
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), log.RequestIDKey, log.GetNextRequestID()), r.timeout)
+			err := r.run(ctx)
+			if err != nil {
+				r.log.Error("an error occurred in the pipeline runner", zap.Error(err))
+			}
+			cancel()
+		}
+
+		time.Sleep(15 * time.Second)
+	}
+}
+
+func (r *Runner) Stop() {
+	r.cancel()
+	<-r.done
+}
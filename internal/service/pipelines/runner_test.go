@@ -0,0 +1,158 @@
+package pipelines
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func awaitingRun() *eve.PipelineRun {
+	return &eve.PipelineRun{
+		State:     eve.PipelineRunStateAwaitingApproval,
+		Stage:     1,
+		User:      "alice",
+		StartedBy: "ci-bot",
+		Stages: []eve.PipelineRunStage{
+			{State: eve.PipelineStageStateSucceeded},
+			{State: eve.PipelineStageStateAwaitingApproval, PipelineStage: eve.PipelineStage{Approval: true}},
+		},
+	}
+}
+
+func TestRunner_approveStage(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        *eve.PipelineRun
+		user       string
+		wantErr    bool
+		approvedBy string
+	}{
+		{
+			name:       "approved",
+			run:        awaitingRun(),
+			user:       "bob",
+			approvedBy: "bob",
+		},
+		{
+			name:    "started the run",
+			run:     awaitingRun(),
+			user:    "Alice",
+			wantErr: true,
+		},
+		{
+			name:    "authenticated starter",
+			run:     awaitingRun(),
+			user:    "ci-bot",
+			wantErr: true,
+		},
+		{
+			name:    "admin token",
+			run:     awaitingRun(),
+			user:    "admin",
+			wantErr: true,
+		},
+		{
+			name:    "not authenticated",
+			run:     awaitingRun(),
+			wantErr: true,
+		},
+		{
+			name: "not awaiting approval",
+			run: func() *eve.PipelineRun {
+				run := awaitingRun()
+				run.State = eve.PipelineRunStateRunning
+				return run
+			}(),
+			user:    "bob",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := approveStage(tt.run, tt.user, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("approveStage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.run.Stages[1].ApprovedBy; got != tt.approvedBy {
+				t.Errorf("approveStage() approved by = %v, want %v", got, tt.approvedBy)
+			}
+		})
+	}
+}
+
+func TestRunner_stalledStage(t *testing.T) {
+	now := time.Now().UTC()
+	started := now.Add(-2 * stageStartTimeout)
+	recent := now.Add(-time.Second)
+
+	tests := []struct {
+		name  string
+		stage eve.PipelineRunStage
+		want  bool
+	}{
+		{
+			name:  "pending without deployments",
+			stage: eve.PipelineRunStage{State: eve.PipelineStageStatePending, StartedAt: &started},
+			want:  true,
+		},
+		{
+			name:  "still being started",
+			stage: eve.PipelineRunStage{State: eve.PipelineStageStatePending, StartedAt: &recent},
+		},
+		{
+			name:  "running",
+			stage: eve.PipelineRunStage{State: eve.PipelineStageStateRunning, StartedAt: &started},
+		},
+		{
+			name: "pending with deployments",
+			stage: eve.PipelineRunStage{
+				State:         eve.PipelineStageStatePending,
+				StartedAt:     &started,
+				DeploymentIDs: make([]uuid.UUID, 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &eve.PipelineRun{Stages: []eve.PipelineRunStage{tt.stage}}
+			if got := stalledStage(run, now); got != tt.want {
+				t.Errorf("stalledStage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_stageArtifacts(t *testing.T) {
+	run := &eve.PipelineRun{
+		Artifacts: eve.ArtifactDefinitions{
+			{ArtifactName: "api", RequestedVersion: "1.2"},
+		},
+		Stages: []eve.PipelineRunStage{
+			{
+				Artifacts: pinnedArtifacts(eve.ArtifactDefinitions{
+					{ID: 1, ArtifactName: "api", AvailableVersion: "1.2.3", FeedType: "docker"},
+					{ID: 1, ArtifactName: "api", AvailableVersion: "1.2.3", FeedType: "docker"},
+				}),
+			},
+			{},
+		},
+	}
+
+	// the first stage deploys the requested versions
+	if got := stageArtifacts(run); len(got) != 1 || got[0].RequestedVersion != "1.2" {
+		t.Errorf("stageArtifacts() = %v, want the requested version", got)
+	}
+
+	// the stages after it deploy the versions the stage before them deployed
+	run.Stage = 1
+	want := eve.ArtifactDefinitions{
+		{ArtifactName: "api", RequestedVersion: "1.2.3", FeedType: "docker"},
+	}
+	if got := stageArtifacts(run); !reflect.DeepEqual(got, want) {
+		t.Errorf("stageArtifacts() = %v, want %v", got, want)
+	}
+}
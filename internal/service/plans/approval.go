@@ -0,0 +1,123 @@
+package plans
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// ApproveDeployment records an approval, the deployment is queued once it has the approvals it needs
+func (d *PlanGenerator) ApproveDeployment(ctx context.Context, id string, approval eve.ApprovalOptions) (*eve.Deployment, error) {
+	return d.recordApproval(ctx, id, approval, true)
+}
+
+// RejectDeployment rejects a deployment that's pending approval, a single rejection is final
+func (d *PlanGenerator) RejectDeployment(ctx context.Context, id string, approval eve.ApprovalOptions) (*eve.Deployment, error) {
+	return d.recordApproval(ctx, id, approval, false)
+}
+
+func (d *PlanGenerator) recordApproval(ctx context.Context, id string, approval eve.ApprovalOptions, approved bool) (*eve.Deployment, error) {
+	deploymentID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid deployment id")
+	}
+
+	existing, err := d.repo.DeploymentByID(ctx, deploymentID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	if existing.State != data.DeploymentStatePendingApproval {
+		return nil, errors.NewRestError(400, "deployment: %s is %s, it isn't pending approval", id, existing.State)
+	}
+
+	approvals, err := d.repo.DeploymentApprovalsByDeploymentID(ctx, deploymentID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	user := service.AuditRequestFromContext(ctx).User
+	if err = checkApprover(existing, approvals, user); err != nil {
+		return nil, err
+	}
+
+	dataApproval := data.DeploymentApproval{
+		DeploymentID: deploymentID,
+		User:         user,
+		Approved:     approved,
+		Reason: sql.NullString{
+			String: approval.Reason,
+			Valid:  len(approval.Reason) > 0,
+		},
+	}
+	deployment, err := d.repo.RecordDeploymentApproval(ctx, &dataApproval)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+	approvals = append(approvals, dataApproval)
+
+	switch deployment.State {
+	case data.DeploymentStateRejected:
+		message := fmt.Sprintf("deployment rejected by: %s", user)
+		d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventRejected, *deployment, message))
+		if err = d.queueCancel(ctx, deployment, message); err != nil {
+			return nil, err
+		}
+	case data.DeploymentStateQueued:
+		d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventApproved, *deployment, fmt.Sprintf("deployment approved by: %s", user)))
+		d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventQueued, *deployment))
+		ns := eve.NamespaceRequest{ID: deployment.NamespaceID}
+		if err = d.queueDeployment(ctx, deployment.ID, ns.GetQueueGroupID()); err != nil {
+			return nil, err
+		}
+	default:
+		d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventApproved, *deployment,
+			fmt.Sprintf("deployment approved by: %s (%d of %d)", user, approvedCount(approvals), deployment.RequiredApprovals)))
+	}
+
+	result := eve.ToDeployment(*deployment)
+	result.Approvals = eve.ToDeploymentApprovals(approvals)
+	return &result, nil
+}
+
+// checkApprover makes sure the authenticated user can approve or reject the deployment, the user that requested it
+// can't, neither can the shared admin or unknown identities, and each user only gets one say
+func checkApprover(deployment *data.Deployment, approvals data.DeploymentApprovals, user string) error {
+	if len(user) == 0 {
+		return errors.NewRestError(401, "deployment: %s can only be approved or rejected by an authenticated user", deployment.ID)
+	}
+
+	if service.SharedAuditUser(user) {
+		return errors.NewRestError(403, "deployment: %s can't be approved or rejected by the shared user: %s", deployment.ID, user)
+	}
+
+	// the user in the request body is checked too since it's who the deployment was requested on behalf of
+	if strings.EqualFold(deployment.RequestedBy, user) || strings.EqualFold(deployment.User, user) {
+		return errors.NewRestError(403, "deployment: %s can't be approved or rejected by the user that requested it", deployment.ID)
+	}
+
+	for _, x := range approvals {
+		if strings.EqualFold(x.User, user) {
+			return errors.NewRestError(400, "user: %s has already approved or rejected deployment: %s", user, deployment.ID)
+		}
+	}
+	return nil
+}
+
+func approvedCount(approvals data.DeploymentApprovals) int {
+	var count int
+	for _, x := range approvals {
+		if x.Approved {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,93 @@
+package plans
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestPlanGenerator_checkApprover(t *testing.T) {
+	deployment := &data.Deployment{User: "alice", RequestedBy: "ci-bot"}
+	approvals := data.DeploymentApprovals{{User: "carol", Approved: true}}
+
+	tests := []struct {
+		name     string
+		user     string
+		wantCode int
+	}{
+		{
+			name: "approver",
+			user: "bob",
+		},
+		{
+			name:     "requester",
+			user:     "Alice",
+			wantCode: 403,
+		},
+		{
+			name:     "authenticated requester",
+			user:     "ci-bot",
+			wantCode: 403,
+		},
+		{
+			name:     "admin token",
+			user:     "admin",
+			wantCode: 403,
+		},
+		{
+			name:     "unknown user",
+			user:     "unknown",
+			wantCode: 403,
+		},
+		{
+			name:     "already approved",
+			user:     "carol",
+			wantCode: 400,
+		},
+		{
+			name:     "not authenticated",
+			wantCode: 401,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkApprover(deployment, approvals, tt.user)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("checkApprover() error = %v", err)
+				}
+				return
+			}
+
+			restErr, ok := err.(errors.RestError)
+			if !ok || restErr.Code != tt.wantCode {
+				t.Errorf("checkApprover() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPlanGenerator_checkApproverIgnoresBody(t *testing.T) {
+	// the requester can't approve their own deployment by naming another user in the body, the deployment was
+	// requested with user: bob in its body by alice
+	ctx := service.WithAuditUser(context.Background(), "alice")
+	r := httptest.NewRequest("POST", "/deployments/1/approve", strings.NewReader(`{"user": "bob", "reason": "lgtm"}`)).WithContext(ctx)
+
+	var approval eve.ApprovalOptions
+	if err := json.ParseBody(r, &approval); err == nil {
+		t.Errorf("ParseBody() expected an error for the user field")
+	}
+
+	err := checkApprover(&data.Deployment{User: "bob", RequestedBy: "alice"}, nil, service.AuditRequestFromContext(ctx).User)
+	if restErr, ok := err.(errors.RestError); !ok || restErr.Code != 403 {
+		t.Errorf("checkApprover() error = %v, want code 403", err)
+	}
+}
@@ -0,0 +1,280 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	ejson "github.com/unanet/go/pkg/json"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// rolloutPlan is the promotion stage of a rollout, it's queued once the canary is verified
+type rolloutPlan struct {
+	Options           eve.DeploymentPlanOptions `json:"options"`
+	Canary            eve.CanaryOptions         `json:"canary"`
+	Namespaces        eve.NamespaceRequests     `json:"namespaces"`
+	ArtifactsSupplied bool                      `json:"artifacts_supplied"`
+}
+
+func unmarshalRolloutPlan(rollout *data.Rollout) (*rolloutPlan, error) {
+	var plan rolloutPlan
+	if err := json.Unmarshal(rollout.Plan, &plan); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return &plan, nil
+}
+
+func namespaceAliases(namespaces eve.NamespaceRequests) eve.StringList {
+	var aliases eve.StringList
+	for _, x := range namespaces {
+		aliases = append(aliases, x.Alias)
+	}
+	return aliases
+}
+
+// queueCanary creates the rollout and queues its canary stage, the versions that were resolved for the canary are the
+// ones that are promoted
+func (d *PlanGenerator) queueCanary(ctx context.Context, env *data.Environment, options *eve.DeploymentPlanOptions, namespaceRequests eve.NamespaceRequests, artifactsSupplied bool) error {
+	canary := options.Canary
+
+	var canaryNamespaces, remaining eve.NamespaceRequests
+	for _, x := range namespaceRequests {
+		if len(canary.Namespaces) == 0 || canary.Namespaces.Contains(x.Alias) {
+			canaryNamespaces = append(canaryNamespaces, x)
+		} else {
+			remaining = append(remaining, x)
+		}
+	}
+
+	if len(canaryNamespaces) == 0 {
+		return errors.NewRestError(400, "none of the canary namespaces: %s are in the plan", strings.Join(canary.Namespaces, ", "))
+	}
+
+	// a replica count canary is promoted by deploying its namespaces again with the full count
+	promotion := remaining
+	if canary.Count > 0 {
+		promotion = namespaceRequests
+	}
+
+	if len(promotion) == 0 {
+		return errors.NewRestError(400, "every namespace in the plan is a canary namespace, there's nothing to promote")
+	}
+
+	promotionOptions := *options
+	promotionOptions.Type = eve.DeploymentPlanTypeApplication
+	promotionOptions.Canary = nil
+	promotionOptions.Messages = nil
+	promotionOptions.DeploymentIDs = nil
+	promotionOptions.NamespaceAliases = namespaceAliases(promotion)
+	// the canary namespaces already have the versions, they're only missing the replicas
+	promotionOptions.ForceDeploy = options.ForceDeploy || canary.Count > 0
+
+	plan, err := ejson.StructToJsonObject(&rolloutPlan{
+		Options:           promotionOptions,
+		Canary:            *canary,
+		Namespaces:        promotion,
+		ArtifactsSupplied: artifactsSupplied,
+	})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	rollout := data.Rollout{
+		EnvironmentID: env.ID,
+		Plan:          plan,
+		User:          options.User,
+	}
+	if err = d.repo.CreateRollout(ctx, &rollout); err != nil {
+		return errors.Wrap(err)
+	}
+
+	options.RolloutID = &rollout.ID
+	options.RolloutStage = data.RolloutStageCanary
+	options.Message("rollout: %s, the canary is deployed to: %s first", rollout.ID, strings.Join(namespaceAliases(canaryNamespaces), ", "))
+
+	return d.queueNamespaces(ctx, env, options, canaryNamespaces, artifactsSupplied, canary.Count)
+}
+
+func (d *PlanGenerator) activeRollout(ctx context.Context, id string) (*data.Rollout, error) {
+	rolloutID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid rollout id")
+	}
+
+	rollout, err := d.repo.RolloutByID(ctx, rolloutID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	return rollout, nil
+}
+
+func rolloutMessage(action string, options eve.RolloutOptions) string {
+	message := fmt.Sprintf("rollout %s by: %s", action, options.User)
+	if len(options.Reason) > 0 {
+		message += ", " + options.Reason
+	}
+	return message
+}
+
+// PromoteRollout is the success callback for a canary, it queues the rest of the plan
+func (d *PlanGenerator) PromoteRollout(ctx context.Context, id string, options eve.RolloutOptions) error {
+	rollout, err := d.activeRollout(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if rollout.State != data.RolloutStateVerifying {
+		return errors.NewRestError(400, "rollout: %s is %s, only a rollout that's being verified can be promoted", id, rollout.State)
+	}
+
+	return d.promoteRollout(ctx, rollout.ID, rolloutMessage("promoted", options))
+}
+
+// AbortRollout stops a rollout that hasn't been promoted and rolls back its canary
+func (d *PlanGenerator) AbortRollout(ctx context.Context, id string, options eve.RolloutOptions) error {
+	rollout, err := d.activeRollout(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch rollout.State {
+	case data.RolloutStateCanary, data.RolloutStateVerifying:
+	default:
+		return errors.NewRestError(400, "rollout: %s is already %s", id, rollout.State)
+	}
+
+	return d.abortRollout(ctx, rollout.ID, rolloutMessage("aborted", options))
+}
+
+func (d *PlanGenerator) promoteRollout(ctx context.Context, id uuid.UUID, message string) error {
+	current, err := d.repo.RolloutByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	plan, err := unmarshalRolloutPlan(current)
+	if err != nil {
+		return err
+	}
+
+	env, err := d.repo.EnvironmentByID(ctx, current.EnvironmentID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	// the freeze is checked again since a window can start while the canary is verified, the rollout stays in
+	// verification until the window ends
+	namespaces, err := d.checkFreezeWindows(ctx, env, &plan.Options, plan.Namespaces)
+	if err != nil {
+		return err
+	}
+
+	rollout, err := d.repo.UpdateRolloutState(ctx, id, data.RolloutStatePromoted, message, data.RolloutStateVerifying)
+	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return errors.NewRestError(400, "rollout: %s is no longer being verified", id)
+		}
+		return errors.Wrap(err)
+	}
+
+	log.Logger.Info("rollout promoted", zap.String("id", rollout.ID.String()), zap.String("message", message))
+	plan.Options.RolloutID = &rollout.ID
+	plan.Options.RolloutStage = data.RolloutStagePromotion
+	return d.queueNamespaces(ctx, env, &plan.Options, namespaces, plan.ArtifactsSupplied, 0)
+}
+
+// abortRollout moves the rollout to aborting and then undoes the canary, when that fails the rollout stays aborting
+// and the verifier retries it
+func (d *PlanGenerator) abortRollout(ctx context.Context, id uuid.UUID, message string) error {
+	rollout, err := d.repo.UpdateRolloutState(ctx, id, data.RolloutStateAborting, message, data.RolloutStateCanary, data.RolloutStateVerifying)
+	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return errors.NewRestError(400, "rollout: %s was already promoted or aborted", id)
+		}
+		return errors.Wrap(err)
+	}
+
+	log.Logger.Warn("rollout aborting", zap.String("id", rollout.ID.String()), zap.String("message", message))
+	return d.finishAbort(ctx, rollout)
+}
+
+// finishAbort cancels the canary deployments that haven't finished and rolls back the ones that have, then the rollout
+// is aborted. It's safe to retry, a namespace that already has a rollback isn't rolled back again
+func (d *PlanGenerator) finishAbort(ctx context.Context, rollout *data.Rollout) error {
+	message := rollout.Message.String
+	deployments, err := d.repo.DeploymentsByRolloutID(ctx, rollout.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	rolledBack := make(map[int]bool)
+	for _, x := range deployments {
+		if x.RolloutStage.String == data.RolloutStageRollback {
+			rolledBack[x.NamespaceID] = true
+		}
+	}
+
+	for _, x := range deployments {
+		if x.RolloutStage.String != data.RolloutStageCanary {
+			continue
+		}
+
+		switch x.State {
+		case data.DeploymentStateQueued, data.DeploymentStateScheduled, data.DeploymentStatePendingApproval, data.DeploymentStateWaiting:
+			// the cancel is rolled back when it can't be sent, so it's tried again
+			var cancelled *data.Deployment
+			err = d.repo.WithTx(ctx, func(ctx context.Context) error {
+				var cErr error
+				cancelled, cErr = d.repo.CancelDeployment(ctx, x.ID)
+				if cErr != nil {
+					// it finished since we queried
+					log.Logger.Warn("failed to cancel the canary deployment", zap.String("id", x.ID.String()), zap.Error(cErr))
+					return nil
+				}
+				return d.queueCancel(ctx, cancelled, message)
+			})
+			if err != nil {
+				return err
+			}
+			if cancelled != nil {
+				d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCancelled, *cancelled, message))
+			}
+		case data.DeploymentStateCompleted:
+			if rolledBack[x.NamespaceID] {
+				continue
+			}
+			_, rErr := d.rollbackDeployment(ctx, &x.Deployment, eve.RollbackOptions{User: rollout.User}, &rollout.ID)
+			if rErr != nil {
+				// a canary that didn't change any versions has nothing to roll back
+				if restErr, ok := rErr.(errors.RestError); ok && restErr.Code == 400 {
+					log.Logger.Info("canary deployment wasn't rolled back", zap.String("id", x.ID.String()), zap.Error(rErr))
+					continue
+				}
+				return errors.Wrap(rErr)
+			}
+			rolledBack[x.NamespaceID] = true
+		}
+	}
+
+	_, err = d.repo.UpdateRolloutState(ctx, rollout.ID, data.RolloutStateAborted, "", data.RolloutStateAborting)
+	if err != nil {
+		// something else finished the abort
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil
+		}
+		return errors.Wrap(err)
+	}
+
+	log.Logger.Warn("rollout aborted", zap.String("id", rollout.ID.String()), zap.String("message", message))
+	return nil
+}
@@ -0,0 +1,73 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/eve/pkg/queue"
+)
+
+// CancelDeployment cancels a deployment that hasn't finished, the rest of the work (releasing the namespace's queue group
+// and the callback) is done by the api queue so that it happens in the same place the deployment is scheduled
+func (d *PlanGenerator) CancelDeployment(ctx context.Context, id string, cancel eve.CancelOptions) (*eve.Deployment, error) {
+	deploymentID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid deployment id")
+	}
+
+	existing, err := d.repo.DeploymentByID(ctx, deploymentID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	switch existing.State {
+	case data.DeploymentStateQueued, data.DeploymentStateScheduled, data.DeploymentStatePendingApproval, data.DeploymentStateWaiting:
+	default:
+		return nil, errors.NewRestError(400, "deployment: %s is already %s", id, existing.State)
+	}
+
+	deployment, err := d.repo.CancelDeployment(ctx, deploymentID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	message := fmt.Sprintf("deployment cancelled by: %s", cancel.User)
+	if err = d.queueCancel(ctx, deployment, message); err != nil {
+		return nil, err
+	}
+
+	d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCancelled, *deployment, message))
+
+	result := eve.ToDeployment(*deployment)
+	return &result, nil
+}
+
+// queueCancel sends the cancel command for a deployment that's been cancelled (or rejected), the cancel message gets its own group,
+// the namespace's group could be blocked by the deployment we're cancelling
+func (d *PlanGenerator) queueCancel(ctx context.Context, deployment *data.Deployment, message string) error {
+	body, err := json.StructToJsonObject(eve.CallbackMessage{
+		Messages: []string{message},
+	})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	err = d.q.Message(ctx, &queue.M{
+		ID:      deployment.ID,
+		GroupID: fmt.Sprintf("cancel-%s", deployment.ID),
+		Body:    body,
+		Command: queue.CommandCancelDeployment,
+	})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
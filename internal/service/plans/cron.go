@@ -32,12 +32,15 @@ type DeploymentCron struct {
 	done    chan bool
 	repo    DeploymentCronRepo
 	dq      DeploymentQueuer
+	// frozen holds the cron jobs whose namespaces were all frozen the last time they were due, so it's only logged once
+	frozen map[uuid.UUID]bool
 }
 
 func NewDeploymentCron(repo DeploymentCronRepo, dq DeploymentQueuer, timeout time.Duration) *DeploymentCron {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &DeploymentCron{
 		repo:    repo,
+		frozen:  make(map[uuid.UUID]bool),
 		log:     log.Logger,
 		ctx:     ctx,
 		cancel:  cancel,
@@ -69,11 +72,33 @@ func (dc *DeploymentCron) scheduler(ctx context.Context, job *data.DeploymentCro
 		return nil, errors.Wrap(err)
 	}
 
+	options.SkipFrozen = true
 	err = dc.dq.QueuePlan(ctx, &options)
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
 
+	// the job isn't marked as run when every target is frozen, so it runs once the freeze window is over
+	if len(options.DeploymentIDs) == 0 && len(options.FrozenNamespaces) > 0 {
+		if !dc.frozen[job.ID] {
+			dc.log.Info("deployment cron skipped, all of its namespaces are frozen",
+				zap.String("cron_id", job.ID.String()),
+				zap.String("description", job.Description),
+				zap.Strings("messages", options.Messages))
+			dc.frozen[job.ID] = true
+		}
+		return nil, nil
+	}
+	delete(dc.frozen, job.ID)
+
+	if len(options.FrozenNamespaces) > 0 {
+		dc.log.Info("deployment cron skipped frozen namespaces",
+			zap.String("cron_id", job.ID.String()),
+			zap.String("description", job.Description),
+			zap.Strings("namespaces", options.FrozenNamespaces),
+			zap.Strings("messages", options.Messages))
+	}
+
 	return options.DeploymentIDs, nil
 }
 
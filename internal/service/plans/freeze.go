@@ -0,0 +1,119 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+type FreezeWindowRepo interface {
+	EnabledFreezeWindowsByEnvironmentID(ctx context.Context, environmentID int) (data.FreezeWindows, error)
+}
+
+// checkFreezeWindows returns the namespaces that can be deployed to. A plan with a namespace inside a freeze window is rejected
+// unless it's a dry run or has a freeze override, or it's asked to skip frozen namespaces
+func (d *PlanGenerator) checkFreezeWindows(ctx context.Context, env *data.Environment, options *eve.DeploymentPlanOptions, ns eve.NamespaceRequests) (eve.NamespaceRequests, error) {
+	active, err := activeFreezeWindows(ctx, d.repo, env.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(active) == 0 {
+		return ns, nil
+	}
+
+	var allowed eve.NamespaceRequests
+	var frozen []string
+	for _, x := range ns {
+		window := frozenBy(active, env.ID, x.ID)
+		if window == nil {
+			allowed = append(allowed, x)
+			continue
+		}
+
+		switch {
+		case options.DryRun:
+			options.Message("namespace: %s is frozen by: %s", x.Alias, window.Description)
+			allowed = append(allowed, x)
+		case len(options.FreezeOverride) > 0:
+			options.Message("namespace: %s freeze: %s overridden by: %s, reason: %s", x.Alias, window.Description, options.User, options.FreezeOverride)
+			allowed = append(allowed, x)
+		case options.SkipFrozen:
+			options.Message("namespace: %s skipped, it's frozen by: %s", x.Alias, window.Description)
+			options.FrozenNamespaces = append(options.FrozenNamespaces, x.Alias)
+		default:
+			frozen = append(frozen, x.Alias+" ("+window.Description+")")
+		}
+	}
+
+	if len(frozen) > 0 {
+		return nil, errors.NewRestError(http.StatusConflict, "deployments are frozen for namespace(s): %s", strings.Join(frozen, ", "))
+	}
+
+	if len(options.FrozenNamespaces) > 0 {
+		options.NamespaceAliases = nil
+		for _, x := range allowed {
+			options.NamespaceAliases = append(options.NamespaceAliases, x.Alias)
+		}
+	}
+
+	return allowed, nil
+}
+
+// Frozen is true when err rejected a plan because one of its namespaces is inside a freeze window
+func Frozen(err error) bool {
+	restErr, ok := err.(errors.RestError)
+	return ok && restErr.Code == http.StatusConflict
+}
+
+// frozenDeployment returns the freeze window the deployment's namespace is in when it's released, nil when the namespace
+// isn't frozen or the plan was a dry run or overrode the freeze
+func frozenDeployment(ctx context.Context, repo FreezeWindowRepo, deployment *data.Deployment) (*eve.FreezeWindow, error) {
+	var options eve.NamespacePlanOptions
+	if err := json.Unmarshal(deployment.PlanOptions, &options); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	if options.DryRun || len(options.FreezeOverride) > 0 {
+		return nil, nil
+	}
+
+	active, err := activeFreezeWindows(ctx, repo, deployment.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return frozenBy(active, deployment.EnvironmentID, deployment.NamespaceID), nil
+}
+
+func activeFreezeWindows(ctx context.Context, repo FreezeWindowRepo, environmentID int) ([]eve.FreezeWindow, error) {
+	windows, err := repo.EnabledFreezeWindowsByEnvironmentID(ctx, environmentID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	var active []eve.FreezeWindow
+	for _, x := range windows {
+		if window := eve.ToFreezeWindow(x); window.Active(now) {
+			active = append(active, window)
+		}
+	}
+	return active, nil
+}
+
+func frozenBy(windows []eve.FreezeWindow, environmentID int, namespaceID int) *eve.FreezeWindow {
+	for i, x := range windows {
+		if x.Applies(environmentID, namespaceID) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
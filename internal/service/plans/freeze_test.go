@@ -0,0 +1,28 @@
+package plans
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+func TestFrozen(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "frozen", err: errors.Wrap(errors.NewRestError(409, "deployments are frozen for namespace(s): current")), want: true},
+		{name: "bad request", err: errors.BadRequest("invalid plan"), want: false},
+		{name: "not a rest error", err: fmt.Errorf("connection refused"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Frozen(tt.err); got != tt.want {
+				t.Errorf("Frozen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
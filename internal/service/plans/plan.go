@@ -2,9 +2,8 @@ package plans
 
 import (
 	"context"
+	"database/sql"
 	"sort"
-	"strconv"
-	"strings"
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/unanet/go/pkg/errors"
@@ -13,109 +12,215 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
 	"github.com/unanet/eve/pkg/artifactory"
 	"github.com/unanet/eve/pkg/eve"
 	"github.com/unanet/eve/pkg/queue"
+	"github.com/unanet/eve/pkg/version"
 )
 
 type VersionQuery interface {
-	GetLatestVersion(ctx context.Context, repository string, path string, version string) (string, error)
+	GetLatestVersionMatching(ctx context.Context, repository string, path string, constraint string) (string, error)
+	GetLatestVersionLessThan(ctx context.Context, repository string, path string, lessThanVersion string) (string, error)
 }
 
 type PlanGenerator struct {
-	repo *data.Repo
-	vq   VersionQuery
-	q    QWriter
+	repo   *data.Repo
+	vq     VersionQuery
+	q      QWriter
+	events EventPublisher
 }
 
-func NewPlanGenerator(r *data.Repo, v VersionQuery, q QWriter) *PlanGenerator {
+func NewPlanGenerator(r *data.Repo, v VersionQuery, q QWriter, events EventPublisher) *PlanGenerator {
 	return &PlanGenerator{
-		repo: r,
-		vq:   v,
-		q:    q,
+		repo:   r,
+		vq:     v,
+		q:      q,
+		events: events,
 	}
 }
 
 func (d *PlanGenerator) QueuePlan(ctx context.Context, options *eve.DeploymentPlanOptions) error {
+	options.DeploymentIDs = []uuid.UUID{}
+
+	env, namespaceRequests, artifactsSupplied, err := d.preparePlan(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	// every namespace was frozen and skipped
+	if len(namespaceRequests) == 0 {
+		return nil
+	}
+
+	if options.Type == eve.DeploymentPlanTypeCanary && !options.DryRun {
+		return d.queueCanary(ctx, env, options, namespaceRequests, artifactsSupplied)
+	}
+
+	return d.queueNamespaces(ctx, env, options, namespaceRequests, artifactsSupplied, 0)
+}
+
+// preparePlan validates the plan and resolves the artifact versions it would deploy, it returns whether the artifacts
+// were supplied (rather than generated) and no namespaces when every namespace was frozen and skipped
+func (d *PlanGenerator) preparePlan(ctx context.Context, options *eve.DeploymentPlanOptions) (*data.Environment, eve.NamespaceRequests, bool, error) {
 	// make sure the environment name is valid
 	env, err := d.repo.EnvironmentByName(ctx, options.Environment)
 	if err != nil {
 		if _, ok := err.(data.NotFoundError); ok {
-			return errors.NotFoundf("environment: %s, not found", options.Environment)
+			return nil, nil, false, errors.NotFoundf("environment: %s, not found", options.Environment)
 		}
-		return errors.Wrap(err)
+		return nil, nil, false, errors.Wrap(err)
 	}
 
-	options.DeploymentIDs = []uuid.UUID{}
-
 	// whether they explicitly supplied artifacts or whether they were generated
 	artifactsSupplied := len(options.Artifacts) > 0
 
 	namespaceRequests, err := d.validateNamespaces(ctx, env, options)
 	if err != nil {
-		return errors.Wrap(err)
+		return nil, nil, false, errors.Wrap(err)
+	}
+
+	namespaceRequests, err = d.checkFreezeWindows(ctx, env, options, namespaceRequests)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err)
+	}
+
+	if len(namespaceRequests) == 0 {
+		return env, nil, artifactsSupplied, nil
+	}
+
+	err = d.validateDependencies(ctx, options, namespaceRequests)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err)
 	}
 
 	err = d.validateArtifactDefinitions(ctx, env, options, namespaceRequests)
 	if err != nil {
-		return errors.Wrap(err)
+		return nil, nil, false, errors.Wrap(err)
 	}
 
 	err = d.setArtifactoryVersions(ctx, options)
 	if err != nil {
-		return errors.Wrap(err)
+		return nil, nil, false, errors.Wrap(err)
 	}
 
 	// nothing to do, should exit
 	if len(options.Artifacts) == 0 {
-		return errors.NewRestError(400, "no artifacts would be deployed: %v", options.Messages)
+		return nil, nil, false, errors.NewRestError(400, "no artifacts would be deployed: %v", options.Messages)
+	}
+
+	return env, namespaceRequests, artifactsSupplied, nil
+}
+
+// namespacePlanOptions are the options the api queue builds a namespace's plan from
+func namespacePlanOptions(env *data.Environment, options *eve.DeploymentPlanOptions, ns *eve.NamespaceRequest, artifactsSupplied bool, planType eve.PlanType, canaryCount int) eve.NamespacePlanOptions {
+	return eve.NamespacePlanOptions{
+		NamespaceRequest:  ns,
+		ArtifactsSupplied: artifactsSupplied,
+		Artifacts:         options.Artifacts,
+		ForceDeploy:       options.ForceDeploy,
+		DryRun:            options.DryRun,
+		CallbackURL:       options.CallbackURL,
+		EnvironmentID:     env.ID,
+		EnvironmentName:   env.Name,
+		EnvironmentAlias:  env.Alias,
+		Type:              planType,
+		Metadata:          options.Metadata,
+		FreezeOverride:    options.FreezeOverride,
+		CanaryCount:       canaryCount,
+	}
+}
+
+// queueNamespaces creates a deployment for each namespace and queues the ones that don't need to be approved, when the
+// namespaces have different deploy orders only the lowest order is queued and the rest wait for the sequencer
+func (d *PlanGenerator) queueNamespaces(ctx context.Context, env *data.Environment, options *eve.DeploymentPlanOptions, namespaceRequests eve.NamespaceRequests, artifactsSupplied bool, canaryCount int) error {
+	planType := options.Type
+	if planType == eve.DeploymentPlanTypeCanary {
+		planType = eve.DeploymentPlanTypeApplication
+	}
+
+	// a dry run doesn't deploy anything so there's nothing to wait on
+	orders := namespaceRequests.DeployOrders()
+	var planID uuid.NullUUID
+	if len(orders) > 1 && !options.DryRun {
+		planID = uuid.NullUUID{UUID: uuid.NewV4(), Valid: true}
 	}
 
 	for _, ns := range namespaceRequests {
-		nsPlanOptions, marshalErr := json.StructToJsonObject(&eve.NamespacePlanOptions{
-			NamespaceRequest:  ns,
-			ArtifactsSupplied: artifactsSupplied,
-			Artifacts:         options.Artifacts,
-			ForceDeploy:       options.ForceDeploy,
-			DryRun:            options.DryRun,
-			CallbackURL:       options.CallbackURL,
-			EnvironmentID:     env.ID,
-			EnvironmentName:   env.Name,
-			EnvironmentAlias:  env.Alias,
-			Type:              options.Type,
-			Metadata:          options.Metadata,
-		})
+		nsOptions := namespacePlanOptions(env, options, ns, artifactsSupplied, planType, canaryCount)
+		nsPlanOptions, marshalErr := json.StructToJsonObject(&nsOptions)
 		if marshalErr != nil {
 			return errors.Wrap(marshalErr)
 		}
 		dataDeployment := data.Deployment{
-			EnvironmentID: env.ID,
-			NamespaceID:   ns.ID,
-			ReqID:         log.GetReqID(ctx),
-			PlanOptions:   nsPlanOptions,
-			User:          options.User,
+			EnvironmentID:     env.ID,
+			NamespaceID:       ns.ID,
+			ReqID:             log.GetReqID(ctx),
+			PlanOptions:       nsPlanOptions,
+			User:              options.User,
+			RequestedBy:       service.AuditRequestFromContext(ctx).User,
+			RequiredApprovals: ns.RequiredApprovals,
+			PlanID:            planID,
+			DeployOrder:       ns.DeployOrder,
+		}
+		if options.RolloutID != nil {
+			dataDeployment.RolloutID = uuid.NullUUID{UUID: *options.RolloutID, Valid: true}
+			dataDeployment.RolloutStage = sql.NullString{String: options.RolloutStage, Valid: len(options.RolloutStage) > 0}
+		}
+
+		if options.PipelineRunID != nil {
+			dataDeployment.PipelineRunID = uuid.NullUUID{UUID: *options.PipelineRunID, Valid: true}
+		}
+		// a dry run doesn't change anything so it doesn't need to be approved, a waiting deployment is moved to pending
+		// approval when it's released
+		if planID.Valid && ns.DeployOrder > orders[0] {
+			dataDeployment.State = data.DeploymentStateWaiting
+		} else if ns.RequiredApprovals > 0 && !options.DryRun {
+			dataDeployment.State = data.DeploymentStatePendingApproval
 		}
 		repoErr := d.repo.CreateDeployment(ctx, &dataDeployment)
 		if repoErr != nil {
 			return errors.Wrap(repoErr)
 		}
 		options.DeploymentIDs = append(options.DeploymentIDs, dataDeployment.ID)
-		queueM := queue.M{
-			ID:      dataDeployment.ID,
-			GroupID: ns.GetQueueGroupID(),
-			Command: queue.CommandScheduleDeployment,
+
+		if dataDeployment.State == data.DeploymentStateWaiting {
+			options.Message("deployment to namespace: %s is waiting on the namespaces with a deploy order lower than %d", ns.Alias, ns.DeployOrder)
+			continue
 		}
-		if qErr := d.q.Message(ctx, &queueM); qErr != nil {
-			return errors.Wrap(qErr)
+
+		if dataDeployment.State == data.DeploymentStatePendingApproval {
+			options.Message("deployment to namespace: %s is pending %d approval(s)", ns.Alias, ns.RequiredApprovals)
+			d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventPendingApproval, dataDeployment))
+			continue
 		}
-		repoErr = d.repo.UpdateDeploymentMessageID(ctx, queueM.ID, queueM.MessageID)
-		if repoErr != nil {
-			return errors.Wrap(repoErr)
+
+		d.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventQueued, dataDeployment))
+		if qErr := d.queueDeployment(ctx, dataDeployment.ID, ns.GetQueueGroupID()); qErr != nil {
+			return errors.Wrap(qErr)
 		}
 	}
 	return nil
 }
 
+func (d *PlanGenerator) queueDeployment(ctx context.Context, id uuid.UUID, groupID string) error {
+	queueM := queue.M{
+		ID:      id,
+		GroupID: groupID,
+		Command: queue.CommandScheduleDeployment,
+	}
+	if err := d.q.Message(ctx, &queueM); err != nil {
+		return errors.Wrap(err)
+	}
+
+	err := d.repo.UpdateDeploymentMessageID(ctx, queueM.ID, queueM.MessageID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
 func (d *PlanGenerator) validateArtifactDefinitions(ctx context.Context, env *data.Environment, options *eve.DeploymentPlanOptions, ns eve.NamespaceRequests) error {
 	// If services were supplied, we check those against the database to make sure they are valid and pull
 	// required info needed to lookup in Artifactory
@@ -127,7 +232,7 @@ func (d *PlanGenerator) validateArtifactDefinitions(ctx context.Context, env *da
 			var ras data.RequestArtifacts
 			var err error
 			switch options.Type {
-			case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart:
+			case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart, eve.DeploymentPlanTypeCanary:
 				ras, err = d.repo.RequestServiceArtifactByEnvironment(ctx, x.Name, x.ArtifactName, env.ID, ns.ToIDs())
 			case eve.DeploymentPlanTypeJob:
 				ras, err = d.repo.RequestJobArtifactByEnvironment(ctx, x.Name, x.ArtifactName, env.ID, ns.ToIDs())
@@ -162,7 +267,7 @@ func (d *PlanGenerator) validateArtifactDefinitions(ctx context.Context, env *da
 		var dataArtifacts data.RequestArtifacts
 		var err error
 		switch options.Type {
-		case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart:
+		case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart, eve.DeploymentPlanTypeCanary:
 			dataArtifacts, err = d.repo.ServiceArtifacts(ctx, ns.ToIDs())
 		case eve.DeploymentPlanTypeJob:
 			dataArtifacts, err = d.repo.JobArtifacts(ctx, ns.ToIDs())
@@ -221,11 +326,13 @@ func (d *PlanGenerator) validateNamespaces(ctx context.Context, env *data.Enviro
 	var namespaceRequests eve.NamespaceRequests
 	for _, x := range namespacesToDeploy {
 		namespaceRequests = append(namespaceRequests, &eve.NamespaceRequest{
-			ID:        x.ID,
-			Name:      x.Name,
-			Alias:     x.Alias,
-			ClusterID: x.ClusterID,
-			Version:   x.RequestedVersion,
+			ID:                x.ID,
+			Name:              x.Name,
+			Alias:             x.Alias,
+			ClusterID:         x.ClusterID,
+			Version:           x.RequestedVersion,
+			RequiredApprovals: max(env.RequiredApprovals, x.RequiredApprovals),
+			DeployOrder:       x.DeployOrder,
 		})
 	}
 
@@ -236,13 +343,12 @@ func (d *PlanGenerator) setArtifactoryVersions(ctx context.Context, options *eve
 	// now we query artifactory for the actual version
 	var artifacts eve.ArtifactDefinitions
 	for _, a := range options.Artifacts {
-		// if you didn't pass a full version, we need to add a wildcard so it work correctly to query artifactory
 		log.Logger.Info("get artifact",
 			zap.String("feed", a.ArtifactoryFeed),
 			zap.String("path", a.ArtifactoryPath),
-			zap.String("version", a.ArtifactoryRequestedVersion()),
+			zap.String("version", a.RequestedVersion),
 		)
-		version, err := d.vq.GetLatestVersion(ctx, a.ArtifactoryFeed, a.ArtifactoryPath, a.ArtifactoryRequestedVersion())
+		availableVersion, err := d.vq.GetLatestVersionMatching(ctx, a.ArtifactoryFeed, a.ArtifactoryPath, a.RequestedVersion)
 		if err != nil {
 			if _, ok := err.(artifactory.NotFoundError); ok {
 				options.Message("artifact not found in artifactory: %s/%s/%s:%s", a.ArtifactoryFeed, a.ArtifactoryPath, a.Name, a.ArtifactoryRequestedVersion())
@@ -252,37 +358,20 @@ func (d *PlanGenerator) setArtifactoryVersions(ctx context.Context, options *eve
 		}
 
 		a.RequestedVersion = ""
-		a.AvailableVersion = version
+		a.AvailableVersion = availableVersion
 		artifacts = append(artifacts, a)
 	}
 
 	// we need to sort the higher versions first so that when we match, it tries to match the highest version possible first
-	sort.Slice(artifacts, func(i, j int) bool {
-		jSplit := strings.Split(artifacts[j].AvailableVersion, ".")
-		iSplit := strings.Split(artifacts[i].AvailableVersion, ".")
-		minLength := min(len(jSplit), len(iSplit))
-		for x := 0; x < minLength; x++ {
-			jv, err := strconv.Atoi(jSplit[x])
-			if err != nil {
-				return false
-			}
-			iv, err := strconv.Atoi(iSplit[x])
-			if err != nil {
-				return false
-			}
-			if iv == jv {
-				continue
-			}
-			return jv < iv
-		}
-		return true
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return version.Less(artifacts[j].AvailableVersion, artifacts[i].AvailableVersion)
 	})
 	options.Artifacts = artifacts
 	return nil
 }
 
-func min(x, y int) int {
-	if x > y {
+func max(x, y int) int {
+	if x < y {
 		return y
 	}
 	return x
@@ -0,0 +1,169 @@
+package plans
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// previewBaselineDeployments is how many of a namespace's completed deployments are searched for the last deployment
+// of each artifact in a preview
+const previewBaselineDeployments = 25
+
+// NamespacePreviewer builds the plan a namespace would be sent to the scheduler, it's implemented by the api queue so
+// the preview is built the same way a queued plan is
+type NamespacePreviewer interface {
+	PreviewNamespace(ctx context.Context, options eve.NamespacePlanOptions) (*eve.NamespacePlanPreview, error)
+}
+
+// PreviewPlan validates the plan and builds each namespace's plan synchronously, no deployments are created and nothing
+// is queued
+func (d *PlanGenerator) PreviewPlan(ctx context.Context, options *eve.DeploymentPlanOptions, previewer NamespacePreviewer) (*eve.DeploymentPlanPreview, error) {
+	options.DryRun = true
+
+	env, namespaceRequests, artifactsSupplied, err := d.preparePlan(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	planType := options.Type
+	if planType == eve.DeploymentPlanTypeCanary {
+		planType = eve.DeploymentPlanTypeApplication
+		options.Message("the canary is previewed as an application plan for every namespace")
+	}
+
+	preview := eve.DeploymentPlanPreview{
+		Environment: env.Name,
+		Type:        planType,
+	}
+
+	for _, ns := range namespaceRequests {
+		nsOptions := namespacePlanOptions(env, options, ns, artifactsSupplied, planType, 0)
+		// each namespace matches its own copy of the artifacts, the same as when its options are read back by the queue
+		nsOptions.Artifacts = options.Artifacts.Clone()
+
+		nsPreview, pErr := previewer.PreviewNamespace(ctx, nsOptions)
+		if pErr != nil {
+			return nil, errors.Wrap(pErr)
+		}
+		preview.Namespaces = append(preview.Namespaces, *nsPreview)
+	}
+
+	preview.Messages = options.Messages
+	return &preview, nil
+}
+
+// PreviewNamespace builds the namespace's plan and compares each artifact in it with its last successful deployment
+func (dq *Queue) PreviewNamespace(ctx context.Context, options eve.NamespacePlanOptions) (*eve.NamespacePlanPreview, error) {
+	options.DryRun = true
+
+	var plan *eve.NSDeploymentPlan
+	var err error
+	switch options.Type {
+	case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart:
+		plan, err = dq.createServicesDeployment(ctx, uuid.Nil, options)
+	case eve.DeploymentPlanTypeJob:
+		plan, err = dq.createJobsDeployment(ctx, uuid.Nil, options)
+	default:
+		return nil, errors.BadRequestf("plan type: %s can't be previewed", options.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baselines, err := dq.artifactBaselines(ctx, options.NamespaceRequest.ID, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := eve.NamespacePlanPreview{
+		Namespace: options.NamespaceRequest.Alias,
+		Plan:      plan,
+	}
+
+	for _, x := range plan.Services {
+		artifact, aErr := eve.NewArtifactPreview(eve.PreviewArtifactService, x.ServiceName, x.DeployArtifact, x.Definition, baselines[eve.ServiceNode(x.ServiceName)])
+		if aErr != nil {
+			return nil, aErr
+		}
+		preview.Artifacts = append(preview.Artifacts, artifact)
+	}
+
+	for _, x := range plan.Jobs {
+		artifact, aErr := eve.NewArtifactPreview(eve.PreviewArtifactJob, x.JobName, x.DeployArtifact, x.Definition, baselines[eve.JobNode(x.JobName)])
+		if aErr != nil {
+			return nil, aErr
+		}
+		preview.Artifacts = append(preview.Artifacts, artifact)
+	}
+
+	return &preview, nil
+}
+
+// artifactBaselines finds what the namespace's last completed deployments sent to the scheduler for each artifact in
+// the plan, keyed by its dependency node name. An artifact that wasn't deployed successfully in the searched deployments
+// doesn't have a baseline
+func (dq *Queue) artifactBaselines(ctx context.Context, namespaceID int, plan *eve.NSDeploymentPlan) (map[string]*eve.ArtifactBaseline, error) {
+	baselines := make(map[string]*eve.ArtifactBaseline)
+	needed := len(plan.Services) + len(plan.Jobs)
+	if needed == 0 {
+		return baselines, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, x := range plan.Services {
+		wanted[eve.ServiceNode(x.ServiceName)] = true
+	}
+	for _, x := range plan.Jobs {
+		wanted[eve.JobNode(x.JobName)] = true
+	}
+
+	deployments, err := dq.repo.Deployments(ctx, false, previewBaselineDeployments,
+		data.Where("d.namespace_id", namespaceID),
+		data.Where("d.state", data.DeploymentStateCompleted))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for _, x := range deployments {
+		if len(baselines) == needed {
+			break
+		}
+		if len(x.PlanLocation) == 0 || x.PlanLocation.String() == string(json.EmptyJSONObject) {
+			continue
+		}
+
+		deployed, dErr := eve.UnMarshalNSDeploymentFromLocationBody(ctx, dq.downloader, x.PlanLocation)
+		if dErr != nil {
+			// older plans may have been removed from storage
+			dq.Logger(ctx).Warn("failed to download the deployment plan for the preview", zap.String("id", x.ID.String()), zap.Error(dErr))
+			continue
+		}
+
+		for _, y := range deployed.Services {
+			addBaseline(baselines, wanted, eve.ServiceNode(y.ServiceName), x.ID, y.DeployArtifact, y.Definition)
+		}
+		for _, y := range deployed.Jobs {
+			addBaseline(baselines, wanted, eve.JobNode(y.JobName), x.ID, y.DeployArtifact, y.Definition)
+		}
+	}
+
+	return baselines, nil
+}
+
+func addBaseline(baselines map[string]*eve.ArtifactBaseline, wanted map[string]bool, node string, deploymentID uuid.UUID, a *eve.DeployArtifact, definition []byte) {
+	if !wanted[node] || baselines[node] != nil || a == nil || a.Result != eve.DeployArtifactResultSuccess {
+		return
+	}
+	baselines[node] = &eve.ArtifactBaseline{
+		DeploymentID: deploymentID,
+		Metadata:     a.Metadata,
+		Definition:   definition,
+	}
+}
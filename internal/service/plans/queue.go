@@ -2,7 +2,9 @@ package plans
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/unanet/eve/internal/service/crud"
 	"github.com/unanet/eve/pkg/eve"
 	"github.com/unanet/eve/pkg/queue"
+	"github.com/unanet/eve/pkg/storage"
 	"github.com/unanet/go/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -31,6 +34,11 @@ type HttpCallback interface {
 	Post(ctx context.Context, url string, body interface{}) error
 }
 
+// EventPublisher is where deployment state transitions and scheduler messages are sent so they can be streamed
+type EventPublisher interface {
+	Publish(e eve.DeploymentEvent)
+}
+
 func fromDataService(s data.DeployService) *eve.DeployService {
 	return &eve.DeployService{
 		ServiceID:        s.ServiceID,
@@ -84,24 +92,40 @@ func fromDataJobs(d data.DeployJobs) eve.DeployJobs {
 	return list
 }
 
+func toDataDeploymentResult(deploymentID uuid.UUID, name string, a *eve.DeployArtifact) data.DeploymentResult {
+	return data.DeploymentResult{
+		DeploymentID:     deploymentID,
+		ArtifactID:       a.ArtifactID,
+		ArtifactName:     a.ArtifactName,
+		Name:             name,
+		RequestedVersion: sql.NullString{String: a.RequestedVersion, Valid: len(a.RequestedVersion) > 0},
+		PreviousVersion:  sql.NullString{String: a.DeployedVersion, Valid: len(a.DeployedVersion) > 0},
+		NewVersion:       sql.NullString{String: a.AvailableVersion, Valid: len(a.AvailableVersion) > 0},
+		Result:           a.Result.String(),
+		ExitCode:         a.ExitCode,
+	}
+}
+
 type messageLogger func(format string, a ...interface{})
 
 type Queue struct {
 	worker     QueueWorker
 	repo       *data.Repo
-	uploader   eve.CloudUploader
+	uploader   storage.Uploader
 	callback   HttpCallback
-	downloader eve.CloudDownloader
+	downloader storage.Downloader
 	crud       *crud.Manager
+	events     EventPublisher
 }
 
 func NewQueue(
 	worker QueueWorker,
 	repo *data.Repo,
 	crud *crud.Manager,
-	uploader eve.CloudUploader,
-	downloader eve.CloudDownloader,
-	httpCallBack HttpCallback) *Queue {
+	uploader storage.Uploader,
+	downloader storage.Downloader,
+	httpCallBack HttpCallback,
+	events EventPublisher) *Queue {
 	return &Queue{
 		worker:     worker,
 		repo:       repo,
@@ -109,6 +133,7 @@ func NewQueue(
 		uploader:   uploader,
 		downloader: downloader,
 		callback:   httpCallBack,
+		events:     events,
 	}
 }
 
@@ -154,6 +179,61 @@ func (dq *Queue) matchArtifact(a *eve.DeployArtifact, optName string, options ev
 	a.Deploy = true
 }
 
+// renderTemplates renders the templated values in the artifact's metadata and definitions once its version is known,
+// an artifact that can't be rendered isn't deployed and the error is added to the plan's messages
+func (dq *Queue) renderTemplates(plan *eve.NSDeploymentPlan, name string, a *eve.DeployArtifact, definition *[]byte) bool {
+	if !a.Deploy {
+		return false
+	}
+
+	values := eve.NewTemplateValues(name, a, plan.Namespace, plan.EnvironmentName, a.Metadata)
+	metadata, err := values.RenderMetadata(a.Metadata)
+	if err != nil {
+		a.Deploy = false
+		plan.Message("artifact: %s, name: %s, failed to render the metadata, %s", a.ArtifactName, name, err)
+		return false
+	}
+
+	rendered, err := values.RenderDefinitionBytes(*definition)
+	if err != nil {
+		a.Deploy = false
+		plan.Message("artifact: %s, name: %s, failed to render the definitions, %s", a.ArtifactName, name, err)
+		return false
+	}
+
+	a.Metadata = metadata
+	*definition = rendered
+	return true
+}
+
+// validateDefinitions validates the manifests the artifact's rendered definitions are deployed with using the schemas of
+// their definition types, an artifact with invalid manifests isn't deployed and each violation is added to the plan's messages
+func (dq *Queue) validateDefinitions(plan *eve.NSDeploymentPlan, schemas eve.DefinitionSchemas, spec eve.DeploymentSpec) bool {
+	a := spec.GetArtifact()
+	var definitions eve.DefinitionResults
+	if len(spec.GetDefinitions()) > 0 {
+		if err := json.Unmarshal(spec.GetDefinitions(), &definitions); err != nil {
+			a.Deploy = false
+			plan.Message("artifact: %s, name: %s, failed to read the definitions, %s", a.ArtifactName, spec.GetName(), err)
+			return false
+		}
+	}
+
+	manifests, err := eve.RenderManifests(spec, a.Metadata, definitions, plan.Namespace.Name)
+	if err != nil {
+		a.Deploy = false
+		plan.Message("artifact: %s, name: %s, failed to render the manifests, %s", a.ArtifactName, spec.GetName(), err)
+		return false
+	}
+
+	if err = schemas.ValidateManifests(definitions, manifests); err != nil {
+		a.Deploy = false
+		plan.Message("artifact: %s, name: %s, %s", a.ArtifactName, spec.GetName(), err)
+		return false
+	}
+	return true
+}
+
 func (dq *Queue) setupNSDeploymentPlan(ctx context.Context, deploymentID uuid.UUID, options eve.NamespacePlanOptions) (*eve.NSDeploymentPlan, error) {
 	cluster, err := dq.repo.ClusterByID(ctx, options.NamespaceRequest.ClusterID)
 	if err != nil {
@@ -190,6 +270,10 @@ func (dq *Queue) createServicesDeployment(ctx context.Context, deploymentID uuid
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
+	schemas, err := dq.crud.DefinitionSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
 	services := fromDataServices(dataServices)
 	for _, x := range services {
 		metadata, err := dq.crud.ServiceMetadata(ctx, x.ServiceID)
@@ -211,6 +295,9 @@ func (dq *Queue) createServicesDeployment(ctx context.Context, deploymentID uuid
 		x.Definition = defBytes
 
 		dq.matchArtifact(x.DeployArtifact, x.ServiceName, options, nSDeploymentPlan.Message)
+		if dq.renderTemplates(nSDeploymentPlan, x.ServiceName, x.DeployArtifact, &x.Definition) {
+			dq.validateDefinitions(nSDeploymentPlan, schemas, x)
+		}
 	}
 	// Trap the restart command, since we don't care about matching a service (we just want to restart whatever version is currently deployed)
 	if options.ArtifactsSupplied && options.Type != eve.DeploymentPlanTypeRestart {
@@ -221,9 +308,40 @@ func (dq *Queue) createServicesDeployment(ctx context.Context, deploymentID uuid
 	}
 	nSDeploymentPlan.Services = services.ToDeploy()
 
+	// the canary stage of a rollout only runs some of the replicas
+	if options.CanaryCount > 0 {
+		for _, x := range nSDeploymentPlan.Services {
+			if x.Count > options.CanaryCount {
+				x.Count = options.CanaryCount
+			}
+		}
+	}
+
+	if err = dq.orderServices(ctx, nSDeploymentPlan, options); err != nil {
+		return nil, err
+	}
+
 	return nSDeploymentPlan, nil
 }
 
+func (dq *Queue) setupDeployJob(ctx context.Context, x *eve.DeployJob) error {
+	metadata, err := dq.crud.JobMetadata(ctx, x.JobID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	x.Metadata = metadata
+
+	definition, err := dq.crud.JobDefinitionResults(ctx, x.JobID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	defBytes, _ := json.Marshal(definition)
+
+	x.Definition = defBytes
+	return nil
+}
+
 func (dq *Queue) createJobsDeployment(ctx context.Context, deploymentID uuid.UUID, options eve.NamespacePlanOptions) (*eve.NSDeploymentPlan, error) {
 	nSDeploymentPlan, err := dq.setupNSDeploymentPlan(ctx, deploymentID, options)
 	if err != nil {
@@ -233,24 +351,20 @@ func (dq *Queue) createJobsDeployment(ctx context.Context, deploymentID uuid.UUI
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
+	schemas, err := dq.crud.DefinitionSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
 	jobs := fromDataJobs(dataJobs)
 	for _, x := range jobs {
-		metadata, mErr := dq.crud.JobMetadata(ctx, x.JobID)
-		if mErr != nil {
-			return nil, errors.Wrap(mErr)
+		if err = dq.setupDeployJob(ctx, x); err != nil {
+			return nil, err
 		}
-		x.Metadata = metadata
-
-		definition, dErr := dq.crud.JobDefinitionResults(ctx, x.JobID)
-		if dErr != nil {
-			return nil, errors.Wrap(dErr)
-		}
-
-		defBytes, _ := json.Marshal(definition)
-
-		x.Definition = defBytes
 
 		dq.matchArtifact(x.DeployArtifact, x.JobName, options, nSDeploymentPlan.Message)
+		if dq.renderTemplates(nSDeploymentPlan, x.JobName, x.DeployArtifact, &x.Definition) {
+			dq.validateDefinitions(nSDeploymentPlan, schemas, x)
+		}
 	}
 	if options.ArtifactsSupplied {
 		unmatched := options.Artifacts.UnMatched()
@@ -273,15 +387,36 @@ func (dq *Queue) rollbackError(ctx context.Context, m *queue.M, err error) error
 func (dq *Queue) scheduleDeployment(ctx context.Context, m *queue.M) error {
 	deployment, err := dq.repo.UpdateDeploymentReceiptHandle(ctx, m.ID, m.ReceiptHandle)
 	if err != nil {
+		// it was cancelled before it was scheduled, or it finished before the message was redelivered
+		if _, ok := err.(data.NotFoundError); ok {
+			dq.Logger(ctx).Info("deployment is no longer queued, skipping...", zap.String("id", m.ID.String()))
+			return dq.worker.DeleteMessage(ctx, m)
+		}
 		return dq.rollbackError(ctx, m, err)
 	}
 
+	// the message was redelivered while the scheduler is deploying the plan (or one of its waves), it's kept so the
+	// namespace stays blocked and the deployment resumes from the recorded wave when the scheduler replies
+	if deployment.State == data.DeploymentStateScheduled {
+		dq.Logger(ctx).Info("deployment is already scheduled, keeping the message", zap.String("id", deployment.ID.String()), zap.Int("wave", deployment.Wave))
+		return nil
+	}
+
 	var options eve.NamespacePlanOptions
 	err = json.Unmarshal(deployment.PlanOptions, &options)
 	if err != nil {
 		return dq.rollbackError(ctx, m, err)
 	}
 
+	// the namespace can be frozen after the plan was queued, by the time an approval, a sequencer or a canary releases it
+	window, err := frozenDeployment(ctx, dq.repo, deployment)
+	if err != nil {
+		return dq.rollbackError(ctx, m, err)
+	}
+	if window != nil {
+		return dq.cancelFrozenDeployment(ctx, m, deployment, options, window)
+	}
+
 	var nsDeploymentPlan *eve.NSDeploymentPlan
 	switch options.Type {
 	case eve.DeploymentPlanTypeApplication, eve.DeploymentPlanTypeRestart:
@@ -310,33 +445,50 @@ func (dq *Queue) scheduleDeployment(ctx context.Context, m *queue.M) error {
 			return dq.rollbackError(ctx, m, err)
 		}
 		dq.Logger(ctx).Info("updating scheduled deployment", zap.Any("id", deployment.ID))
-		_, err = dq.repo.UpdateDeploymentResult(ctx, deployment.ID)
+		completed, err := dq.repo.UpdateDeploymentResult(ctx, deployment.ID)
 		if err != nil {
 			return errors.Wrap(err)
 		}
+		dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCompleted, *completed, nsDeploymentPlan.Messages...))
 		return nil
 	}
 
-	mBody, err := eve.MarshalNSDeploymentPlanToS3LocationBody(ctx, dq.uploader, nsDeploymentPlan)
+	mBody, err := eve.MarshalNSDeploymentPlanToLocationBody(ctx, dq.uploader, nsDeploymentPlan)
 	if err != nil {
 		return errors.Wrap(err)
 	}
 
-	err = dq.worker.Message(ctx, nsDeploymentPlan.SchQueueUrl, &queue.M{
-		ID:      deployment.ID,
-		GroupID: nsDeploymentPlan.Namespace.GetQueueGroupID(),
-		Body:    mBody,
-		Command: nsDeploymentPlan.Type.Command(),
-	})
-	if err != nil {
+	if err = dq.storePendingWaves(ctx, nsDeploymentPlan); err != nil {
 		return dq.rollbackError(ctx, m, err)
 	}
 
-	err = dq.repo.UpdateDeploymentPlanLocation(ctx, deployment.ID, mBody)
+	// the deployment is only sent to the scheduler when it's still queued, a cancel that comes in while the plan is
+	// being built wins. The state change is rolled back when the message can't be sent
+	var scheduled *data.Deployment
+	err = dq.repo.WithTx(ctx, func(ctx context.Context) error {
+		var sErr error
+		scheduled, sErr = dq.repo.ScheduleDeployment(ctx, deployment.ID, nsDeploymentPlan.Wave, mBody)
+		if sErr != nil {
+			return sErr
+		}
+
+		return dq.worker.Message(ctx, nsDeploymentPlan.SchQueueUrl, &queue.M{
+			ID:      deployment.ID,
+			GroupID: nsDeploymentPlan.Namespace.GetQueueGroupID(),
+			Body:    mBody,
+			Command: nsDeploymentPlan.Type.Command(),
+		})
+	})
 	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			dq.Logger(ctx).Info("deployment was cancelled while it was being scheduled, skipping...", zap.String("id", deployment.ID.String()))
+			return dq.worker.DeleteMessage(ctx, m)
+		}
 		return dq.rollbackError(ctx, m, err)
 	}
 
+	dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventScheduled, *scheduled, nsDeploymentPlan.Messages...))
+
 	return nil
 }
 
@@ -352,6 +504,9 @@ func (dq *Queue) handleMessage(ctx context.Context, m *queue.M) error {
 
 	case queue.CommandCallbackMessage:
 		return dq.callbackMessage(ctx, m)
+
+	case queue.CommandCancelDeployment:
+		return dq.cancelDeployment(ctx, m)
 	default:
 		return errors.Wrapf("unrecognized command: %s", m.Command)
 	}
@@ -359,12 +514,33 @@ func (dq *Queue) handleMessage(ctx context.Context, m *queue.M) error {
 
 func (dq *Queue) updateDeployment(ctx context.Context, m *queue.M) error {
 	dq.Logger(ctx).Info("updating message deployment", zap.Any("id", m.ID))
-	deployment, err := dq.repo.UpdateDeploymentResult(ctx, m.ID)
+	deployment, err := dq.repo.DeploymentByID(ctx, m.ID)
 	if err != nil {
 		return errors.Wrap(err)
 	}
 
-	plan, err := eve.UnMarshalNSDeploymentFromS3LocationBody(ctx, dq.downloader, m.Body)
+	plan, err := eve.UnMarshalNSDeploymentFromLocationBody(ctx, dq.downloader, m.Body)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	// a reply for a wave before the one that was last sent was already handled
+	if plan.Wave > 0 && plan.Wave < deployment.Wave {
+		dq.Logger(ctx).Info("skipping the reply for a wave that was already handled", zap.String("id", deployment.ID.String()), zap.Int("wave", plan.Wave))
+		return dq.worker.DeleteMessage(ctx, m)
+	}
+
+	location, err := dq.mergeWaveResults(ctx, deployment, plan, m.Body)
+	if err != nil {
+		return err
+	}
+
+	err = dq.repo.UpdateDeploymentResultLocation(ctx, deployment.ID, location)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	err = dq.recordDeploymentResults(ctx, deployment.ID, plan)
 	if err != nil {
 		return errors.Wrap(err)
 	}
@@ -400,15 +576,33 @@ func (dq *Queue) updateDeployment(ctx context.Context, m *queue.M) error {
 		}
 	}
 
+	scheduled, err := dq.scheduleNextWave(ctx, deployment, plan)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	// the original deploy message is kept until the last wave finishes so the namespace stays blocked
+	if scheduled {
+		return dq.worker.DeleteMessage(ctx, m)
+	}
+
+	deployment, err = dq.repo.UpdateDeploymentResult(ctx, m.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
 	// Here we are deleting the original deploy message which unblocks deployments for a namespace in an environment
 	// We will need to add some additional logic to this to account for certain scenarios where we should
 	// Still Delete the Message that triggers this updateDeployment (like an error that returns not found or already deleted)
-	err = dq.worker.DeleteMessage(ctx, &queue.M{
-		ID:            deployment.ID,
-		ReceiptHandle: deployment.ReceiptHandle.String,
-	})
-	if err != nil {
-		return errors.Wrap(err)
+	// A cancelled or timed out deployment already had its original message deleted
+	if !deployment.Released() {
+		err = dq.worker.DeleteMessage(ctx, &queue.M{
+			ID:            deployment.ID,
+			ReceiptHandle: deployment.ReceiptHandle.String,
+		})
+		if err != nil {
+			return errors.Wrap(err)
+		}
 	}
 
 	err = dq.worker.DeleteMessage(ctx, m)
@@ -416,6 +610,29 @@ func (dq *Queue) updateDeployment(ctx context.Context, m *queue.M) error {
 		return errors.Wrap(err)
 	}
 
+	dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCompleted, *deployment, plan.Messages...))
+
+	return nil
+}
+
+// recordDeploymentResults keeps the per service/job outcome so it's available after the plan is removed from storage
+func (dq *Queue) recordDeploymentResults(ctx context.Context, deploymentID uuid.UUID, plan *eve.NSDeploymentPlan) error {
+	for _, x := range plan.Services {
+		result := toDataDeploymentResult(deploymentID, x.ServiceName, x.DeployArtifact)
+		result.ServiceID = sql.NullInt32{Int32: int32(x.ServiceID), Valid: true}
+		if err := dq.repo.UpsertDeploymentResult(ctx, &result); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
+	for _, x := range plan.Jobs {
+		result := toDataDeploymentResult(deploymentID, x.JobName, x.DeployArtifact)
+		result.JobID = sql.NullInt32{Int32: int32(x.JobID), Valid: true}
+		if err := dq.repo.UpsertDeploymentResult(ctx, &result); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
@@ -450,6 +667,8 @@ func (dq *Queue) callbackMessage(ctx context.Context, m *queue.M) error {
 		return errors.Wrap(err)
 	}
 
+	dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventMessage, *d, cm.Messages...))
+
 	dcm := eve.DeploymentCallbackMessage{
 		DeploymentID: m.ID,
 		Status:       eve.DeploymentPlanStatusMessage,
@@ -466,3 +685,85 @@ func (dq *Queue) callbackMessage(ctx context.Context, m *queue.M) error {
 	}
 	return nil
 }
+
+func (dq *Queue) cancelDeployment(ctx context.Context, m *queue.M) error {
+	defer func() {
+		err := dq.worker.DeleteMessage(ctx, m)
+		if err != nil {
+			dq.Logger(ctx).Error("sqs message removal failed", zap.Error(err))
+		}
+	}()
+
+	var cm eve.CallbackMessage
+	err := json.Unmarshal(m.Body, &cm)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	d, err := dq.repo.DeploymentByID(ctx, m.ID)
+	if err != nil {
+		dq.Logger(ctx).Warn("an error occurred trying to get the deployment from the db", zap.String("id", m.ID.String()), zap.Error(errors.Wrap(err)))
+		return nil
+	}
+
+	// The original schedule message is only deleted once the scheduler replies, deleting it here unblocks the namespace,
+	// a deployment that's still queued doesn't have a receipt handle and is skipped when it's received
+	if d.ReceiptHandle.Valid && len(d.ReceiptHandle.String) > 0 {
+		if qErr := dq.worker.DeleteMessage(ctx, &queue.M{ID: d.ID, ReceiptHandle: d.ReceiptHandle.String}); qErr != nil {
+			dq.Logger(ctx).Warn("failed to remove the original message for the cancelled deployment", zap.String("id", d.ID.String()), zap.Error(qErr))
+		}
+	}
+
+	var options eve.NamespacePlanOptions
+	err = json.Unmarshal(d.PlanOptions, &options)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	dq.cancelCallback(ctx, d, options, cm.Messages)
+	return nil
+}
+
+// cancelFrozenDeployment cancels a deployment whose namespace is frozen when it's received
+func (dq *Queue) cancelFrozenDeployment(ctx context.Context, m *queue.M, deployment *data.Deployment, options eve.NamespacePlanOptions, window *eve.FreezeWindow) error {
+	message := fmt.Sprintf("deployment cancelled, namespace: %s is frozen by: %s", options.NamespaceRequest.Alias, window.Description)
+	dq.Logger(ctx).Info(message, zap.String("id", deployment.ID.String()))
+
+	cancelled, err := dq.repo.CancelDeployment(ctx, deployment.ID)
+	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			// it finished or was cancelled in the meantime
+			return dq.worker.DeleteMessage(ctx, m)
+		}
+		return dq.rollbackError(ctx, m, err)
+	}
+
+	if err = dq.worker.DeleteMessage(ctx, m); err != nil {
+		return errors.Wrap(err)
+	}
+
+	dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCancelled, *cancelled, message))
+	dq.cancelCallback(ctx, cancelled, options, []string{message})
+	return nil
+}
+
+func (dq *Queue) cancelCallback(ctx context.Context, d *data.Deployment, options eve.NamespacePlanOptions, messages []string) {
+	if len(options.CallbackURL) == 0 {
+		return
+	}
+
+	status := eve.DeploymentPlanStatusCancelled
+	if d.State == data.DeploymentStateRejected {
+		status = eve.DeploymentPlanStatusRejected
+	}
+
+	dcm := eve.DeploymentCallbackMessage{
+		DeploymentID: d.ID,
+		Status:       status,
+		Type:         options.Type,
+		Messages:     messages,
+	}
+	if cErr := dq.callback.Post(ctx, options.CallbackURL, dcm); cErr != nil {
+		dq.Logger(ctx).Warn("cancel deployment callback failed", zap.String("callback_url", options.CallbackURL), zap.Error(cErr), zap.String("id", d.ID.String()))
+	}
+}
@@ -0,0 +1,155 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/eve/pkg/queue"
+)
+
+type DeploymentReaperRepo interface {
+	TimedOutDeployments(ctx context.Context, defaultTimeout time.Duration, maxTimeout time.Duration) ([]data.Deployment, error)
+	TimeoutDeployment(ctx context.Context, id uuid.UUID) (*data.Deployment, error)
+}
+
+type MessageDeleter interface {
+	DeleteMessage(ctx context.Context, m *queue.M) error
+}
+
+// DeploymentReaper times out deployments that the scheduler never replied to, otherwise the original
+// schedule message is never deleted and the namespace's queue group stays blocked
+type DeploymentReaper struct {
+	log            *zap.Logger
+	defaultTimeout time.Duration
+	maxTimeout     time.Duration
+	timeout        time.Duration
+	ctx            context.Context
+	cancel         context.CancelFunc
+	done           chan bool
+	repo           DeploymentReaperRepo
+	worker         MessageDeleter
+	callback       HttpCallback
+	events         EventPublisher
+}
+
+// reapInterval is how often the reaper looks for timed out deployments
+const reapInterval = time.Minute
+
+// MaxDeploymentTimeout is the longest a deployment can stay scheduled. The original schedule message becomes visible
+// again after the api queue's visibility timeout, the deployment has to be timed out (and the message deleted) before then
+func MaxDeploymentTimeout(visibilityTimeout time.Duration) time.Duration {
+	return visibilityTimeout - reapInterval
+}
+
+func NewDeploymentReaper(repo DeploymentReaperRepo, worker MessageDeleter, callback HttpCallback, events EventPublisher, defaultTimeout time.Duration, maxTimeout time.Duration, timeout time.Duration) *DeploymentReaper {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DeploymentReaper{
+		repo:           repo,
+		worker:         worker,
+		callback:       callback,
+		events:         events,
+		log:            log.Logger,
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan bool),
+		defaultTimeout: defaultTimeout,
+		maxTimeout:     maxTimeout,
+		timeout:        timeout,
+	}
+}
+
+func (dr *DeploymentReaper) Start() {
+	go dr.start()
+	dr.log.Info("deployment reaper started")
+}
+
+func (dr *DeploymentReaper) reap(ctx context.Context, d data.Deployment) error {
+	deployment, err := dr.repo.TimeoutDeployment(ctx, d.ID)
+	if err != nil {
+		// the scheduler replied (or it was cancelled) since we queried
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil
+		}
+		return errors.Wrap(err)
+	}
+
+	dr.log.Warn("deployment timed out waiting on the scheduler", zap.String("id", deployment.ID.String()))
+	message := fmt.Sprintf("deployment timed out, the scheduler didn't respond after %s", time.Since(d.UpdatedAt.Time).Round(time.Second))
+	dr.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventTimedOut, *deployment, message))
+
+	if deployment.ReceiptHandle.Valid && len(deployment.ReceiptHandle.String) > 0 {
+		if qErr := dr.worker.DeleteMessage(ctx, &queue.M{ID: deployment.ID, ReceiptHandle: deployment.ReceiptHandle.String}); qErr != nil {
+			dr.log.Warn("failed to remove the original message for the timed out deployment", zap.String("id", deployment.ID.String()), zap.Error(qErr))
+		}
+	}
+
+	var options eve.NamespacePlanOptions
+	err = json.Unmarshal(deployment.PlanOptions, &options)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if len(options.CallbackURL) > 0 {
+		dcm := eve.DeploymentCallbackMessage{
+			DeploymentID: deployment.ID,
+			Status:       eve.DeploymentPlanStatusErrors,
+			Type:         options.Type,
+			Messages:     []string{message},
+		}
+		if cErr := dr.callback.Post(ctx, options.CallbackURL, dcm); cErr != nil {
+			dr.log.Warn("timed out deployment callback failed", zap.String("callback_url", options.CallbackURL), zap.Error(cErr), zap.String("id", deployment.ID.String()))
+		}
+	}
+
+	return nil
+}
+
+func (dr *DeploymentReaper) run(ctx context.Context) error {
+	deployments, err := dr.repo.TimedOutDeployments(ctx, dr.defaultTimeout, dr.maxTimeout)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for _, x := range deployments {
+		err = dr.reap(ctx, x)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (dr *DeploymentReaper) start() {
+	for {
+		select {
+		case <-dr.ctx.Done():
+			dr.log.Info("deployment reaper stopped")
+			close(dr.done)
+			return
+		default:
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), log.RequestIDKey, log.GetNextRequestID()), dr.timeout)
+			err := dr.run(ctx)
+			if err != nil {
+				dr.log.Error("an error occurred in the deployment reaper", zap.Error(err))
+			}
+			cancel()
+		}
+
+		time.Sleep(reapInterval)
+	}
+}
+
+func (dr *DeploymentReaper) Stop() {
+	dr.cancel()
+	<-dr.done
+}
@@ -0,0 +1,125 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/artifactory"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+// QueueRollback queues a deployment that puts the namespace back to the versions that were deployed before the supplied deployment
+func (d *PlanGenerator) QueueRollback(ctx context.Context, id string, rollback eve.RollbackOptions) (*eve.DeploymentPlanOptions, error) {
+	deploymentID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, errors.NewRestError(400, "invalid deployment id")
+	}
+
+	deployment, err := d.repo.DeploymentByID(ctx, deploymentID)
+	if err != nil {
+		return nil, service.CheckForNotFoundError(err)
+	}
+
+	return d.rollbackDeployment(ctx, deployment, rollback, nil)
+}
+
+// rollbackDeployment queues the rollback of the deployment, it's a stage of the rollout when there is one
+func (d *PlanGenerator) rollbackDeployment(ctx context.Context, deployment *data.Deployment, rollback eve.RollbackOptions, rolloutID *uuid.UUID) (*eve.DeploymentPlanOptions, error) {
+	var nsOptions eve.NamespacePlanOptions
+	err := json.Unmarshal(deployment.PlanOptions, &nsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	if nsOptions.Type == eve.DeploymentPlanTypeRestart {
+		return nil, errors.NewRestError(400, "a restart deployment can not be rolled back")
+	}
+
+	options := eve.DeploymentPlanOptions{
+		ForceDeploy:      true,
+		User:             rollback.User,
+		DryRun:           rollback.DryRun,
+		CallbackURL:      rollback.CallbackURL,
+		Environment:      nsOptions.EnvironmentName,
+		NamespaceAliases: eve.StringList{nsOptions.NamespaceRequest.Alias},
+		Type:             nsOptions.Type,
+	}
+
+	if rolloutID != nil {
+		options.RolloutID = rolloutID
+		options.RolloutStage = data.RolloutStageRollback
+	}
+
+	results, err := d.repo.DeploymentResultsByDeploymentID(ctx, deployment.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	if len(results) > 0 {
+		options.Artifacts = rollbackArtifactsFromResults(results)
+	} else {
+		// deployments that finished before results were recorded (or haven't finished) fall back to asking artifactory
+		// for the version that came before the one that was deployed
+		options.Artifacts, err = d.rollbackArtifactsFromArtifactory(ctx, nsOptions.Artifacts, &options)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+	}
+
+	if len(options.Artifacts) == 0 {
+		return nil, errors.NewRestError(400, "deployment: %s didn't change any versions, nothing to roll back", deployment.ID)
+	}
+
+	err = d.QueuePlan(ctx, &options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &options, nil
+}
+
+func rollbackArtifactsFromResults(results data.DeploymentResults) eve.ArtifactDefinitions {
+	var artifacts eve.ArtifactDefinitions
+	for _, x := range results {
+		if eve.ParseDeployArtifactResult(x.Result) != eve.DeployArtifactResultSuccess || !x.PreviousVersion.Valid {
+			continue
+		}
+
+		artifacts = append(artifacts, &eve.ArtifactDefinition{
+			Name:             x.Name,
+			ArtifactName:     x.ArtifactName,
+			RequestedVersion: x.PreviousVersion.String,
+		})
+	}
+	return artifacts
+}
+
+func (d *PlanGenerator) rollbackArtifactsFromArtifactory(ctx context.Context, deployed eve.ArtifactDefinitions, options *eve.DeploymentPlanOptions) (eve.ArtifactDefinitions, error) {
+	var artifacts eve.ArtifactDefinitions
+	for _, x := range deployed {
+		if x.AvailableVersion == "" {
+			continue
+		}
+
+		version, err := d.vq.GetLatestVersionLessThan(ctx, x.ArtifactoryFeed, x.ArtifactoryPath, x.AvailableVersion)
+		if err != nil {
+			if _, ok := err.(artifactory.NotFoundError); ok {
+				options.Message("no version less than %s found for artifact: %s", x.AvailableVersion, x.ArtifactName)
+				continue
+			}
+			return nil, errors.Wrap(err)
+		}
+
+		artifacts = append(artifacts, &eve.ArtifactDefinition{
+			Name:             x.Name,
+			ArtifactName:     x.ArtifactName,
+			RequestedVersion: version,
+		})
+	}
+	return artifacts, nil
+}
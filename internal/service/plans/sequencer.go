@@ -0,0 +1,234 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+type DeploymentSequencerRepo interface {
+	FreezeWindowRepo
+	WaitingPlanIDs(ctx context.Context) ([]uuid.UUID, error)
+	DeploymentsByPlanID(ctx context.Context, planID uuid.UUID) ([]data.DeploymentHistory, error)
+	DeploymentResultsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (data.DeploymentResults, error)
+	ReleaseWaitingDeployment(ctx context.Context, id uuid.UUID, state data.DeploymentState) (*data.Deployment, error)
+	CancelDeployment(ctx context.Context, id uuid.UUID) (*data.Deployment, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// DeploymentReleaser sends the messages for the deployments the sequencer releases or skips, it's implemented by the
+// PlanGenerator
+type DeploymentReleaser interface {
+	queueDeployment(ctx context.Context, id uuid.UUID, groupID string) error
+	queueCancel(ctx context.Context, deployment *data.Deployment, message string) error
+}
+
+// DeploymentSequencer queues a plan's waiting deployments once every deployment with a lower deploy order has completed,
+// the waiting deployments are skipped (cancelled) when one of those was cancelled, rejected, timed out or failed
+type DeploymentSequencer struct {
+	log     *zap.Logger
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan bool
+	repo    DeploymentSequencerRepo
+	plans   DeploymentReleaser
+	events  EventPublisher
+}
+
+func NewDeploymentSequencer(repo DeploymentSequencerRepo, plans DeploymentReleaser, events EventPublisher, timeout time.Duration) *DeploymentSequencer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DeploymentSequencer{
+		repo:    repo,
+		plans:   plans,
+		events:  events,
+		log:     log.Logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan bool),
+		timeout: timeout,
+	}
+}
+
+func (ds *DeploymentSequencer) Start() {
+	go ds.start()
+	ds.log.Info("deployment sequencer started")
+}
+
+func (ds *DeploymentSequencer) run(ctx context.Context) error {
+	ids, err := ds.repo.WaitingPlanIDs(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for _, x := range ids {
+		// one plan shouldn't hold up the rest
+		if err = ds.sequencePlan(ctx, x); err != nil {
+			ds.log.Error("failed to sequence the plan", zap.String("plan_id", x.String()), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (ds *DeploymentSequencer) start() {
+	for {
+		select {
+		case <-ds.ctx.Done():
+			ds.log.Info("deployment sequencer stopped")
+			close(ds.done)
+			return
+		default:
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), log.RequestIDKey, log.GetNextRequestID()), ds.timeout)
+			err := ds.run(ctx)
+			if err != nil {
+				ds.log.Error("an error occurred in the deployment sequencer", zap.Error(err))
+			}
+			cancel()
+		}
+
+		time.Sleep(15 * time.Second)
+	}
+}
+
+func (ds *DeploymentSequencer) Stop() {
+	ds.cancel()
+	<-ds.done
+}
+
+// sequencePlan releases the plan's lowest waiting deploy order when the orders before it have completed
+func (ds *DeploymentSequencer) sequencePlan(ctx context.Context, planID uuid.UUID) error {
+	deployments, err := ds.repo.DeploymentsByPlanID(ctx, planID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	next, waiting := 0, false
+	for _, x := range deployments {
+		if x.State == data.DeploymentStateWaiting && (!waiting || x.DeployOrder < next) {
+			next, waiting = x.DeployOrder, true
+		}
+	}
+
+	if !waiting {
+		return nil
+	}
+
+	for _, x := range deployments {
+		if x.DeployOrder >= next {
+			continue
+		}
+
+		switch x.State {
+		case data.DeploymentStateQueued, data.DeploymentStateScheduled, data.DeploymentStatePendingApproval:
+			return nil
+		case data.DeploymentStateCompleted:
+		default:
+			return ds.skipWaiting(ctx, deployments, fmt.Sprintf("deployment to namespace: %s was %s", x.NamespaceName.String, x.State))
+		}
+
+		results, rErr := ds.repo.DeploymentResultsByDeploymentID(ctx, x.ID)
+		if rErr != nil {
+			return errors.Wrap(rErr)
+		}
+
+		for _, y := range results {
+			if eve.ParseDeployArtifactResult(y.Result) == eve.DeployArtifactResultFailed {
+				return ds.skipWaiting(ctx, deployments, fmt.Sprintf("deployment of: %s to namespace: %s failed", y.Name, x.NamespaceName.String))
+			}
+		}
+	}
+
+	for _, x := range deployments {
+		if x.DeployOrder != next || x.State != data.DeploymentStateWaiting {
+			continue
+		}
+
+		// a frozen namespace keeps waiting until its freeze window ends
+		window, fErr := frozenDeployment(ctx, ds.repo, &x.Deployment)
+		if fErr != nil {
+			return fErr
+		}
+		if window != nil {
+			log.Logger.Info("waiting deployment is frozen", zap.String("id", x.ID.String()), zap.String("freeze_window", window.Description))
+			continue
+		}
+
+		state := data.DeploymentStateQueued
+		if x.RequiredApprovals > 0 {
+			state = data.DeploymentStatePendingApproval
+		}
+
+		// the release is rolled back when the deployment can't be queued, so it's still waiting the next time around
+		var released *data.Deployment
+		err = ds.repo.WithTx(ctx, func(ctx context.Context) error {
+			var rErr error
+			released, rErr = ds.repo.ReleaseWaitingDeployment(ctx, x.ID, state)
+			if rErr != nil {
+				return rErr
+			}
+
+			if released.State == data.DeploymentStatePendingApproval {
+				return nil
+			}
+
+			ns := eve.NamespaceRequest{ID: released.NamespaceID}
+			return ds.plans.queueDeployment(ctx, released.ID, ns.GetQueueGroupID())
+		})
+		if err != nil {
+			// it was cancelled since we queried
+			if _, ok := err.(data.NotFoundError); ok {
+				continue
+			}
+			return errors.Wrap(err)
+		}
+
+		if released.State == data.DeploymentStatePendingApproval {
+			ds.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventPendingApproval, *released))
+			continue
+		}
+
+		ds.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventQueued, *released))
+	}
+
+	return nil
+}
+
+// skipWaiting cancels the plan's waiting deployments, the reason is sent to the callback and the event subscribers
+func (ds *DeploymentSequencer) skipWaiting(ctx context.Context, deployments []data.DeploymentHistory, reason string) error {
+	message := fmt.Sprintf("deployment skipped, %s", reason)
+	for _, x := range deployments {
+		if x.State != data.DeploymentStateWaiting {
+			continue
+		}
+
+		var cancelled *data.Deployment
+		err := ds.repo.WithTx(ctx, func(ctx context.Context) error {
+			var cErr error
+			cancelled, cErr = ds.repo.CancelDeployment(ctx, x.ID)
+			if cErr != nil {
+				return cErr
+			}
+			return ds.plans.queueCancel(ctx, cancelled, message)
+		})
+		if err != nil {
+			// it was cancelled since we queried
+			if _, ok := err.(data.NotFoundError); ok {
+				log.Logger.Warn("failed to skip the waiting deployment", zap.String("id", x.ID.String()), zap.Error(err))
+				continue
+			}
+			return errors.Wrap(err)
+		}
+		ds.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventCancelled, *cancelled, message))
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+type stubSequencerRepo struct {
+	deployments []data.DeploymentHistory
+	// states is what's been committed, a transaction that fails isn't applied
+	states map[uuid.UUID]data.DeploymentState
+	tx     map[uuid.UUID]data.DeploymentState
+}
+
+func (s *stubSequencerRepo) EnabledFreezeWindowsByEnvironmentID(ctx context.Context, environmentID int) (data.FreezeWindows, error) {
+	return nil, nil
+}
+
+func (s *stubSequencerRepo) WaitingPlanIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (s *stubSequencerRepo) DeploymentsByPlanID(ctx context.Context, planID uuid.UUID) ([]data.DeploymentHistory, error) {
+	return s.deployments, nil
+}
+
+func (s *stubSequencerRepo) DeploymentResultsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (data.DeploymentResults, error) {
+	return nil, nil
+}
+
+func (s *stubSequencerRepo) ReleaseWaitingDeployment(ctx context.Context, id uuid.UUID, state data.DeploymentState) (*data.Deployment, error) {
+	s.tx[id] = state
+	return &data.Deployment{ID: id, State: state}, nil
+}
+
+func (s *stubSequencerRepo) CancelDeployment(ctx context.Context, id uuid.UUID) (*data.Deployment, error) {
+	s.tx[id] = data.DeploymentStateCancelled
+	return &data.Deployment{ID: id, State: data.DeploymentStateCancelled}, nil
+}
+
+func (s *stubSequencerRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.tx = make(map[uuid.UUID]data.DeploymentState)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	for k, v := range s.tx {
+		s.states[k] = v
+	}
+	return nil
+}
+
+type stubReleaser struct {
+	err       error
+	queued    []uuid.UUID
+	cancelled []uuid.UUID
+}
+
+func (s *stubReleaser) queueDeployment(ctx context.Context, id uuid.UUID, groupID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.queued = append(s.queued, id)
+	return nil
+}
+
+func (s *stubReleaser) queueCancel(ctx context.Context, deployment *data.Deployment, message string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.cancelled = append(s.cancelled, deployment.ID)
+	return nil
+}
+
+type stubPublisher struct {
+	events []eve.DeploymentEventType
+}
+
+func (s *stubPublisher) Publish(e eve.DeploymentEvent) {
+	s.events = append(s.events, e.Type)
+}
+
+func sequencedDeployment(id uuid.UUID, order int, state data.DeploymentState) data.DeploymentHistory {
+	return data.DeploymentHistory{
+		Deployment: data.Deployment{
+			ID:          id,
+			DeployOrder: order,
+			State:       state,
+			PlanOptions: json.Object(`{}`),
+		},
+	}
+}
+
+func TestDeploymentSequencer_sequencePlan(t *testing.T) {
+	first, second := uuid.NewV4(), uuid.NewV4()
+
+	tests := []struct {
+		name          string
+		firstState    data.DeploymentState
+		queueErr      error
+		wantErr       bool
+		wantState     data.DeploymentState
+		wantQueued    []uuid.UUID
+		wantCancelled []uuid.UUID
+		wantEvents    []eve.DeploymentEventType
+	}{
+		{
+			name:       "previous order still deploying",
+			firstState: data.DeploymentStateScheduled,
+		},
+		{
+			name:       "previous order completed",
+			firstState: data.DeploymentStateCompleted,
+			wantState:  data.DeploymentStateQueued,
+			wantQueued: []uuid.UUID{second},
+			wantEvents: []eve.DeploymentEventType{eve.DeploymentEventQueued},
+		},
+		{
+			name:       "message can't be sent",
+			firstState: data.DeploymentStateCompleted,
+			queueErr:   fmt.Errorf("queue unavailable"),
+			wantErr:    true,
+		},
+		{
+			name:          "previous order failed",
+			firstState:    data.DeploymentStateTimedOut,
+			wantState:     data.DeploymentStateCancelled,
+			wantCancelled: []uuid.UUID{second},
+			wantEvents:    []eve.DeploymentEventType{eve.DeploymentEventCancelled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSequencerRepo{
+				deployments: []data.DeploymentHistory{
+					sequencedDeployment(first, 1, tt.firstState),
+					sequencedDeployment(second, 2, data.DeploymentStateWaiting),
+				},
+				states: make(map[uuid.UUID]data.DeploymentState),
+			}
+			releaser := &stubReleaser{err: tt.queueErr}
+			events := &stubPublisher{}
+			ds := NewDeploymentSequencer(repo, releaser, events, time.Second)
+
+			err := ds.sequencePlan(context.Background(), uuid.NewV4())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sequencePlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := repo.states[second]; got != tt.wantState {
+				t.Errorf("sequencePlan() state = %v, want %v", got, tt.wantState)
+			}
+			if !reflect.DeepEqual(releaser.queued, tt.wantQueued) {
+				t.Errorf("sequencePlan() queued = %v, want %v", releaser.queued, tt.wantQueued)
+			}
+			if !reflect.DeepEqual(releaser.cancelled, tt.wantCancelled) {
+				t.Errorf("sequencePlan() cancelled = %v, want %v", releaser.cancelled, tt.wantCancelled)
+			}
+			if !reflect.DeepEqual(events.events, tt.wantEvents) {
+				t.Errorf("sequencePlan() events = %v, want %v", events.events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+type RolloutVerifierRepo interface {
+	ActiveRollouts(ctx context.Context) ([]data.Rollout, error)
+	DeploymentsByRolloutID(ctx context.Context, rolloutID uuid.UUID) ([]data.DeploymentHistory, error)
+	DeploymentResultsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (data.DeploymentResults, error)
+	UpdateRolloutState(ctx context.Context, id uuid.UUID, state data.RolloutState, message string, from ...data.RolloutState) (*data.Rollout, error)
+}
+
+// RolloutManager promotes and aborts rollouts, it's implemented by the PlanGenerator
+type RolloutManager interface {
+	promoteRollout(ctx context.Context, id uuid.UUID, message string) error
+	abortRollout(ctx context.Context, id uuid.UUID, message string) error
+	finishAbort(ctx context.Context, rollout *data.Rollout) error
+}
+
+// RolloutVerifier moves rollouts along, a canary is verified once its deployments complete and it's either promoted by
+// the metrics check or aborted (and rolled back) when a deployment fails, the metrics check fails or it times out
+type RolloutVerifier struct {
+	log     *zap.Logger
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan bool
+	repo    RolloutVerifierRepo
+	plans   RolloutManager
+	client  *http.Client
+}
+
+func NewRolloutVerifier(repo RolloutVerifierRepo, plans RolloutManager, timeout time.Duration) *RolloutVerifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RolloutVerifier{
+		repo:    repo,
+		plans:   plans,
+		client:  &http.Client{Timeout: timeout},
+		log:     log.Logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan bool),
+		timeout: timeout,
+	}
+}
+
+func (rv *RolloutVerifier) Start() {
+	go rv.start()
+	rv.log.Info("rollout verifier started")
+}
+
+func (rv *RolloutVerifier) verifyCanary(ctx context.Context, rollout data.Rollout) error {
+	deployments, err := rv.repo.DeploymentsByRolloutID(ctx, rollout.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for _, x := range deployments {
+		if x.RolloutStage.String != data.RolloutStageCanary {
+			continue
+		}
+
+		switch x.State {
+		case data.DeploymentStateQueued, data.DeploymentStateScheduled, data.DeploymentStatePendingApproval, data.DeploymentStateWaiting:
+			return nil
+		case data.DeploymentStateCompleted:
+		default:
+			return rv.plans.abortRollout(ctx, rollout.ID, fmt.Sprintf("canary deployment to namespace: %s was %s", x.NamespaceName.String, x.State))
+		}
+
+		results, err := rv.repo.DeploymentResultsByDeploymentID(ctx, x.ID)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		for _, y := range results {
+			if eve.ParseDeployArtifactResult(y.Result) == eve.DeployArtifactResultFailed {
+				return rv.plans.abortRollout(ctx, rollout.ID, fmt.Sprintf("canary deployment of: %s to namespace: %s failed", y.Name, x.NamespaceName.String))
+			}
+		}
+	}
+
+	_, err = rv.repo.UpdateRolloutState(ctx, rollout.ID, data.RolloutStateVerifying, "canary deployments completed", data.RolloutStateCanary)
+	if err != nil {
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil
+		}
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (rv *RolloutVerifier) checkMetrics(ctx context.Context, rollout data.Rollout, metricsURL string) error {
+	u, err := url.Parse(metricsURL)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("rollout_id", rollout.ID.String())
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := rv.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the metrics check returned: %s", resp.Status)
+	}
+	return nil
+}
+
+func (rv *RolloutVerifier) verifyMetrics(ctx context.Context, rollout data.Rollout) error {
+	plan, err := unmarshalRolloutPlan(&rollout)
+	if err != nil {
+		return err
+	}
+
+	started := rollout.VerifyStartedAt.Time
+	if len(plan.Canary.MetricsURL) == 0 {
+		if time.Since(started) > plan.Canary.TimeoutDuration() {
+			return rv.plans.abortRollout(ctx, rollout.ID, fmt.Sprintf("the canary wasn't promoted within %s", plan.Canary.TimeoutDuration()))
+		}
+		return nil
+	}
+
+	if time.Since(started) < plan.Canary.AnalysisDuration() {
+		return nil
+	}
+
+	if mErr := rv.checkMetrics(ctx, rollout, plan.Canary.MetricsURL); mErr != nil {
+		return rv.plans.abortRollout(ctx, rollout.ID, mErr.Error())
+	}
+
+	return rv.plans.promoteRollout(ctx, rollout.ID, "the metrics check passed")
+}
+
+func (rv *RolloutVerifier) run(ctx context.Context) error {
+	rollouts, err := rv.repo.ActiveRollouts(ctx)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for _, x := range rollouts {
+		switch x.State {
+		case data.RolloutStateCanary:
+			err = rv.verifyCanary(ctx, x)
+		case data.RolloutStateVerifying:
+			err = rv.verifyMetrics(ctx, x)
+		case data.RolloutStateAborting:
+			err = rv.plans.finishAbort(ctx, &x)
+		}
+
+		// one rollout shouldn't hold up the rest
+		if err != nil {
+			rv.log.Error("failed to verify the rollout", zap.String("id", x.ID.String()), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (rv *RolloutVerifier) start() {
+	for {
+		select {
+		case <-rv.ctx.Done():
+			rv.log.Info("rollout verifier stopped")
+			close(rv.done)
+			return
+		default:
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), log.RequestIDKey, log.GetNextRequestID()), rv.timeout)
+			err := rv.run(ctx)
+			if err != nil {
+				rv.log.Error("an error occurred in the rollout verifier", zap.Error(err))
+			}
+			cancel()
+		}
+
+		time.Sleep(15 * time.Second)
+	}
+}
+
+func (rv *RolloutVerifier) Stop() {
+	rv.cancel()
+	<-rv.done
+}
@@ -0,0 +1,220 @@
+package plans
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+type stubVerifierRepo struct {
+	rollouts    []data.Rollout
+	deployments []data.DeploymentHistory
+	results     map[uuid.UUID]data.DeploymentResults
+	states      []data.RolloutState
+}
+
+func (s *stubVerifierRepo) ActiveRollouts(ctx context.Context) ([]data.Rollout, error) {
+	return s.rollouts, nil
+}
+
+func (s *stubVerifierRepo) DeploymentsByRolloutID(ctx context.Context, rolloutID uuid.UUID) ([]data.DeploymentHistory, error) {
+	return s.deployments, nil
+}
+
+func (s *stubVerifierRepo) DeploymentResultsByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (data.DeploymentResults, error) {
+	return s.results[deploymentID], nil
+}
+
+func (s *stubVerifierRepo) UpdateRolloutState(ctx context.Context, id uuid.UUID, state data.RolloutState, message string, from ...data.RolloutState) (*data.Rollout, error) {
+	s.states = append(s.states, state)
+	return &data.Rollout{ID: id, State: state}, nil
+}
+
+type stubRolloutManager struct {
+	promoted []string
+	aborted  []string
+	finished []uuid.UUID
+}
+
+func (s *stubRolloutManager) promoteRollout(ctx context.Context, id uuid.UUID, message string) error {
+	s.promoted = append(s.promoted, message)
+	return nil
+}
+
+func (s *stubRolloutManager) abortRollout(ctx context.Context, id uuid.UUID, message string) error {
+	s.aborted = append(s.aborted, message)
+	return nil
+}
+
+func (s *stubRolloutManager) finishAbort(ctx context.Context, rollout *data.Rollout) error {
+	s.finished = append(s.finished, rollout.ID)
+	return nil
+}
+
+func canaryDeployment(id uuid.UUID, state data.DeploymentState) data.DeploymentHistory {
+	return data.DeploymentHistory{
+		Deployment: data.Deployment{
+			ID:           id,
+			State:        state,
+			RolloutStage: sql.NullString{String: data.RolloutStageCanary, Valid: true},
+		},
+		NamespaceName: sql.NullString{String: "int-api", Valid: true},
+	}
+}
+
+func TestRolloutVerifier_verifyCanary(t *testing.T) {
+	id := uuid.NewV4()
+
+	tests := []struct {
+		name        string
+		deployments []data.DeploymentHistory
+		results     data.DeploymentResults
+		wantAborted bool
+		wantState   data.RolloutState
+	}{
+		{
+			name:        "still deploying",
+			deployments: []data.DeploymentHistory{canaryDeployment(id, data.DeploymentStateScheduled)},
+		},
+		{
+			name:        "deployment timed out",
+			deployments: []data.DeploymentHistory{canaryDeployment(id, data.DeploymentStateTimedOut)},
+			wantAborted: true,
+		},
+		{
+			name:        "artifact failed",
+			deployments: []data.DeploymentHistory{canaryDeployment(id, data.DeploymentStateCompleted)},
+			results:     data.DeploymentResults{{Name: "api", Result: "failed"}},
+			wantAborted: true,
+		},
+		{
+			name:        "completed",
+			deployments: []data.DeploymentHistory{canaryDeployment(id, data.DeploymentStateCompleted)},
+			results:     data.DeploymentResults{{Name: "api", Result: "success"}},
+			wantState:   data.RolloutStateVerifying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubVerifierRepo{deployments: tt.deployments, results: map[uuid.UUID]data.DeploymentResults{id: tt.results}}
+			plans := &stubRolloutManager{}
+			rv := NewRolloutVerifier(repo, plans, time.Second)
+
+			if err := rv.verifyCanary(context.Background(), data.Rollout{ID: uuid.NewV4(), State: data.RolloutStateCanary}); err != nil {
+				t.Fatalf("verifyCanary() error = %v", err)
+			}
+
+			if got := len(plans.aborted) > 0; got != tt.wantAborted {
+				t.Errorf("verifyCanary() aborted = %v, want %v", plans.aborted, tt.wantAborted)
+			}
+
+			var gotState data.RolloutState
+			if len(repo.states) > 0 {
+				gotState = repo.states[0]
+			}
+			if gotState != tt.wantState {
+				t.Errorf("verifyCanary() state = %v, want %v", gotState, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestRolloutVerifier_verifyMetrics(t *testing.T) {
+	passing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer passing.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name         string
+		canary       eve.CanaryOptions
+		started      time.Duration
+		wantPromoted bool
+		wantAborted  bool
+	}{
+		{
+			name:    "waiting on a promote request",
+			canary:  eve.CanaryOptions{Count: 1},
+			started: time.Minute,
+		},
+		{
+			name:        "not promoted in time",
+			canary:      eve.CanaryOptions{Count: 1, Timeout: 60},
+			started:     2 * time.Minute,
+			wantAborted: true,
+		},
+		{
+			name:    "analysing",
+			canary:  eve.CanaryOptions{Count: 1, MetricsURL: passing.URL, Analysis: 300},
+			started: time.Minute,
+		},
+		{
+			name:         "metrics passed",
+			canary:       eve.CanaryOptions{Count: 1, MetricsURL: passing.URL, Analysis: 60},
+			started:      2 * time.Minute,
+			wantPromoted: true,
+		},
+		{
+			name:        "metrics failed",
+			canary:      eve.CanaryOptions{Count: 1, MetricsURL: failing.URL, Analysis: 60},
+			started:     2 * time.Minute,
+			wantAborted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := json.StructToJsonObject(rolloutPlan{Canary: tt.canary})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plans := &stubRolloutManager{}
+			rv := NewRolloutVerifier(&stubVerifierRepo{}, plans, time.Second)
+			rollout := data.Rollout{
+				ID:              uuid.NewV4(),
+				State:           data.RolloutStateVerifying,
+				Plan:            plan,
+				VerifyStartedAt: sql.NullTime{Time: time.Now().Add(-tt.started), Valid: true},
+			}
+
+			if err = rv.verifyMetrics(context.Background(), rollout); err != nil {
+				t.Fatalf("verifyMetrics() error = %v", err)
+			}
+
+			if got := len(plans.promoted) > 0; got != tt.wantPromoted {
+				t.Errorf("verifyMetrics() promoted = %v, want %v", plans.promoted, tt.wantPromoted)
+			}
+			if got := len(plans.aborted) > 0; got != tt.wantAborted {
+				t.Errorf("verifyMetrics() aborted = %v, want %v", plans.aborted, tt.wantAborted)
+			}
+		})
+	}
+}
+
+func TestRolloutVerifier_runRetriesAborting(t *testing.T) {
+	aborting := data.Rollout{ID: uuid.NewV4(), State: data.RolloutStateAborting}
+	plans := &stubRolloutManager{}
+	rv := NewRolloutVerifier(&stubVerifierRepo{rollouts: []data.Rollout{aborting}}, plans, time.Second)
+
+	if err := rv.run(context.Background()); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if len(plans.finished) != 1 || plans.finished[0] != aborting.ID {
+		t.Errorf("run() finished = %v, want %v", plans.finished, aborting.ID)
+	}
+}
@@ -0,0 +1,329 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/pkg/eve"
+	"github.com/unanet/eve/pkg/queue"
+)
+
+// validateDependencies refuses a services plan when a namespace's dependencies can't be ordered
+func (d *PlanGenerator) validateDependencies(ctx context.Context, options *eve.DeploymentPlanOptions, ns eve.NamespaceRequests) error {
+	if options.Type == eve.DeploymentPlanTypeJob {
+		return nil
+	}
+
+	for _, x := range ns {
+		dependencies, err := d.repo.ServiceDependenciesByNamespaceID(ctx, x.ID)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+
+		if cycle := eve.DependencyGraphFromData(dependencies).Cycle(nil); cycle != nil {
+			return errors.NewRestError(400, "namespace: %s, %s", x.Alias, eve.DependencyCycleError{Cycle: cycle})
+		}
+	}
+
+	return nil
+}
+
+// matchDependencyJob matches a job that a service depends on, it's only deployed when the plan has a new version of
+// the job's artifact
+func matchDependencyJob(a *eve.DeployArtifact, options eve.NamespacePlanOptions) bool {
+	for _, x := range options.Artifacts {
+		if x.ID != a.ArtifactID || !eve.MatchesVersion(x.AvailableVersion, a.RequestedVersion) {
+			continue
+		}
+		if x.AvailableVersion == a.DeployedVersion && !options.ForceDeploy {
+			return false
+		}
+		a.AvailableVersion = x.AvailableVersion
+		a.ArtifactoryPath = x.ArtifactoryPath
+		a.ArtifactoryFeed = x.ArtifactoryFeed
+		a.ArtifactoryFeedType = x.FeedType
+		a.Deploy = true
+		return true
+	}
+	return false
+}
+
+// dependencyJobs are the jobs the plan's services depend on that have a new version to deploy
+func (dq *Queue) dependencyJobs(ctx context.Context, dependencies data.ServiceDependencies, plan *eve.NSDeploymentPlan, options eve.NamespacePlanOptions) (eve.DeployJobs, error) {
+	deploying := make(map[int]bool)
+	for _, x := range plan.Services {
+		deploying[x.ServiceID] = true
+	}
+
+	needed := make(map[int]bool)
+	for _, x := range dependencies {
+		if x.DependsOnJobID.Valid && deploying[x.ServiceID] {
+			needed[int(x.DependsOnJobID.Int32)] = true
+		}
+	}
+
+	if len(needed) == 0 {
+		return nil, nil
+	}
+
+	dataJobs, err := dq.repo.DeployedJobsByNamespaceID(ctx, options.NamespaceRequest.ID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	schemas, err := dq.crud.DefinitionSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs eve.DeployJobs
+	for _, x := range fromDataJobs(dataJobs) {
+		if !needed[x.JobID] || !matchDependencyJob(x.DeployArtifact, options) {
+			continue
+		}
+		if err = dq.setupDeployJob(ctx, x); err != nil {
+			return nil, err
+		}
+		if !dq.renderTemplates(plan, x.JobName, x.DeployArtifact, &x.Definition) ||
+			!dq.validateDefinitions(plan, schemas, x) {
+			continue
+		}
+		jobs = append(jobs, x)
+	}
+
+	return jobs, nil
+}
+
+// orderServices splits the plan into waves when its services depend on each other or on a job that's being deployed,
+// the first wave stays in the plan and the rest are sent to the scheduler once the wave before them finishes
+func (dq *Queue) orderServices(ctx context.Context, plan *eve.NSDeploymentPlan, options eve.NamespacePlanOptions) error {
+	if len(plan.Services) == 0 {
+		return nil
+	}
+
+	dependencies, err := dq.repo.ServiceDependenciesByNamespaceID(ctx, options.NamespaceRequest.ID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if len(dependencies) == 0 {
+		return nil
+	}
+
+	// a restart doesn't change any versions so there aren't any jobs to run first
+	var jobs eve.DeployJobs
+	if options.Type == eve.DeploymentPlanTypeApplication {
+		jobs, err = dq.dependencyJobs(ctx, dependencies, plan, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	var nodes []string
+	services := make(map[string]*eve.DeployService)
+	for _, x := range plan.Services {
+		node := eve.ServiceNode(x.ServiceName)
+		services[node] = x
+		nodes = append(nodes, node)
+	}
+	deployJobs := make(map[string]*eve.DeployJob)
+	for _, x := range jobs {
+		node := eve.JobNode(x.JobName)
+		deployJobs[node] = x
+		nodes = append(nodes, node)
+	}
+
+	nodeWaves, err := eve.DependencyGraphFromData(dependencies).Waves(nodes)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if len(nodeWaves) < 2 {
+		return nil
+	}
+
+	var waves []eve.DeploymentWave
+	for i, x := range nodeWaves {
+		var wave eve.DeploymentWave
+		for _, node := range x {
+			if svc, ok := services[node]; ok {
+				wave.Services = append(wave.Services, svc)
+			} else {
+				wave.Jobs = append(wave.Jobs, deployJobs[node])
+			}
+		}
+		waves = append(waves, wave)
+		plan.Message("wave %d: %s", i+1, strings.Join(x, ", "))
+	}
+
+	// a dry run shows the waves without splitting the plan
+	if options.DryRun {
+		plan.Jobs = append(plan.Jobs, jobs...)
+		return nil
+	}
+
+	plan.Wave = 1
+	plan.Services = waves[0].Services
+	plan.Jobs = waves[0].Jobs
+	plan.PendingWaves = waves[1:]
+	return nil
+}
+
+// storePendingWaves uploads the waves after the first one, they're sent in order as each wave finishes
+func (dq *Queue) storePendingWaves(ctx context.Context, plan *eve.NSDeploymentPlan) error {
+	if len(plan.PendingWaves) == 0 {
+		return nil
+	}
+
+	var locations []json.Object
+	for i, x := range plan.PendingWaves {
+		body, err := eve.MarshalNSDeploymentPlanToLocationBody(ctx, dq.uploader, plan.WavePlan(plan.Wave+i+1, x))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		locations = append(locations, body)
+	}
+
+	return dq.repo.UpdateDeploymentPendingWaves(ctx, plan.DeploymentID, locations)
+}
+
+// scheduleNextWave sends the next wave to the scheduler, the remaining waves are skipped when the deployment was
+// cancelled or timed out or something in the wave that just finished failed
+func (dq *Queue) scheduleNextWave(ctx context.Context, deployment *data.Deployment, plan *eve.NSDeploymentPlan) (bool, error) {
+	waves, err := deployment.Waves()
+	if err != nil {
+		return false, err
+	}
+
+	if len(waves) == 0 {
+		return false, nil
+	}
+
+	var failed []string
+	for _, x := range plan.Services {
+		if x.Result == eve.DeployArtifactResultFailed {
+			failed = append(failed, x.ServiceName)
+		}
+	}
+	for _, x := range plan.Jobs {
+		if x.Result == eve.DeployArtifactResultFailed {
+			failed = append(failed, x.JobName)
+		}
+	}
+
+	if deployment.Released() || len(failed) > 0 {
+		if err = dq.repo.UpdateDeploymentPendingWaves(ctx, deployment.ID, nil); err != nil {
+			return false, err
+		}
+		if len(failed) > 0 {
+			plan.Message("%d remaining wave(s) skipped, %s failed", len(waves), strings.Join(failed, ", "))
+		} else {
+			plan.Message("%d remaining wave(s) skipped, the deployment was %s", len(waves), deployment.State)
+		}
+		return false, nil
+	}
+
+	cluster, err := dq.repo.ClusterByID(ctx, plan.Namespace.ClusterID)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+
+	// the updates are rolled back when the wave can't be sent, and a wave that's sent again when the reply is redelivered
+	// after the updates failed is dropped by the queue's dedupe
+	next := waves[0]
+	err = dq.repo.WithTx(ctx, func(ctx context.Context) error {
+		if uErr := dq.repo.UpdateDeploymentPendingWaves(ctx, deployment.ID, waves[1:]); uErr != nil {
+			return uErr
+		}
+
+		// the plan location keeps the results of the waves that finished, the wave's plan comes back with the reply
+		if uErr := dq.repo.UpdateDeploymentWave(ctx, deployment.ID, plan.Wave+1); uErr != nil {
+			return uErr
+		}
+
+		return dq.worker.Message(ctx, cluster.SchQueueUrl, &queue.M{
+			ID:       deployment.ID,
+			GroupID:  plan.Namespace.GetQueueGroupID(),
+			Body:     next,
+			Command:  plan.Type.Command(),
+			DedupeID: waveDedupeID(deployment.ID, plan.Wave+1),
+		})
+	})
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+
+	deployment.State = data.DeploymentStateScheduled
+	dq.events.Publish(eve.NewDeploymentEvent(eve.DeploymentEventScheduled, *deployment, fmt.Sprintf("the next wave was scheduled, %d wave(s) remaining after it", len(waves)-1)))
+	return true, nil
+}
+
+// waveDedupeID is the same each time a deployment's wave is sent
+func waveDedupeID(id uuid.UUID, wave int) string {
+	return fmt.Sprintf("%s-wave-%d", id, wave)
+}
+
+// mergeWaveResults returns the location of the deployment's results, the results of a wave are merged with the results
+// of the waves before it so the plan location has every wave's results. A redelivered reply replaces its own results
+func (dq *Queue) mergeWaveResults(ctx context.Context, deployment *data.Deployment, plan *eve.NSDeploymentPlan, body json.Object) (json.Object, error) {
+	if plan.Wave < 2 || len(deployment.PlanLocation) == 0 {
+		return body, nil
+	}
+
+	previous, err := eve.UnMarshalNSDeploymentFromLocationBody(ctx, dq.downloader, deployment.PlanLocation)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	merged := mergeWavePlans(previous, plan)
+	location, err := eve.MarshalNSDeploymentPlanToLocationBody(ctx, dq.uploader, merged)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return location, nil
+}
+
+func mergeWavePlans(previous *eve.NSDeploymentPlan, plan *eve.NSDeploymentPlan) *eve.NSDeploymentPlan {
+	merged := *plan
+
+	services := make(map[int]bool)
+	for _, x := range plan.Services {
+		services[x.ServiceID] = true
+	}
+	merged.Services = nil
+	for _, x := range previous.Services {
+		if !services[x.ServiceID] {
+			merged.Services = append(merged.Services, x)
+		}
+	}
+	merged.Services = append(merged.Services, plan.Services...)
+
+	jobs := make(map[int]bool)
+	for _, x := range plan.Jobs {
+		jobs[x.JobID] = true
+	}
+	merged.Jobs = nil
+	for _, x := range previous.Jobs {
+		if !jobs[x.JobID] {
+			merged.Jobs = append(merged.Jobs, x)
+		}
+	}
+	merged.Jobs = append(merged.Jobs, plan.Jobs...)
+
+	messages := make(map[string]bool)
+	merged.Messages = nil
+	for _, x := range append(previous.Messages, plan.Messages...) {
+		if !messages[x] {
+			messages[x] = true
+			merged.Messages = append(merged.Messages, x)
+		}
+	}
+
+	return &merged
+}
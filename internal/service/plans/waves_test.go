@@ -0,0 +1,82 @@
+package plans
+
+import (
+	"reflect"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestQueue_mergeWavePlans(t *testing.T) {
+	api := &eve.DeployService{ServiceID: 1, ServiceName: "api", DeployArtifact: &eve.DeployArtifact{Result: eve.DeployArtifactResultSuccess}}
+	migrate := &eve.DeployJob{JobID: 1, JobName: "migrate", DeployArtifact: &eve.DeployArtifact{Result: eve.DeployArtifactResultSuccess}}
+	web := &eve.DeployService{ServiceID: 2, ServiceName: "web", DeployArtifact: &eve.DeployArtifact{Result: eve.DeployArtifactResultFailed}}
+
+	previous := &eve.NSDeploymentPlan{
+		Wave:     1,
+		Services: eve.DeployServices{api},
+		Jobs:     eve.DeployJobs{migrate},
+		Messages: []string{"wave 1: job:migrate, service:api"},
+	}
+
+	tests := []struct {
+		name         string
+		plan         *eve.NSDeploymentPlan
+		wantServices []string
+		wantJobs     []string
+		wantMessages []string
+	}{
+		{
+			name:         "next wave",
+			plan:         &eve.NSDeploymentPlan{Wave: 2, Services: eve.DeployServices{web}, Messages: []string{"web failed"}},
+			wantServices: []string{"api", "web"},
+			wantJobs:     []string{"migrate"},
+			wantMessages: []string{"wave 1: job:migrate, service:api", "web failed"},
+		},
+		{
+			name:         "redelivered wave",
+			plan:         &eve.NSDeploymentPlan{Wave: 1, Services: eve.DeployServices{api}, Jobs: eve.DeployJobs{migrate}, Messages: []string{"wave 1: job:migrate, service:api"}},
+			wantServices: []string{"api"},
+			wantJobs:     []string{"migrate"},
+			wantMessages: []string{"wave 1: job:migrate, service:api"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeWavePlans(previous, tt.plan)
+
+			var services, jobs []string
+			for _, x := range got.Services {
+				services = append(services, x.ServiceName)
+			}
+			for _, x := range got.Jobs {
+				jobs = append(jobs, x.JobName)
+			}
+
+			if !reflect.DeepEqual(services, tt.wantServices) {
+				t.Errorf("mergeWavePlans() services = %v, want %v", services, tt.wantServices)
+			}
+			if !reflect.DeepEqual(jobs, tt.wantJobs) {
+				t.Errorf("mergeWavePlans() jobs = %v, want %v", jobs, tt.wantJobs)
+			}
+			if !reflect.DeepEqual(got.Messages, tt.wantMessages) {
+				t.Errorf("mergeWavePlans() messages = %v, want %v", got.Messages, tt.wantMessages)
+			}
+			if got.Wave != tt.plan.Wave {
+				t.Errorf("mergeWavePlans() wave = %d, want %d", got.Wave, tt.plan.Wave)
+			}
+		})
+	}
+}
+
+func TestQueue_waveDedupeID(t *testing.T) {
+	id := uuid.NewV4()
+	if waveDedupeID(id, 2) != waveDedupeID(id, 2) {
+		t.Errorf("waveDedupeID() isn't the same for the same wave")
+	}
+	if waveDedupeID(id, 2) == waveDedupeID(id, 3) {
+		t.Errorf("waveDedupeID() is the same for different waves")
+	}
+}
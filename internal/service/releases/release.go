@@ -49,15 +49,6 @@ func artifactRepoPath(providerGroup, artifactName, version string) string {
 	return fmt.Sprintf("%s/%s/%s", providerGroup, artifactName, version)
 }
 
-func version(version string) string {
-	if version == "" {
-		return "*"
-	} else if len(strings.Split(version, ".")) < 4 {
-		return version + ".*"
-	}
-	return version
-}
-
 func evalArtifactImageTag(a *data.Artifact, availableVersion string) string {
 	imageTag := a.ImageTag
 	versionSplit := strings.Split(availableVersion, ".")
@@ -98,10 +89,13 @@ func (svc *ReleaseSvc) releaseInfo(ctx context.Context, release eve.Release) (*a
 		return nil, goerrors.Wrapf(err, "failed to get the artifact destination (to) feed")
 	}
 
-	artifactVersion, err := svc.artifactoryClient.GetLatestVersion(ctx, fromFeed.Name, path(artifact.ProviderGroup, artifact.Name), version(release.Version))
+	artifactVersion, err := svc.artifactoryClient.GetLatestVersionMatching(ctx, fromFeed.Name, path(artifact.ProviderGroup, artifact.Name), release.Version)
 	if err != nil {
 		if _, ok := err.(artifactory.NotFoundError); ok {
-			return nil, errors.NotFound(fmt.Sprintf("artifact not found in artifactory: %s/%s/%s:%s", fromFeed.Name, path(artifact.ProviderGroup, artifact.Name), artifact.Name, version(release.Version)))
+			return nil, errors.NotFound(fmt.Sprintf("artifact not found in artifactory: %s/%s/%s:%s", fromFeed.Name, path(artifact.ProviderGroup, artifact.Name), artifact.Name, release.Version))
+		}
+		if _, ok := err.(artifactory.InvalidRequestError); ok {
+			return nil, errors.BadRequest(err.Error())
 		}
 		return nil, goerrors.Wrapf(err, "failed to get the latest artifact version")
 	}
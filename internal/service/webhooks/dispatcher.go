@@ -0,0 +1,352 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+	ehttp "github.com/unanet/go/pkg/http"
+	"github.com/unanet/go/pkg/log"
+	"go.uber.org/zap"
+
+	"github.com/unanet/eve/internal/data"
+	"github.com/unanet/eve/internal/service"
+	"github.com/unanet/eve/pkg/eve"
+)
+
+const (
+	userAgent = "eve"
+
+	maxAttempts    = 10
+	baseBackoff    = 10 * time.Second
+	maxBackoff     = time.Hour
+	claimLease     = 2 * time.Minute
+	claimLimit     = 20
+	pollInterval   = 5 * time.Second
+	publishTimeout = 10 * time.Second
+	// maxResponseError is how much of a failed response body is kept with the attempt
+	maxResponseError = 1024
+	// deliveryRetention is how long a finished delivery (and its payload) is kept before it's pruned
+	deliveryRetention = 30 * 24 * time.Hour
+	pruneInterval     = time.Hour
+)
+
+// Sign returns the signature sent in the WebhookSignatureHeader
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is how long to wait before the next attempt after the supplied number of attempts have failed
+func Backoff(attempts int) time.Duration {
+	backoff := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempts-1)))
+	if backoff > maxBackoff || backoff <= 0 {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// Dispatcher persists every webhook/callback delivery and sends it, failed deliveries are retried
+// with an exponential backoff by the retry loop (on whichever api instance claims them first)
+type Dispatcher struct {
+	repo           *data.Repo
+	client         *http.Client
+	callbackSecret string
+	log            *zap.Logger
+	ctx            context.Context
+	cancel         context.CancelFunc
+	done           chan bool
+}
+
+// NewDispatcher creates a Dispatcher, the callbackSecret is used to sign deliveries to a plan's callback_url
+func NewDispatcher(repo *data.Repo, timeout time.Duration, callbackSecret string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: ehttp.LoggingTransport,
+		},
+		callbackSecret: callbackSecret,
+		log:            log.Logger,
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan bool),
+	}
+}
+
+// Post records a delivery to a plan's callback url and makes the first attempt, an error means
+// the first attempt failed and the delivery will be retried
+func (d *Dispatcher) Post(ctx context.Context, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	var ref struct {
+		DeploymentID uuid.UUID `json:"deployment_id"`
+	}
+	_ = json.Unmarshal(payload, &ref)
+
+	delivery := data.WebhookDelivery{
+		DeploymentID: uuid.NullUUID{UUID: ref.DeploymentID, Valid: ref.DeploymentID != uuid.Nil},
+		URL:          url,
+		Event:        eve.WebhookEventCallback,
+		Payload:      payload,
+	}
+	err = d.repo.CreateWebhookDelivery(ctx, &delivery)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	return d.sendNow(ctx, delivery.ID)
+}
+
+// Publish records and sends a delivery for each of the environment's webhooks that apply to the event's namespace,
+// it's published from the queue handlers so the work is done in the background
+func (d *Dispatcher) Publish(e eve.DeploymentEvent) {
+	go d.publish(e)
+}
+
+func (d *Dispatcher) publish(e eve.DeploymentEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	webhooks, err := d.repo.WebhooksByNamespace(ctx, e.EnvironmentID, e.NamespaceID)
+	if err != nil {
+		d.log.Error("failed to get the webhooks for the event", zap.Int("environment_id", e.EnvironmentID), zap.Error(err))
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		d.log.Error("failed to marshal the event", zap.Error(err))
+		return
+	}
+
+	var ids []uuid.UUID
+	for _, x := range webhooks {
+		delivery := data.WebhookDelivery{
+			WebhookID:    sql.NullInt32{Int32: int32(x.ID), Valid: true},
+			DeploymentID: uuid.NullUUID{UUID: e.DeploymentID, Valid: true},
+			URL:          x.URL,
+			Event:        string(e.Type),
+			Payload:      payload,
+		}
+		err = d.repo.CreateWebhookDelivery(ctx, &delivery)
+		if err != nil {
+			d.log.Error("failed to record the webhook delivery", zap.Int("webhook_id", x.ID), zap.Error(err))
+			continue
+		}
+		ids = append(ids, delivery.ID)
+	}
+
+	for _, id := range ids {
+		sCtx, sCancel := context.WithTimeout(context.Background(), d.client.Timeout+publishTimeout)
+		_ = d.sendNow(sCtx, id)
+		sCancel()
+	}
+}
+
+// Redeliver resets the delivery so it's sent again, the attempt log is kept
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) error {
+	uID, err := uuid.FromString(id)
+	if err != nil {
+		return errors.NewRestError(400, "invalid webhook delivery id")
+	}
+
+	_, err = d.repo.RedeliverWebhookDelivery(ctx, uID)
+	if err != nil {
+		return service.CheckForNotFoundError(err)
+	}
+
+	go func() {
+		sCtx, cancel := context.WithTimeout(context.Background(), d.client.Timeout+publishTimeout)
+		defer cancel()
+		_ = d.sendNow(sCtx, uID)
+	}()
+
+	return nil
+}
+
+func (d *Dispatcher) sendNow(ctx context.Context, id uuid.UUID) error {
+	delivery, err := d.repo.ClaimWebhookDelivery(ctx, id, claimLease)
+	if err != nil {
+		// it's already been claimed by the retry loop
+		if _, ok := err.(data.NotFoundError); ok {
+			return nil
+		}
+		return errors.Wrap(err)
+	}
+
+	return d.send(ctx, delivery)
+}
+
+func (d *Dispatcher) secret(delivery *data.WebhookDelivery) string {
+	if delivery.WebhookID.Valid {
+		return delivery.Secret
+	}
+	return d.callbackSecret
+}
+
+// send makes an attempt and records the outcome, the returned error is the attempt's error
+func (d *Dispatcher) send(ctx context.Context, delivery *data.WebhookDelivery) error {
+	started := time.Now()
+	code, sendErr := d.post(ctx, delivery)
+
+	attempt := data.WebhookDeliveryAttempt{
+		Attempt:    delivery.Attempts + 1,
+		DurationMS: int(time.Since(started).Milliseconds()),
+	}
+	if code > 0 {
+		attempt.ResponseCode = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+	if sendErr != nil {
+		attempt.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+
+	delivery.Attempts = attempt.Attempt
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		delivery.State = data.WebhookDeliveryStateDelivered
+		delivery.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		delivery.NextAttemptAt = sql.NullTime{Time: now, Valid: true}
+	case delivery.Attempts >= maxAttempts:
+		delivery.State = data.WebhookDeliveryStateFailed
+		delivery.NextAttemptAt = sql.NullTime{Time: now, Valid: true}
+	default:
+		delivery.State = data.WebhookDeliveryStatePending
+		delivery.NextAttemptAt = sql.NullTime{Time: now.Add(Backoff(delivery.Attempts)), Valid: true}
+	}
+
+	// the attempt is recorded even if the request's context is done
+	rCtx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := d.repo.RecordWebhookDeliveryAttempt(rCtx, delivery, &attempt); err != nil {
+		d.log.Error("failed to record the webhook delivery attempt", zap.String("delivery_id", delivery.ID.String()), zap.Error(err))
+	}
+
+	return sendErr
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery *data.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(eve.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(eve.WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(eve.WebhookEventHeader, delivery.Event)
+	if secret := d.secret(delivery); len(secret) > 0 {
+		req.Header.Set(eve.WebhookSignatureHeader, Sign(secret, timestamp, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseError))
+	return resp.StatusCode, fmt.Errorf("webhook responded with %d: %s", resp.StatusCode, body)
+}
+
+func (d *Dispatcher) retry(ctx context.Context) error {
+	deliveries, err := d.repo.ClaimWebhookDeliveries(ctx, claimLimit, claimLease)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	for i := range deliveries {
+		if sErr := d.send(ctx, &deliveries[i]); sErr != nil {
+			d.log.Warn("webhook delivery attempt failed",
+				zap.String("delivery_id", deliveries[i].ID.String()),
+				zap.Int("attempts", deliveries[i].Attempts),
+				zap.Error(sErr))
+		}
+	}
+
+	return nil
+}
+
+// Start runs the retry loop
+func (d *Dispatcher) Start() {
+	go d.start()
+	d.log.Info("webhook dispatcher started")
+}
+
+// prune deletes the finished deliveries, along with their payloads and attempts, once they're past the retention period
+func (d *Dispatcher) prune(ctx context.Context) error {
+	pruned, err := d.repo.DeleteWebhookDeliveriesBefore(ctx, time.Now().UTC().Add(-deliveryRetention))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if pruned > 0 {
+		d.log.Info("pruned webhook deliveries", zap.Int64("count", pruned))
+	}
+	return nil
+}
+
+func (d *Dispatcher) start() {
+	var lastPruned time.Time
+	for {
+		select {
+		case <-d.ctx.Done():
+			d.log.Info("webhook dispatcher stopped")
+			close(d.done)
+			return
+		default:
+			ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), log.RequestIDKey, log.GetNextRequestID()), claimLease)
+			err := d.retry(ctx)
+			if err != nil {
+				d.log.Error("an error occurred retrying webhook deliveries", zap.Error(err))
+			}
+			if time.Since(lastPruned) >= pruneInterval {
+				err = d.prune(ctx)
+				if err != nil {
+					d.log.Error("an error occurred pruning webhook deliveries", zap.Error(err))
+				}
+				lastPruned = time.Now()
+			}
+			cancel()
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	<-d.done
+}
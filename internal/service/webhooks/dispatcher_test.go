@@ -0,0 +1,30 @@
+package webhooks_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/internal/service/webhooks"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"deployment_id":"abc"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1600000000.{"deployment_id":"abc"}`))
+
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), webhooks.Sign("secret", 1600000000, body))
+	assert.NotEqual(t, webhooks.Sign("secret", 1600000000, body), webhooks.Sign("secret", 1600000001, body))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, webhooks.Backoff(1))
+	assert.Equal(t, 20*time.Second, webhooks.Backoff(2))
+	assert.Equal(t, 80*time.Second, webhooks.Backoff(4))
+	assert.Equal(t, time.Hour, webhooks.Backoff(20))
+	assert.Equal(t, time.Hour, webhooks.Backoff(200))
+}
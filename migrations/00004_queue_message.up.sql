@@ -0,0 +1,23 @@
+create table if not exists queue_message
+(
+    id             bigserial                                 not null,
+    queue_url      varchar(200)                              not null,
+    message_id     uuid         default uuid_generate_v4()   not null,
+    group_id       varchar(128)                              not null,
+    dedupe_id      varchar(128) default ''::character varying not null,
+    eve_id         uuid                                      not null,
+    command        varchar(50)                               not null,
+    req_id         varchar(100),
+    body           text                                      not null,
+    receipt_handle varchar(100),
+    receive_count  integer      default 0                    not null,
+    visible_at     timestamp    default now()                not null,
+    created_at     timestamp    default now()                not null,
+    deleted_at     timestamp,
+    constraint queue_message_pk
+        primary key (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_message_queue_url_group_id ON queue_message(queue_url, group_id, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_queue_message_receipt_handle ON queue_message(receipt_handle);
+CREATE INDEX IF NOT EXISTS idx_queue_message_dedupe_id ON queue_message(queue_url, dedupe_id, created_at);
@@ -0,0 +1,9 @@
+create table if not exists plan_blob
+(
+    key        varchar(200)            not null,
+    body       bytea                   not null,
+    created_at timestamp default now() not null,
+    updated_at timestamp default now() not null,
+    constraint plan_blob_pk
+        primary key (key)
+);
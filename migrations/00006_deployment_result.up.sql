@@ -0,0 +1,26 @@
+create table if not exists deployment_result
+(
+    id                serial                          not null,
+    deployment_id     uuid                            not null,
+    service_id        integer,
+    job_id            integer,
+    artifact_id       integer                         not null,
+    artifact_name     varchar(50)                     not null,
+    name              varchar(50)                     not null,
+    requested_version varchar(50),
+    previous_version  varchar(50),
+    new_version       varchar(50),
+    result            varchar(25)                     not null,
+    exit_code         integer   default 0             not null,
+    created_at        timestamp default now()         not null,
+    updated_at        timestamp default now()         not null,
+    constraint deployment_result_pk
+        primary key (id),
+    constraint deployment_result_deployment_id
+        foreign key (deployment_id) references deployment,
+    constraint deployment_result_service_or_job
+        check (service_id is not null or job_id is not null)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS deployment_result_deployment_id_service_id_uindex ON deployment_result(deployment_id, service_id) WHERE service_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS deployment_result_deployment_id_job_id_uindex ON deployment_result(deployment_id, job_id) WHERE job_id IS NOT NULL;
@@ -0,0 +1 @@
+alter type deployment_state add value if not exists 'cancelled';
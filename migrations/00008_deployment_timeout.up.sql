@@ -0,0 +1,3 @@
+alter type deployment_state add value if not exists 'timedout';
+
+alter table environment add column if not exists deployment_timeout integer;
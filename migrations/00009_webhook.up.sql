@@ -0,0 +1,61 @@
+create table if not exists webhook
+(
+    id             serial                  not null,
+    environment_id integer                 not null,
+    namespace_id   integer,
+    url            varchar(1024)           not null,
+    secret         varchar(256)            not null,
+    disabled       boolean   default false not null,
+    created_at     timestamp default now() not null,
+    updated_at     timestamp default now() not null,
+    constraint webhook_pk
+        primary key (id),
+    constraint webhook_environment_id
+        foreign key (environment_id) references environment,
+    constraint webhook_namespace_id
+        foreign key (namespace_id) references namespace
+);
+
+create type webhook_delivery_state as enum ('pending', 'delivered', 'failed');
+
+create table if not exists webhook_delivery
+(
+    id                 uuid      default uuid_generate_v4() not null,
+    webhook_id         integer,
+    deployment_id      uuid,
+    url                varchar(1024)                        not null,
+    event              varchar(50)                          not null,
+    payload            jsonb                                not null,
+    state              webhook_delivery_state               not null,
+    attempts           integer   default 0                  not null,
+    next_attempt_at    timestamp default now()              not null,
+    last_response_code integer,
+    last_error         text,
+    delivered_at       timestamp,
+    created_at         timestamp default now()              not null,
+    updated_at         timestamp default now()              not null,
+    constraint webhook_delivery_pk
+        primary key (id),
+    constraint webhook_delivery_webhook_id
+        foreign key (webhook_id) references webhook on delete set null
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_pending ON webhook_delivery(next_attempt_at) WHERE state = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_deployment_id ON webhook_delivery(deployment_id);
+
+create table if not exists webhook_delivery_attempt
+(
+    id            bigserial               not null,
+    delivery_id   uuid                    not null,
+    attempt       integer                 not null,
+    response_code integer,
+    error         text,
+    duration_ms   integer                 not null,
+    created_at    timestamp default now() not null,
+    constraint webhook_delivery_attempt_pk
+        primary key (id),
+    constraint webhook_delivery_attempt_delivery_id
+        foreign key (delivery_id) references webhook_delivery on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempt_delivery_id ON webhook_delivery_attempt(delivery_id);
@@ -0,0 +1,22 @@
+alter type deployment_state add value if not exists 'pending_approval';
+alter type deployment_state add value if not exists 'rejected';
+
+alter table environment add column if not exists required_approvals integer default 0 not null;
+alter table namespace add column if not exists required_approvals integer default 0 not null;
+alter table deployment add column if not exists required_approvals integer default 0 not null;
+
+create table if not exists deployment_approval
+(
+    id            serial                  not null,
+    deployment_id uuid                    not null,
+    "user"        varchar(50)             not null,
+    approved      boolean                 not null,
+    reason        text,
+    created_at    timestamp default now() not null,
+    constraint deployment_approval_pk
+        primary key (id),
+    constraint deployment_approval_deployment_id
+        foreign key (deployment_id) references deployment
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS deployment_approval_deployment_id_user_uindex ON deployment_approval(deployment_id, "user");
@@ -0,0 +1,24 @@
+create table if not exists freeze_window
+(
+    id             serial                  not null,
+    environment_id integer                 not null,
+    namespace_id   integer,
+    description    varchar(250)            not null,
+    schedule       varchar(100),
+    duration       integer,
+    starts_at      timestamp,
+    ends_at        timestamp,
+    disabled       boolean   default false not null,
+    created_at     timestamp default now() not null,
+    updated_at     timestamp default now() not null,
+    constraint freeze_window_pk
+        primary key (id),
+    constraint freeze_window_environment_id
+        foreign key (environment_id) references environment,
+    constraint freeze_window_namespace_id
+        foreign key (namespace_id) references namespace,
+    constraint freeze_window_recurring_or_range
+        check ((schedule is not null and duration > 0) or (starts_at is not null and ends_at is not null))
+);
+
+CREATE INDEX IF NOT EXISTS freeze_window_environment_id_index ON freeze_window(environment_id);
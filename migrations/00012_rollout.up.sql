@@ -0,0 +1,25 @@
+create type rollout_state as enum ('canary', 'verifying', 'promoted', 'aborted');
+
+create table if not exists rollout
+(
+    id                uuid      default uuid_generate_v4() not null,
+    environment_id    integer                              not null,
+    state             rollout_state                        not null,
+    plan              jsonb                                not null,
+    "user"            varchar(50)                          not null,
+    message           text,
+    verify_started_at timestamp,
+    created_at        timestamp default now()              not null,
+    updated_at        timestamp default now()              not null,
+    constraint rollout_pk
+        primary key (id),
+    constraint rollout_environment_id
+        foreign key (environment_id) references environment
+);
+
+CREATE INDEX IF NOT EXISTS idx_rollout_active ON rollout(state) WHERE state in ('canary', 'verifying');
+
+alter table deployment add column if not exists rollout_id uuid references rollout;
+alter table deployment add column if not exists rollout_stage varchar(25);
+
+CREATE INDEX IF NOT EXISTS idx_deployment_rollout_id ON deployment(rollout_id);
@@ -0,0 +1,41 @@
+create table if not exists pipeline
+(
+    id          serial                  not null,
+    name        varchar(50)             not null,
+    description varchar(250),
+    stages      jsonb                   not null,
+    created_at  timestamp default now() not null,
+    updated_at  timestamp default now() not null,
+    constraint pipeline_pk
+        primary key (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS pipeline_name_uindex ON pipeline(name);
+
+create type pipeline_run_state as enum ('running', 'awaiting_approval', 'soaking', 'completed', 'failed', 'cancelled');
+
+create table if not exists pipeline_run
+(
+    id          uuid      default uuid_generate_v4() not null,
+    pipeline_id integer                              not null,
+    state       pipeline_run_state                   not null,
+    stage       integer   default 0                  not null,
+    stages      jsonb                                not null,
+    artifacts   jsonb,
+    "user"      varchar(50)                          not null,
+    message     text,
+    soak_until  timestamp,
+    created_at  timestamp default now()              not null,
+    updated_at  timestamp default now()              not null,
+    constraint pipeline_run_pk
+        primary key (id),
+    constraint pipeline_run_pipeline_id
+        foreign key (pipeline_id) references pipeline on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_run_pipeline_id ON pipeline_run(pipeline_id);
+CREATE INDEX IF NOT EXISTS idx_pipeline_run_active ON pipeline_run(state) WHERE state in ('running', 'soaking');
+
+alter table deployment add column if not exists pipeline_run_id uuid references pipeline_run on delete set null;
+
+CREATE INDEX IF NOT EXISTS idx_deployment_pipeline_run_id ON deployment(pipeline_run_id);
@@ -0,0 +1,28 @@
+create table if not exists service_dependency
+(
+    id                    serial                  not null,
+    service_id            integer                 not null,
+    depends_on_service_id integer,
+    depends_on_job_id     integer,
+    created_at            timestamp default now() not null,
+    constraint service_dependency_pk
+        primary key (id),
+    constraint service_dependency_service_id_fk
+        foreign key (service_id) references service on delete cascade,
+    constraint service_dependency_depends_on_service_id_fk
+        foreign key (depends_on_service_id) references service on delete cascade,
+    constraint service_dependency_depends_on_job_id_fk
+        foreign key (depends_on_job_id) references job on delete cascade,
+    constraint service_dependency_one_target
+        check ((depends_on_service_id is null) <> (depends_on_job_id is null)),
+    constraint service_dependency_not_self
+        check (service_id <> depends_on_service_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_dependency_service_uindex ON service_dependency(service_id, depends_on_service_id) WHERE depends_on_service_id is not null;
+CREATE UNIQUE INDEX IF NOT EXISTS service_dependency_job_uindex ON service_dependency(service_id, depends_on_job_id) WHERE depends_on_job_id is not null;
+CREATE INDEX IF NOT EXISTS idx_service_dependency_depends_on_service_id ON service_dependency(depends_on_service_id);
+CREATE INDEX IF NOT EXISTS idx_service_dependency_depends_on_job_id ON service_dependency(depends_on_job_id);
+
+-- the plan locations of the waves that are sent to the scheduler after the current one finishes
+alter table deployment add column if not exists pending_waves jsonb;
@@ -0,0 +1,10 @@
+alter type deployment_state add value if not exists 'waiting';
+
+-- namespaces in the same plan are deployed in ascending deploy_order, the same order is deployed at the same time
+alter table namespace add column if not exists deploy_order integer default 0 not null;
+
+-- plan_id links the deployments created by one plan so a waiting deployment can be queued after the earlier orders finish
+alter table deployment add column if not exists plan_id uuid;
+alter table deployment add column if not exists deploy_order integer default 0 not null;
+
+CREATE INDEX IF NOT EXISTS idx_deployment_plan_id ON deployment(plan_id);
@@ -0,0 +1,2 @@
+-- a JSON Schema the data of the definition type's definitions is validated with, an empty schema isn't validated
+alter table definition_type add column if not exists schema jsonb default '{}'::jsonb not null;
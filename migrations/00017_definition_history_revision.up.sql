@@ -0,0 +1,20 @@
+-- revision identifies a row in the definition's history so it can be compared with another or restored
+create sequence if not exists definition_history_revision_seq;
+alter table definition_history add column if not exists revision bigint;
+
+-- the existing rows are numbered in the order they were created so paging by revision matches the history's order
+update definition_history h
+set revision = o.revision
+from (
+    select ctid, row_number() over (order by created nulls first, definition_id, ctid) as revision
+    from definition_history
+) o
+where h.ctid = o.ctid;
+
+select setval('definition_history_revision_seq', coalesce((select max(revision) from definition_history), 0) + 1, false);
+alter table definition_history alter column revision set default nextval('definition_history_revision_seq');
+alter table definition_history alter column revision set not null;
+alter sequence definition_history_revision_seq owned by definition_history.revision;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_definition_history_revision ON definition_history(revision);
+CREATE INDEX IF NOT EXISTS idx_definition_history_definition_id ON definition_history(definition_id);
@@ -0,0 +1,21 @@
+-- revision identifies a row in the metadata's history so it can be paged through and compared with another
+create sequence if not exists metadata_history_revision_seq;
+alter table metadata_history add column if not exists revision bigint;
+
+-- the existing rows are numbered in the order they were created so paging by revision matches the history's order
+update metadata_history h
+set revision = o.revision
+from (
+    select ctid, row_number() over (order by created nulls first, metadata_id, ctid) as revision
+    from metadata_history
+) o
+where h.ctid = o.ctid;
+
+select setval('metadata_history_revision_seq', coalesce((select max(revision) from metadata_history), 0) + 1, false);
+alter table metadata_history alter column revision set default nextval('metadata_history_revision_seq');
+alter table metadata_history alter column revision set not null;
+alter sequence metadata_history_revision_seq owned by metadata_history.revision;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_metadata_history_revision ON metadata_history(revision);
+CREATE INDEX IF NOT EXISTS idx_metadata_history_metadata_id ON metadata_history(metadata_id);
+CREATE INDEX IF NOT EXISTS idx_metadata_history_created ON metadata_history(created);
@@ -0,0 +1,20 @@
+create table if not exists audit
+(
+    id          bigserial               not null,
+    entity      varchar(50)             not null,
+    entity_id   varchar(250)            not null,
+    action      varchar(25)             not null,
+    "user"      varchar(250)            not null,
+    request_id  varchar(100),
+    method      varchar(10),
+    route       varchar(250),
+    before      jsonb,
+    after       jsonb,
+    created_at  timestamp default now() not null,
+    constraint audit_pk
+        primary key (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit(entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit("user");
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit(created_at);
@@ -0,0 +1,6 @@
+-- policies now carry an effect so a role can be denied a route its wildcard policy allows
+UPDATE public.policies SET v3 = 'allow' WHERE p_type = 'p' AND (v3 IS NULL OR v3 = '');
+
+-- only admins can override a freeze window
+INSERT INTO public.policies (p_type, v0, v1, v2, v3, v4, v5) VALUES ('p', 'user', '/deployment-plans/freeze-override', 'POST', 'deny', '', '');
+INSERT INTO public.policies (p_type, v0, v1, v2, v3, v4, v5) VALUES ('p', 'service', '/deployment-plans/freeze-override', 'POST', 'deny', '', '');
@@ -0,0 +1,2 @@
+-- the wave that was last sent to the scheduler, 0 when the deployment wasn't split into waves
+alter table deployment add column if not exists wave integer not null default 0;
@@ -0,0 +1,2 @@
+-- the authenticated user that requested the deployment, the "user" column is whatever the request body supplied
+alter table deployment add column if not exists requested_by varchar(250) not null default '';
@@ -0,0 +1,3 @@
+-- the authenticated user that started the run, the "user" column is whatever the request body supplied
+alter table pipeline_run add column if not exists started_by varchar(250) not null default '';
+alter table pipeline_run add column if not exists force_deploy boolean not null default false;
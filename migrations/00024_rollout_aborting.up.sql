@@ -0,0 +1,2 @@
+-- an aborted rollout stays aborting until its canary deployments are cancelled or rolled back
+alter type rollout_state add value if not exists 'aborting' after 'verifying';
@@ -14,6 +14,8 @@ import (
 	ehttp "github.com/unanet/go/pkg/http"
 	"github.com/unanet/go/pkg/json"
 	"github.com/unanet/go/pkg/log"
+
+	"github.com/unanet/eve/pkg/version"
 )
 
 const (
@@ -183,42 +185,118 @@ func (c *Client) GetArtifactProperties(ctx context.Context, repository, path str
 	}
 }
 
-// GetLatestVersionLessThan Retrieves the latest version of an Artifact that is is less than the one specified
-// TODO: pull logic out from below and document up here
+// GetLatestVersionLessThan Retrieves the latest version of an Artifact that is less than the one specified.
+// The versions are compared numerically, 1.10.0 is greater than 1.9.0
 func (c *Client) GetLatestVersionLessThan(ctx context.Context, repository string, path string, lessThanVersion string) (string, error) {
+	lessThan, err := version.Parse(lessThanVersion)
+	if err != nil {
+		return "", InvalidRequestErrorf("%s", err.Error())
+	}
+
+	versions, err := c.GetVersions(ctx, repository, path)
+	if err != nil {
+		return "", err
+	}
+
+	var latest *version.Version
+	for _, x := range versions {
+		v, err := version.Parse(x)
+		if err != nil || !v.LessThan(*lessThan) {
+			continue
+		}
+		if latest == nil || latest.LessThan(*v) {
+			latest = v
+		}
+	}
+
+	if latest == nil {
+		return "", NotFoundErrorf("no version was found less than: %s/%s:%s", repository, path, lessThanVersion)
+	}
+	return latest.String(), nil
+}
+
+// GetLatestVersionMatching Retrieves the latest version of an Artifact that satisfies the version constraint.
+// Constraints that are a simple prefix are resolved by Artifactory, anything else lists the versions and picks the highest one
+func (c *Client) GetLatestVersionMatching(ctx context.Context, repository string, path string, constraint string) (string, error) {
+	vc, err := version.ParseConstraint(constraint)
+	if err != nil {
+		return "", InvalidRequestErrorf("%s", err.Error())
+	}
+
+	if pattern, ok := vc.Pattern(); ok {
+		latest, err := c.GetLatestVersion(ctx, repository, path, pattern)
+		if err != nil {
+			// an exact version with fewer than four parts (1.2.3) doesn't match its own pattern (1.2.3.*)
+			if _, ok := err.(NotFoundError); !ok {
+				return "", err
+			}
+		} else if version.Satisfies(latest, constraint) {
+			// Artifactory sorts by the upload date, so make sure it's actually what was asked for before trusting it
+			return latest, nil
+		}
+	}
+
+	versions, err := c.GetVersions(ctx, repository, path)
+	if err != nil {
+		return "", err
+	}
+
+	latest, ok := version.Latest(versions, vc)
+	if !ok {
+		return "", NotFoundErrorf("no version was found matching: %s/%s:%s", repository, path, constraint)
+	}
+	return latest, nil
+}
+
+// maxVersions is how many of the most recently uploaded versions GetVersions considers
+const maxVersions = 1000
+
+// GetVersions Retrieves the most recent versions of an Artifact from the version property
+func (c *Client) GetVersions(ctx context.Context, repository string, path string) ([]string, error) {
 	var success AQLResult
 	var failure string
-	var sort string
-	// TODO: We should really move this logic out of here, the artifactory client shouldn't be responsible for logic like this.
 	// This occurs because the path for docker includes the version due to how docker repositories work in artifactory
-	// The path only includes the folder structure for a normal repository
-	// Also, since it's not one file with docker but instead a tag (the version) represents a folder, there's actually many files, none of which have the version on them
-	// So instead we need to sort by path descending which has the version on it.
+	// The path only includes the folder structure for a normal repository. Only the manifest is searched for docker
+	// so each tag is a single item instead of one for every layer
+	nameQuery := ""
 	if strings.Contains(repository, "docker") {
 		path = fmt.Sprintf("%s/*", path)
-		sort = "{\"$desc\": [\"path\"]}"
-	} else {
-		sort = "{\"$desc\": [\"name\"]}"
+		nameQuery = ",{\"name\":{\"$eq\":\"manifest.json\"}}"
 	}
-	aqlQuery := fmt.Sprintf("{\"$and\":[{\"repo\":{\"$eq\":\"%s\"}},{\"@version\":{\"$lt\":\"%s\"}},{\"path\":{\"$match\":\"%s\"}}]}", repository, lessThanVersion, path)
-	body := strings.NewReader(fmt.Sprintf("items.find(%s).include(\"name\",\"@version\", \"path\").sort(%s).limit(1)", aqlQuery, sort))
+	aqlQuery := fmt.Sprintf("{\"$and\":[{\"repo\":{\"$eq\":\"%s\"}},{\"path\":{\"$match\":\"%s\"}}%s,{\"@version\":{\"$match\":\"*\"}}]}", repository, path, nameQuery)
+	body := strings.NewReader(fmt.Sprintf("items.find(%s).include(\"name\",\"@version\", \"path\", \"created\").sort({\"$desc\":[\"created\"]}).limit(%d)", aqlQuery, maxVersions))
 
 	r, err := c.sling.New().Post("search/aql").Body(body).Request()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
 	resp, err := c.sling.Do(r.WithContext(ctx), &success, &failure)
 	if err != nil {
-		return "", err
+		return nil, errors.Wrap(err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.Wrap(err)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusServiceUnavailable:
+		return nil, ServiceUnavailableErrorf("Artifactory returned a 503 and appears to be unavailable")
+	default:
+		return nil, errors.Wrap(fmt.Errorf("an error occurred while trying to retrieve the artifact versions: %s", failure))
 	}
 
-	if len(success.Results) == 0 {
-		return "", NotFoundErrorf("no version was found less than: %s/%s:%s", repository, path, lessThanVersion)
+	seen := make(map[string]bool)
+	var versions []string
+	for _, x := range success.Results {
+		for _, p := range x.Properties {
+			if p.Key != "version" || seen[p.Value] {
+				continue
+			}
+			seen[p.Value] = true
+			versions = append(versions, p.Value)
+		}
 	}
 
-	if len(success.Results[0].Properties) == 0 {
-		return "", errors.Wrap(fmt.Errorf("there is no version property for the following path: %s", success.Results[0].Path))
+	if len(versions) == 0 {
+		return nil, NotFoundErrorf("no versions were found for: %s/%s", repository, path)
 	}
-	return success.Results[0].Properties[0].Value, nil
+	return versions, nil
 }
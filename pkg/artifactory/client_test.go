@@ -0,0 +1,142 @@
+package artifactory_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/artifactory"
+)
+
+// artifactoryServer answers the latest version request with the status and version, and the AQL search with the versions
+func artifactoryServer(t *testing.T, status int, latest string, versions ...string) (*httptest.Server, *int) {
+	searches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/versions/"):
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				_, _ = fmt.Fprintf(w, `{"version":%q}`, latest)
+			} else {
+				_, _ = fmt.Fprint(w, `{"errors":[]}`)
+			}
+		case r.URL.Path == "/search/aql":
+			searches++
+			var results []string
+			for _, x := range versions {
+				results = append(results, fmt.Sprintf(`{"path":"api/%[1]s","name":"api-%[1]s.tgz","properties":[{"key":"version","value":%[1]q}]}`, x))
+			}
+			_, _ = fmt.Fprintf(w, `{"results":[%s]}`, strings.Join(results, ","))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &searches
+}
+
+func TestClient_GetLatestVersionMatching(t *testing.T) {
+	tests := []struct {
+		name         string
+		constraint   string
+		status       int
+		latest       string
+		versions     []string
+		want         string
+		wantSearches int
+		wantErr      bool
+	}{
+		{
+			name:         "exact version doesn't match its own pattern",
+			constraint:   "1.2.3",
+			status:       http.StatusNotFound,
+			versions:     []string{"1.2.2", "1.2.3", "1.3.0"},
+			want:         "1.2.3",
+			wantSearches: 1,
+		},
+		{
+			name:       "prefix resolved by artifactory",
+			constraint: "1.2",
+			status:     http.StatusOK,
+			latest:     "1.2.9",
+			want:       "1.2.9",
+		},
+		{
+			name:         "latest upload doesn't satisfy the constraint",
+			constraint:   "1.2",
+			status:       http.StatusOK,
+			latest:       "1.20.0",
+			versions:     []string{"1.2.7", "1.2.8", "1.20.0"},
+			want:         "1.2.8",
+			wantSearches: 1,
+		},
+		{
+			name:       "artifactory unavailable",
+			constraint: "1.2.3",
+			status:     http.StatusServiceUnavailable,
+			wantErr:    true,
+		},
+		{
+			name:         "no version matches",
+			constraint:   "1.2.3",
+			status:       http.StatusNotFound,
+			versions:     []string{"1.2.2"},
+			wantSearches: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, searches := artifactoryServer(t, tt.status, tt.latest, tt.versions...)
+			client := artifactory.NewClient(artifactory.Config{ArtifactoryBaseUrl: server.URL, ArtifactoryApiKey: "key"})
+
+			got, err := client.GetLatestVersionMatching(context.Background(), "generic-local", "api", tt.constraint)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.Equal(t, tt.wantSearches, *searches)
+		})
+	}
+}
+
+func TestClient_GetVersionsQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		repository   string
+		wantManifest bool
+	}{
+		{name: "generic", repository: "generic-local"},
+		{name: "docker", repository: "docker-int", wantManifest: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				query = string(body)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"results":[{"path":"api/1.0.0","name":"manifest.json","properties":[{"key":"version","value":"1.0.0"}]}]}`)
+			}))
+			defer server.Close()
+			client := artifactory.NewClient(artifactory.Config{ArtifactoryBaseUrl: server.URL, ArtifactoryApiKey: "key"})
+
+			versions, err := client.GetVersions(context.Background(), tt.repository, "api")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"1.0.0"}, versions)
+			assert.Contains(t, query, ".limit(")
+			assert.Equal(t, tt.wantManifest, strings.Contains(query, "manifest.json"))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package eve
+
+import (
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditEntry records a change made through the api, Before is empty when it was created and After when it was deleted
+type AuditEntry struct {
+	ID        int                    `json:"id"`
+	Entity    string                 `json:"entity"`
+	EntityID  string                 `json:"entity_id"`
+	Action    AuditAction            `json:"action"`
+	User      string                 `json:"user"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Route     string                 `json:"route,omitempty"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditQuery holds the filters used to search the audit trail
+type AuditQuery struct {
+	Entity    string
+	EntityID  string
+	User      string
+	Action    string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Cursor    string
+	Limit     int
+}
+
+// AuditPage is a page of the audit trail, NextCursor is empty when there are no more results
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,66 @@
+package eve
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type DefinitionHistory struct {
+	Revision     int                    `json:"revision"`
+	DefinitionID int                    `json:"definition_id"`
+	Description  string                 `json:"description"`
+	Data         map[string]interface{} `json:"data"`
+	Created      time.Time              `json:"created"`
+	CreatedBy    string                 `json:"created_by"`
+	Deleted      *time.Time             `json:"deleted"`
+	DeletedBy    *string                `json:"deleted_by"`
+}
+
+// DefinitionHistoryQuery pages through a definition's history
+type DefinitionHistoryQuery struct {
+	DefinitionID int
+	Cursor       string
+	Limit        int
+}
+
+// DefinitionHistoryPage is a page of a definition's history, NextCursor is empty when there are no more results. The
+// api responds with the History and returns the NextCursor in the X-Next-Cursor header
+type DefinitionHistoryPage struct {
+	History    []DefinitionHistory
+	NextCursor string
+}
+
+// DefinitionDiff is what changed in a definition's data between two revisions, without a to revision it's compared
+// with the current data
+type DefinitionDiff struct {
+	DefinitionID int           `json:"definition_id"`
+	FromRevision int           `json:"from_revision"`
+	ToRevision   int           `json:"to_revision,omitempty"`
+	Changes      []ValueChange `json:"changes"`
+}
+
+// NewDefinitionDiff compares the data of the from revision with the to data
+func NewDefinitionDiff(from DefinitionHistory, toRevision int, to map[string]interface{}) DefinitionDiff {
+	changes := DiffValues(from.Data, to)
+	if changes == nil {
+		changes = []ValueChange{}
+	}
+	return DefinitionDiff{
+		DefinitionID: from.DefinitionID,
+		FromRevision: from.Revision,
+		ToRevision:   toRevision,
+		Changes:      changes,
+	}
+}
+
+// DefinitionRestore is the revision a definition's data is rolled back to
+type DefinitionRestore struct {
+	Revision int `json:"revision"`
+}
+
+func (d DefinitionRestore) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &d,
+		validation.Field(&d.Revision, validation.Required))
+}
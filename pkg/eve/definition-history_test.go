@@ -0,0 +1,41 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestNewDefinitionDiff(t *testing.T) {
+	from := eve.DefinitionHistory{
+		Revision:     3,
+		DefinitionID: 7,
+		Data: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": float64(2), "paused": true},
+		},
+	}
+
+	diff := eve.NewDefinitionDiff(from, 5, map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": float64(4)},
+	})
+	assert.Equal(t, eve.DefinitionDiff{
+		DefinitionID: 7,
+		FromRevision: 3,
+		ToRevision:   5,
+		Changes: []eve.ValueChange{
+			{Path: "spec.paused", Type: eve.ValueChangeRemoved, From: true},
+			{Path: "spec.replicas", Type: eve.ValueChangeChanged, From: float64(2), To: float64(4)},
+		},
+	}, diff)
+
+	// the same data is reported as an empty list of changes rather than null
+	assert.Equal(t, []eve.ValueChange{}, eve.NewDefinitionDiff(from, 0, from.Data).Changes)
+}
+
+func TestDefinitionRestore_ValidateWithContext(t *testing.T) {
+	assert.Error(t, eve.DefinitionRestore{}.ValidateWithContext(context.Background()))
+	assert.NoError(t, eve.DefinitionRestore{Revision: 12}.ValidateWithContext(context.Background()))
+}
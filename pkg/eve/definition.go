@@ -331,13 +331,30 @@ func (m DefinitionJobMap) ValidateWithContext(ctx context.Context) error {
 }
 
 type DefinitionType struct {
-	ID              int       `json:"id"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
-	Class           string    `json:"class"`
-	Version         string    `json:"version"`
-	Kind            string    `json:"kind"`
-	DefinitionOrder string    `json:"definition_order"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int              `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	Class           string           `json:"class"`
+	Version         string           `json:"version"`
+	Kind            string           `json:"kind"`
+	DefinitionOrder string           `json:"definition_order"`
+	Schema          DefinitionSchema `json:"schema,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+func (dt DefinitionType) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &dt,
+		validation.Field(&dt.Schema))
+}
+
+// ResultKey is the key of the merged definition results the definition type's schema applies to
+func (dt DefinitionType) ResultKey() string {
+	result := DefinitionResult{
+		Order:   dt.DefinitionOrder,
+		Class:   dt.Class,
+		Version: dt.Version,
+		Kind:    dt.Kind,
+	}
+	return result.Key()
 }
@@ -0,0 +1,202 @@
+package eve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"github.com/unanet/eve/internal/data"
+)
+
+// ServiceDependency holds a service back until the service or job it depends on (in the same namespace) has been
+// deployed, a job dependency is run before the service when the plan deploys a new version of the job's artifact
+type ServiceDependency struct {
+	ID                   int       `json:"id"`
+	ServiceID            int       `json:"service_id"`
+	ServiceName          string    `json:"service_name,omitempty"`
+	DependsOnServiceID   int       `json:"depends_on_service_id,omitempty"`
+	DependsOnServiceName string    `json:"depends_on_service_name,omitempty"`
+	DependsOnJobID       int       `json:"depends_on_job_id,omitempty"`
+	DependsOnJobName     string    `json:"depends_on_job_name,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func (sd ServiceDependency) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &sd,
+		validation.Field(&sd.DependsOnServiceID, validation.When(sd.DependsOnJobID == 0, validation.Required.Error("depends_on_service_id or depends_on_job_id is required")).Else(validation.Empty.Error("only one of depends_on_service_id and depends_on_job_id can be set"))),
+		validation.Field(&sd.DependsOnJobID, validation.Min(0)))
+}
+
+func ToServiceDependency(d data.ServiceDependency) ServiceDependency {
+	return ServiceDependency{
+		ID:                   d.ID,
+		ServiceID:            d.ServiceID,
+		ServiceName:          d.ServiceName,
+		DependsOnServiceID:   int(d.DependsOnServiceID.Int32),
+		DependsOnServiceName: d.DependsOnServiceName.String,
+		DependsOnJobID:       int(d.DependsOnJobID.Int32),
+		DependsOnJobName:     d.DependsOnJobName.String,
+		CreatedAt:            d.CreatedAt.Time,
+	}
+}
+
+func ToServiceDependencies(dependencies data.ServiceDependencies) []ServiceDependency {
+	var list []ServiceDependency
+	for _, x := range dependencies {
+		list = append(list, ToServiceDependency(x))
+	}
+	return list
+}
+
+func ServiceNode(name string) string {
+	return "service:" + name
+}
+
+func JobNode(name string) string {
+	return "job:" + name
+}
+
+// DependencyGraphFromData builds the graph of a namespace's service dependencies
+func DependencyGraphFromData(dependencies data.ServiceDependencies) DependencyGraph {
+	g := make(DependencyGraph)
+	for _, x := range dependencies {
+		if x.DependsOnJobID.Valid {
+			g.Add(ServiceNode(x.ServiceName), JobNode(x.DependsOnJobName.String))
+		} else {
+			g.Add(ServiceNode(x.ServiceName), ServiceNode(x.DependsOnServiceName.String))
+		}
+	}
+	return g
+}
+
+// DependencyCycleError is returned when the dependencies can't be ordered, Cycle starts and ends with the same node
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (e DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// DependencyGraph maps each node to the nodes it depends on
+type DependencyGraph map[string][]string
+
+func (g DependencyGraph) Add(node string, dependsOn string) {
+	for _, x := range g[node] {
+		if x == dependsOn {
+			return
+		}
+	}
+	g[node] = append(g[node], dependsOn)
+}
+
+// Cycle returns a dependency cycle between the included nodes (all of them when include is nil), it's nil when there
+// isn't one
+func (g DependencyGraph) Cycle(include func(node string) bool) []string {
+	if include == nil {
+		include = func(string) bool { return true }
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		path = append(path, node)
+		for _, x := range g[node] {
+			if !include(x) {
+				continue
+			}
+			switch state[x] {
+			case visiting:
+				for i, y := range path {
+					if y == x {
+						return append(append([]string{}, path[i:]...), x)
+					}
+				}
+			case unvisited:
+				if cycle := visit(x); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	// sorted so the same graph always reports the same cycle
+	var nodes []string
+	for k := range g {
+		if include(k) {
+			nodes = append(nodes, k)
+		}
+	}
+	sort.Strings(nodes)
+
+	for _, x := range nodes {
+		if state[x] == unvisited {
+			if cycle := visit(x); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Waves groups the nodes so that each wave only depends on the waves before it, dependencies on nodes that aren't in
+// the list are ignored (they aren't being deployed). The nodes in a wave keep the order they were supplied in
+func (g DependencyGraph) Waves(nodes []string) ([][]string, error) {
+	remaining := make(map[string]bool)
+	for _, x := range nodes {
+		remaining[x] = true
+	}
+	included := func(node string) bool {
+		_, ok := remaining[node]
+		return ok
+	}
+
+	if cycle := g.Cycle(included); cycle != nil {
+		return nil, DependencyCycleError{Cycle: cycle}
+	}
+
+	var waves [][]string
+	for len(waves) < len(nodes) {
+		var wave []string
+		for _, x := range nodes {
+			if !remaining[x] {
+				continue
+			}
+			ready := true
+			for _, y := range g[x] {
+				if remaining[y] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, x)
+			}
+		}
+
+		if len(wave) == 0 {
+			break
+		}
+		for _, x := range wave {
+			remaining[x] = false
+		}
+		waves = append(waves, wave)
+	}
+
+	return waves, nil
+}
@@ -0,0 +1,57 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestDependencyGraph_Waves(t *testing.T) {
+	g := make(eve.DependencyGraph)
+	g.Add("service:api", "job:migrate")
+	g.Add("service:worker", "service:api")
+	g.Add("service:scheduler", "service:api")
+	g.Add("service:worker", "service:cache")
+
+	waves, err := g.Waves([]string{"service:worker", "service:scheduler", "service:api", "job:migrate", "service:web"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"job:migrate", "service:web"},
+		{"service:api"},
+		{"service:worker", "service:scheduler"},
+	}, waves)
+
+	// dependencies that aren't being deployed don't hold anything back
+	waves, err = g.Waves([]string{"service:worker", "service:scheduler"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"service:worker", "service:scheduler"}}, waves)
+}
+
+func TestDependencyGraph_Cycle(t *testing.T) {
+	g := make(eve.DependencyGraph)
+	g.Add("service:api", "service:worker")
+	g.Add("service:worker", "service:cache")
+	assert.Nil(t, g.Cycle(nil))
+
+	g.Add("service:cache", "service:api")
+	assert.Equal(t, []string{"service:api", "service:worker", "service:cache", "service:api"}, g.Cycle(nil))
+
+	_, err := g.Waves([]string{"service:api", "service:worker", "service:cache"})
+	assert.EqualError(t, err, "dependency cycle: service:api -> service:worker -> service:cache -> service:api")
+
+	// the cycle only matters when all of it is being deployed
+	waves, err := g.Waves([]string{"service:api", "service:worker"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"service:worker"}, {"service:api"}}, waves)
+}
+
+func TestServiceDependency_Validate(t *testing.T) {
+	assert.NoError(t, eve.ServiceDependency{ServiceID: 1, DependsOnServiceID: 2}.ValidateWithContext(context.TODO()))
+	assert.NoError(t, eve.ServiceDependency{ServiceID: 1, DependsOnJobID: 2}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.ServiceDependency{ServiceID: 1}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.ServiceDependency{ServiceID: 1, DependsOnServiceID: 2, DependsOnJobID: 3}.ValidateWithContext(context.TODO()))
+}
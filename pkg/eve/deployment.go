@@ -2,6 +2,7 @@ package eve
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -34,7 +35,13 @@ const (
 	DeploymentStateQueued    DeploymentState = "queued"
 	DeploymentStateScheduled DeploymentState = "scheduled"
 	DeploymentStateCompleted DeploymentState = "completed"
+	DeploymentStateCancelled DeploymentState = "cancelled"
+	DeploymentStateTimedOut  DeploymentState = "timedout"
 	DeploymentStateUnknown   DeploymentState = "unknown"
+
+	DeploymentStatePendingApproval DeploymentState = "pending_approval"
+	DeploymentStateRejected        DeploymentState = "rejected"
+	DeploymentStateWaiting         DeploymentState = "waiting"
 )
 
 func ParseDeploymentState(value data.DeploymentState) DeploymentState {
@@ -45,6 +52,16 @@ func ParseDeploymentState(value data.DeploymentState) DeploymentState {
 		return DeploymentStateScheduled
 	case data.DeploymentStateCompleted:
 		return DeploymentStateCompleted
+	case data.DeploymentStateCancelled:
+		return DeploymentStateCancelled
+	case data.DeploymentStateTimedOut:
+		return DeploymentStateTimedOut
+	case data.DeploymentStatePendingApproval:
+		return DeploymentStatePendingApproval
+	case data.DeploymentStateRejected:
+		return DeploymentStateRejected
+	case data.DeploymentStateWaiting:
+		return DeploymentStateWaiting
 	default:
 		return DeploymentStateUnknown
 	}
@@ -54,6 +71,9 @@ const (
 	DeploymentPlanTypeApplication PlanType = "application"
 	DeploymentPlanTypeJob         PlanType = "job"
 	DeploymentPlanTypeRestart     PlanType = "restart"
+	// DeploymentPlanTypeCanary is an application plan that's deployed to the canary namespaces (or replica count) first,
+	// the rest of the plan is queued once the canary is verified
+	DeploymentPlanTypeCanary PlanType = "canary"
 )
 
 type DeployArtifactResult string
@@ -84,11 +104,13 @@ func ParseDeployArtifactResult(value string) DeployArtifactResult {
 type DeploymentPlanStatus string
 
 const (
-	DeploymentPlanStatusPending  DeploymentPlanStatus = "pending"
-	DeploymentPlanStatusDryrun   DeploymentPlanStatus = "dryrun"
-	DeploymentPlanStatusErrors   DeploymentPlanStatus = "errors"
-	DeploymentPlanStatusComplete DeploymentPlanStatus = "complete"
-	DeploymentPlanStatusMessage  DeploymentPlanStatus = "message"
+	DeploymentPlanStatusPending   DeploymentPlanStatus = "pending"
+	DeploymentPlanStatusDryrun    DeploymentPlanStatus = "dryrun"
+	DeploymentPlanStatusErrors    DeploymentPlanStatus = "errors"
+	DeploymentPlanStatusComplete  DeploymentPlanStatus = "complete"
+	DeploymentPlanStatusMessage   DeploymentPlanStatus = "message"
+	DeploymentPlanStatusCancelled DeploymentPlanStatus = "cancelled"
+	DeploymentPlanStatusRejected  DeploymentPlanStatus = "rejected"
 )
 
 func (dps DeploymentPlanStatus) String() string {
@@ -318,6 +340,10 @@ type NamespaceRequest struct {
 	ClusterID   int    `json:"cluster_id"`
 	ClusterName string `json:"cluster_name"`
 	Version     string `json:"version"`
+	// RequiredApprovals is the number of approvals a deployment to the namespace needs, it's the greater of the environment's and namespace's
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+	// DeployOrder is the namespace's place in the plan, lower orders are deployed first
+	DeployOrder int `json:"deploy_order,omitempty"`
 }
 
 func (ns *NamespaceRequest) GetQueueGroupID() string {
@@ -338,6 +364,20 @@ func (n NamespaceRequests) ToIDs() []int {
 	return ids
 }
 
+// DeployOrders returns the distinct deploy orders of the namespaces, lowest first
+func (n NamespaceRequests) DeployOrders() []int {
+	seen := make(map[int]bool)
+	var orders []int
+	for _, x := range n {
+		if !seen[x.DeployOrder] {
+			seen[x.DeployOrder] = true
+			orders = append(orders, x.DeployOrder)
+		}
+	}
+	sort.Ints(orders)
+	return orders
+}
+
 type NSDeploymentPlan struct {
 	DeploymentID      uuid.UUID            `json:"deployment_id"`
 	Namespace         *NamespaceRequest    `json:"namespace"`
@@ -351,6 +391,27 @@ type NSDeploymentPlan struct {
 	Status            DeploymentPlanStatus `json:"status"`
 	MetadataOverrides MetadataField        `json:"metadata_overrides"`
 	Type              PlanType             `json:"type"`
+	// Wave is set when the services were split into dependency ordered waves, it starts at 1
+	Wave int `json:"wave,omitempty"`
+	// PendingWaves are sent to the scheduler one at a time after this plan finishes
+	PendingWaves []DeploymentWave `json:"-"`
+}
+
+// DeploymentWave is the part of a namespace plan that can be deployed once the waves before it have finished
+type DeploymentWave struct {
+	Services DeployServices `json:"services,omitempty"`
+	Jobs     DeployJobs     `json:"jobs,omitempty"`
+}
+
+// WavePlan is a copy of the plan that only deploys the wave
+func (ns *NSDeploymentPlan) WavePlan(wave int, w DeploymentWave) *NSDeploymentPlan {
+	plan := *ns
+	plan.Services = w.Services
+	plan.Jobs = w.Jobs
+	plan.Messages = nil
+	plan.Wave = wave
+	plan.PendingWaves = nil
+	return &plan
 }
 
 // DeploymentPlanType is a helper method to know what type of deployment plan (application,job,migration,restart)
@@ -403,24 +464,257 @@ func (ns *NSDeploymentPlan) Message(format string, a ...interface{}) {
 
 func ToDeployment(d data.Deployment) Deployment {
 	return Deployment{
-		ID:            d.ID,
+		ID:                d.ID,
+		EnvironmentID:     d.EnvironmentID,
+		NamespaceID:       d.NamespaceID,
+		ReqID:             d.ReqID,
+		PlanOptions:       d.PlanOptions.AsMapOrEmpty(),
+		User:              d.User,
+		State:             ParseDeploymentState(d.State),
+		RequiredApprovals: d.RequiredApprovals,
+		RolloutID:         nullUUID(d.RolloutID),
+		RolloutStage:      d.RolloutStage.String,
+		PipelineRunID:     nullUUID(d.PipelineRunID),
+		PlanID:            nullUUID(d.PlanID),
+		DeployOrder:       d.DeployOrder,
+		CreatedAt:         d.CreatedAt.Time,
+		UpdatedAt:         d.UpdatedAt.Time,
+	}
+}
+
+func nullUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func ToDeploymentApprovals(approvals data.DeploymentApprovals) []DeploymentApproval {
+	var list []DeploymentApproval
+	for _, x := range approvals {
+		list = append(list, DeploymentApproval{
+			User:      x.User,
+			Approved:  x.Approved,
+			Reason:    x.Reason.String,
+			CreatedAt: x.CreatedAt.Time,
+		})
+	}
+	return list
+}
+
+func ToDeploymentHistory(d data.DeploymentHistory) Deployment {
+	deployment := ToDeployment(d.Deployment)
+	deployment.EnvironmentName = d.EnvironmentName.String
+	deployment.NamespaceName = d.NamespaceName.String
+	return deployment
+}
+
+type Deployment struct {
+	ID              uuid.UUID              `json:"id"`
+	EnvironmentID   int                    `json:"environment_id"`
+	EnvironmentName string                 `json:"environment_name,omitempty"`
+	NamespaceID     int                    `json:"namespace_id"`
+	NamespaceName   string                 `json:"namespace_name,omitempty"`
+	ReqID           string                 `json:"req_id"`
+	PlanOptions     map[string]interface{} `json:"plan_options"`
+	User            string                 `json:"user"`
+	State           DeploymentState        `json:"state"`
+	// RequiredApprovals is the number of approvals the deployment needed before it was queued
+	RequiredApprovals int                   `json:"required_approvals,omitempty"`
+	Approvals         []DeploymentApproval  `json:"approvals,omitempty"`
+	Result            *DeploymentPlanResult `json:"result,omitempty"`
+	ResultError       string                `json:"result_error,omitempty"`
+	Results           []DeploymentResult    `json:"results,omitempty"`
+	RolloutID         *uuid.UUID            `json:"rollout_id,omitempty"`
+	RolloutStage      string                `json:"rollout_stage,omitempty"`
+	PipelineRunID     *uuid.UUID            `json:"pipeline_run_id,omitempty"`
+	// PlanID is set when the plan's namespaces are deployed in order, the deployment waits until the plan's lower deploy
+	// orders have completed
+	PlanID      *uuid.UUID `json:"plan_id,omitempty"`
+	DeployOrder int        `json:"deploy_order,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type DeploymentApproval struct {
+	User      string    `json:"user"`
+	Approved  bool      `json:"approved"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeploymentPlanResult is the outcome of a deployment plan without the definitions and metadata that were sent to the scheduler
+type DeploymentPlanResult struct {
+	Status   DeploymentPlanStatus       `json:"status"`
+	Messages []string                   `json:"messages,omitempty"`
+	Services []DeploymentArtifactResult `json:"services,omitempty"`
+	Jobs     []DeploymentArtifactResult `json:"jobs,omitempty"`
+}
+
+type DeploymentArtifactResult struct {
+	ID               int                  `json:"id"`
+	Name             string               `json:"name"`
+	ArtifactName     string               `json:"artifact_name"`
+	RequestedVersion string               `json:"requested_version"`
+	DeployedVersion  string               `json:"deployed_version"`
+	AvailableVersion string               `json:"available_version"`
+	Result           DeployArtifactResult `json:"result"`
+	ExitCode         int                  `json:"exit_code"`
+}
+
+func toDeploymentArtifactResult(id int, name string, a *DeployArtifact) DeploymentArtifactResult {
+	return DeploymentArtifactResult{
+		ID:               id,
+		Name:             name,
+		ArtifactName:     a.ArtifactName,
+		RequestedVersion: a.RequestedVersion,
+		DeployedVersion:  a.DeployedVersion,
+		AvailableVersion: a.AvailableVersion,
+		Result:           a.Result,
+		ExitCode:         a.ExitCode,
+	}
+}
+
+// Result returns the per service/job outcome of the plan
+func (ns *NSDeploymentPlan) Result() *DeploymentPlanResult {
+	result := DeploymentPlanResult{
+		Status:   ns.Status,
+		Messages: ns.Messages,
+	}
+
+	for _, x := range ns.Services {
+		result.Services = append(result.Services, toDeploymentArtifactResult(x.ServiceID, x.ServiceName, x.DeployArtifact))
+	}
+
+	for _, x := range ns.Jobs {
+		result.Jobs = append(result.Jobs, toDeploymentArtifactResult(x.JobID, x.JobName, x.DeployArtifact))
+	}
+
+	return &result
+}
+
+// DeploymentResult is the recorded outcome of a service or job in a deployment, it outlives the plan in storage
+type DeploymentResult struct {
+	ID               int                  `json:"id"`
+	DeploymentID     uuid.UUID            `json:"deployment_id"`
+	ServiceID        *int                 `json:"service_id,omitempty"`
+	JobID            *int                 `json:"job_id,omitempty"`
+	ArtifactID       int                  `json:"artifact_id"`
+	ArtifactName     string               `json:"artifact_name"`
+	Name             string               `json:"name"`
+	RequestedVersion string               `json:"requested_version"`
+	PreviousVersion  string               `json:"previous_version"`
+	NewVersion       string               `json:"new_version"`
+	Result           DeployArtifactResult `json:"result"`
+	ExitCode         int                  `json:"exit_code"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+type DeploymentEventType string
+
+const (
+	DeploymentEventQueued    DeploymentEventType = "queued"
+	DeploymentEventScheduled DeploymentEventType = "scheduled"
+	DeploymentEventMessage   DeploymentEventType = "message"
+	DeploymentEventCompleted DeploymentEventType = "completed"
+	DeploymentEventCancelled DeploymentEventType = "cancelled"
+	DeploymentEventTimedOut  DeploymentEventType = "timedout"
+
+	DeploymentEventPendingApproval DeploymentEventType = "pending_approval"
+	DeploymentEventApproved        DeploymentEventType = "approved"
+	DeploymentEventRejected        DeploymentEventType = "rejected"
+	DeploymentEventWaiting         DeploymentEventType = "waiting"
+)
+
+// DeploymentEventForState is the event type of the transition into the state, it's used to send a deployment's current
+// state to a client that subscribes after the transition was published. An unknown state is a message
+func DeploymentEventForState(state DeploymentState) DeploymentEventType {
+	switch state {
+	case DeploymentStateQueued:
+		return DeploymentEventQueued
+	case DeploymentStateScheduled:
+		return DeploymentEventScheduled
+	case DeploymentStateCompleted:
+		return DeploymentEventCompleted
+	case DeploymentStateCancelled:
+		return DeploymentEventCancelled
+	case DeploymentStateTimedOut:
+		return DeploymentEventTimedOut
+	case DeploymentStatePendingApproval:
+		return DeploymentEventPendingApproval
+	case DeploymentStateRejected:
+		return DeploymentEventRejected
+	case DeploymentStateWaiting:
+		return DeploymentEventWaiting
+	default:
+		return DeploymentEventMessage
+	}
+}
+
+// DeploymentEvent is a deployment state transition or a message from the scheduler
+type DeploymentEvent struct {
+	ID            int64               `json:"id"`
+	Type          DeploymentEventType `json:"type"`
+	DeploymentID  uuid.UUID           `json:"deployment_id"`
+	EnvironmentID int                 `json:"environment_id"`
+	NamespaceID   int                 `json:"namespace_id"`
+	State         DeploymentState     `json:"state"`
+	Messages      []string            `json:"messages,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// Finished is true when no more events will be published for the deployment
+func (e DeploymentEvent) Finished() bool {
+	switch e.Type {
+	case DeploymentEventCompleted, DeploymentEventCancelled, DeploymentEventTimedOut, DeploymentEventRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewDeploymentEvent(t DeploymentEventType, d data.Deployment, messages ...string) DeploymentEvent {
+	return DeploymentEvent{
+		Type:          t,
+		DeploymentID:  d.ID,
 		EnvironmentID: d.EnvironmentID,
 		NamespaceID:   d.NamespaceID,
-		User:          d.User,
 		State:         ParseDeploymentState(d.State),
-		CreatedAt:     d.CreatedAt.Time,
-		UpdatedAt:     d.UpdatedAt.Time,
+		Messages:      messages,
 	}
 }
 
-type Deployment struct {
-	ID            uuid.UUID       `json:"id"`
-	EnvironmentID int             `json:"environment_id"`
-	NamespaceID   int             `json:"namespace_id"`
-	User          string          `json:"user"`
-	State         DeploymentState `json:"state"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+type DeploymentSort string
+
+const (
+	DeploymentSortAsc  DeploymentSort = "asc"
+	DeploymentSortDesc DeploymentSort = "desc"
+)
+
+// DeploymentQuery holds the filters used to search the deployment history
+type DeploymentQuery struct {
+	Environment string
+	Namespace   string
+	User        string
+	State       string
+	CronID      string
+	RolloutID   string
+	// PipelineRunID returns the deployments queued by a pipeline run's stages
+	PipelineRunID string
+	From          *time.Time
+	To            *time.Time
+	Cursor        string
+	Limit         int
+	Sort          DeploymentSort
+}
+
+// DeploymentPage is a page of deployments, NextCursor is empty when there are no more results. The deployments have the
+// results recorded for each service and job, the ones that don't have any recorded results (ex: they finished before
+// the results were recorded) have the plan's Result instead
+type DeploymentPage struct {
+	Deployments []Deployment `json:"deployments"`
+	NextCursor  string       `json:"next_cursor,omitempty"`
 }
 
 type DeploymentCronJob struct {
@@ -0,0 +1,46 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestNamespaceRequests_DeployOrders(t *testing.T) {
+	assert.Nil(t, eve.NamespaceRequests{}.DeployOrders())
+	assert.Equal(t, []int{0}, eve.NamespaceRequests{{Alias: "app"}, {Alias: "api"}}.DeployOrders())
+
+	namespaces := eve.NamespaceRequests{
+		{Alias: "app", DeployOrder: 2},
+		{Alias: "data", DeployOrder: 1},
+		{Alias: "api", DeployOrder: 2},
+		{Alias: "infra"},
+	}
+	assert.Equal(t, []int{0, 1, 2}, namespaces.DeployOrders())
+}
+
+func TestNamespace_ValidateDeployOrder(t *testing.T) {
+	ctx := context.Background()
+
+	assert.NoError(t, eve.Namespace{DeployOrder: 1}.ValidateWithContext(ctx))
+	assert.Error(t, eve.Namespace{DeployOrder: -1}.ValidateWithContext(ctx))
+}
+
+func TestDeploymentEventForState(t *testing.T) {
+	assert.Equal(t, eve.DeploymentEventQueued, eve.DeploymentEventForState(eve.DeploymentStateQueued))
+	assert.Equal(t, eve.DeploymentEventScheduled, eve.DeploymentEventForState(eve.DeploymentStateScheduled))
+	assert.Equal(t, eve.DeploymentEventCompleted, eve.DeploymentEventForState(eve.DeploymentStateCompleted))
+	assert.Equal(t, eve.DeploymentEventCancelled, eve.DeploymentEventForState(eve.DeploymentStateCancelled))
+	assert.Equal(t, eve.DeploymentEventTimedOut, eve.DeploymentEventForState(eve.DeploymentStateTimedOut))
+	assert.Equal(t, eve.DeploymentEventPendingApproval, eve.DeploymentEventForState(eve.DeploymentStatePendingApproval))
+	assert.Equal(t, eve.DeploymentEventRejected, eve.DeploymentEventForState(eve.DeploymentStateRejected))
+	assert.Equal(t, eve.DeploymentEventWaiting, eve.DeploymentEventForState(eve.DeploymentStateWaiting))
+	assert.Equal(t, eve.DeploymentEventMessage, eve.DeploymentEventForState(eve.DeploymentStateUnknown))
+
+	// the finished states end a deployment's stream
+	assert.True(t, eve.DeploymentEvent{Type: eve.DeploymentEventForState(eve.DeploymentStateTimedOut)}.Finished())
+	assert.False(t, eve.DeploymentEvent{Type: eve.DeploymentEventForState(eve.DeploymentStateWaiting)}.Finished())
+}
@@ -0,0 +1,69 @@
+package eve
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type ValueChangeType string
+
+const (
+	ValueChangeAdded   ValueChangeType = "added"
+	ValueChangeRemoved ValueChangeType = "removed"
+	ValueChangeChanged ValueChangeType = "changed"
+)
+
+// ValueChange is a single difference between two documents, Path is the dot separated keys leading to the value
+type ValueChange struct {
+	Path string          `json:"path"`
+	Type ValueChangeType `json:"type"`
+	From interface{}     `json:"from,omitempty"`
+	To   interface{}     `json:"to,omitempty"`
+}
+
+// DiffValues compares two documents, nested objects are compared key by key and anything else (lists included) is
+// compared as a whole. The changes are sorted by path
+func DiffValues(from, to map[string]interface{}) []ValueChange {
+	var changes []ValueChange
+	diffValues("", from, to, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffValues(prefix string, from, to map[string]interface{}, changes *[]ValueChange) {
+	for k, fromValue := range from {
+		path := joinPath(prefix, k)
+		toValue, ok := to[k]
+		if !ok {
+			*changes = append(*changes, ValueChange{Path: path, Type: ValueChangeRemoved, From: fromValue})
+			continue
+		}
+
+		fromMap, fromIsMap := fromValue.(map[string]interface{})
+		toMap, toIsMap := toValue.(map[string]interface{})
+		if fromIsMap && toIsMap {
+			diffValues(path, fromMap, toMap, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(fromValue, toValue) {
+			*changes = append(*changes, ValueChange{Path: path, Type: ValueChangeChanged, From: fromValue, To: toValue})
+		}
+	}
+
+	for k, toValue := range to {
+		if _, ok := from[k]; !ok {
+			*changes = append(*changes, ValueChange{Path: joinPath(prefix, k), Type: ValueChangeAdded, To: toValue})
+		}
+	}
+}
+
+func joinPath(prefix string, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return strings.Join([]string{prefix, key}, ".")
+}
@@ -0,0 +1,51 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestDiffValues(t *testing.T) {
+	from := map[string]interface{}{
+		"replicas": float64(2),
+		"removed":  "x",
+		"env": map[string]interface{}{
+			"LOG_LEVEL": "info",
+			"REGION":    "us-east-1",
+		},
+		"ports": []interface{}{float64(80)},
+	}
+	to := map[string]interface{}{
+		"replicas": float64(3),
+		"added":    true,
+		"env": map[string]interface{}{
+			"LOG_LEVEL": "debug",
+			"REGION":    "us-east-1",
+		},
+		"ports": []interface{}{float64(80)},
+	}
+
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "added", Type: eve.ValueChangeAdded, To: true},
+		{Path: "env.LOG_LEVEL", Type: eve.ValueChangeChanged, From: "info", To: "debug"},
+		{Path: "removed", Type: eve.ValueChangeRemoved, From: "x"},
+		{Path: "replicas", Type: eve.ValueChangeChanged, From: float64(2), To: float64(3)},
+	}, eve.DiffValues(from, to))
+
+	assert.Nil(t, eve.DiffValues(to, to))
+}
+
+func TestDiffValues_ObjectReplaced(t *testing.T) {
+	from := map[string]interface{}{"env": "none"}
+	to := map[string]interface{}{"env": map[string]interface{}{"A": "1"}}
+
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "env", Type: eve.ValueChangeChanged, From: "none", To: map[string]interface{}{"A": "1"}},
+	}, eve.DiffValues(from, to))
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "env", Type: eve.ValueChangeAdded, To: map[string]interface{}{"A": "1"}},
+	}, eve.DiffValues(nil, to))
+}
@@ -3,9 +3,13 @@ package eve
 import "time"
 
 type Environment struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Alias       string    `json:"alias,omitempty"`
-	Description string    `json:"description"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	Description string `json:"description"`
+	// DeploymentTimeout is in seconds, when it's 0 the default timeout is used
+	DeploymentTimeout int `json:"deployment_timeout,omitempty"`
+	// RequiredApprovals is the number of approvals a deployment needs before it's queued, 0 doesn't require approval
+	RequiredApprovals int       `json:"required_approvals,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
@@ -0,0 +1,115 @@
+package eve
+
+import (
+	"sort"
+)
+
+// MapScope is what a metadata or definition map applies to, only the ids it's scoped by are set
+type MapScope struct {
+	EnvironmentID int `json:"environment_id,omitempty"`
+	NamespaceID   int `json:"namespace_id,omitempty"`
+	ClusterID     int `json:"cluster_id,omitempty"`
+	ArtifactID    int `json:"artifact_id,omitempty"`
+	ServiceID     int `json:"service_id,omitempty"`
+	JobID         int `json:"job_id,omitempty"`
+}
+
+// MetadataSource is a metadata document mapped to a service or job
+type MetadataSource struct {
+	MetadataID     int           `json:"metadata_id"`
+	Description    string        `json:"description"`
+	MapDescription string        `json:"map_description"`
+	Scope          MapScope      `json:"scope"`
+	StackingOrder  int           `json:"stacking_order"`
+	Metadata       MetadataField `json:"-"`
+}
+
+// MetadataContribution is the value a metadata document has for a key
+type MetadataContribution struct {
+	MetadataSource
+	Value interface{} `json:"value"`
+}
+
+// MetadataKeyExplanation is a key's merged value and the metadata documents that have the key, in the order they're
+// merged. The last one wins unless the values are maps, which are merged
+type MetadataKeyExplanation struct {
+	Key           string                 `json:"key"`
+	Value         interface{}            `json:"value"`
+	Contributions []MetadataContribution `json:"contributions"`
+}
+
+// MetadataExplanation is where each key of a service or job's merged metadata comes from
+type MetadataExplanation struct {
+	Metadata MetadataField            `json:"metadata"`
+	Keys     []MetadataKeyExplanation `json:"keys"`
+}
+
+// NewMetadataExplanation explains the merged metadata, the sources are in the order they were merged
+func NewMetadataExplanation(merged MetadataField, sources []MetadataSource) MetadataExplanation {
+	explanation := MetadataExplanation{
+		Metadata: merged,
+		Keys:     make([]MetadataKeyExplanation, 0),
+	}
+
+	for _, key := range sortedKeys(merged) {
+		keyExplanation := MetadataKeyExplanation{
+			Key:           key,
+			Value:         merged[key],
+			Contributions: make([]MetadataContribution, 0),
+		}
+		for _, x := range sources {
+			if value, ok := x.Metadata[key]; ok {
+				keyExplanation.Contributions = append(keyExplanation.Contributions, MetadataContribution{
+					MetadataSource: x,
+					Value:          value,
+				})
+			}
+		}
+		explanation.Keys = append(explanation.Keys, keyExplanation)
+	}
+
+	return explanation
+}
+
+// DefinitionSource is a definition mapped to a service or job, Result is the key of the definition result it's merged
+// into
+type DefinitionSource struct {
+	DefinitionID     int                    `json:"definition_id"`
+	DefinitionTypeID int                    `json:"definition_type_id"`
+	Description      string                 `json:"description"`
+	MapDescription   string                 `json:"map_description"`
+	Scope            MapScope               `json:"scope"`
+	StackingOrder    int                    `json:"stacking_order"`
+	Result           string                 `json:"-"`
+	Data             map[string]interface{} `json:"data"`
+}
+
+// DefinitionExplanation is a merged definition result and the definitions merged into it, in order. A default
+// definition that's added because none of its kind are mapped has no sources
+type DefinitionExplanation struct {
+	DefinitionResult
+	Sources []DefinitionSource `json:"sources"`
+}
+
+// NewDefinitionExplanations explains each of the merged definition results, the sources are in the order they were
+// merged
+func NewDefinitionExplanations(results DefinitionResults, sources []DefinitionSource) []DefinitionExplanation {
+	explanations := make([]DefinitionExplanation, 0)
+	for _, x := range results {
+		explanation := DefinitionExplanation{
+			DefinitionResult: x,
+			Sources:          make([]DefinitionSource, 0),
+		}
+		for _, y := range sources {
+			if y.Result == x.Key() {
+				explanation.Sources = append(explanation.Sources, y)
+			}
+		}
+		explanations = append(explanations, explanation)
+	}
+
+	sort.SliceStable(explanations, func(i, j int) bool {
+		return explanations[i].Key() < explanations[j].Key()
+	})
+	return explanations
+}
@@ -0,0 +1,71 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestNewMetadataExplanation(t *testing.T) {
+	environment := eve.MetadataSource{
+		MetadataID:     1,
+		Description:    "int",
+		MapDescription: "int-environment",
+		Scope:          eve.MapScope{EnvironmentID: 2},
+		StackingOrder:  10,
+		Metadata:       eve.MetadataField{"LOG_LEVEL": "info", "REGION": "us-east-1"},
+	}
+	service := eve.MetadataSource{
+		MetadataID:     3,
+		Description:    "api",
+		MapDescription: "api-service",
+		Scope:          eve.MapScope{ServiceID: 4},
+		StackingOrder:  20,
+		Metadata:       eve.MetadataField{"LOG_LEVEL": "debug"},
+	}
+
+	explanation := eve.NewMetadataExplanation(eve.MetadataField{"LOG_LEVEL": "debug", "REGION": "us-east-1"}, []eve.MetadataSource{environment, service})
+
+	assert.Equal(t, []eve.MetadataKeyExplanation{
+		{
+			Key:   "LOG_LEVEL",
+			Value: "debug",
+			Contributions: []eve.MetadataContribution{
+				{MetadataSource: environment, Value: "info"},
+				{MetadataSource: service, Value: "debug"},
+			},
+		},
+		{
+			Key:           "REGION",
+			Value:         "us-east-1",
+			Contributions: []eve.MetadataContribution{{MetadataSource: environment, Value: "us-east-1"}},
+		},
+	}, explanation.Keys)
+}
+
+func TestNewDefinitionExplanations(t *testing.T) {
+	replicas := eve.DefinitionSource{
+		DefinitionID:  1,
+		Description:   "api-replicas",
+		Scope:         eve.MapScope{ServiceID: 4},
+		StackingOrder: 10,
+		Result:        "main.apps.v1.Deployment",
+		Data:          map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}},
+	}
+
+	results := eve.DefinitionResults{
+		eve.DefaultServiceResourceDef(),
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: replicas.Data},
+	}
+
+	explanations := eve.NewDefinitionExplanations(results, []eve.DefinitionSource{replicas})
+
+	assert.Len(t, explanations, 2)
+	// the default service definition isn't mapped
+	assert.Equal(t, "main..v1.Service", explanations[0].Key())
+	assert.Empty(t, explanations[0].Sources)
+	assert.Equal(t, "main.apps.v1.Deployment", explanations[1].Key())
+	assert.Equal(t, []eve.DefinitionSource{replicas}, explanations[1].Sources)
+}
@@ -0,0 +1,110 @@
+package eve
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/robfig/cron/v3"
+
+	"github.com/unanet/eve/internal/data"
+)
+
+// FreezeWindow blocks deployments to an environment, or to one of its namespaces when NamespaceID is set.
+// A recurring window has a Schedule (a cron expression for when the window starts, which can be prefixed with CRON_TZ=<zone>)
+// and a Duration in seconds, a one-off window has a StartsAt and EndsAt
+type FreezeWindow struct {
+	ID            int        `json:"id"`
+	EnvironmentID int        `json:"environment_id"`
+	NamespaceID   int        `json:"namespace_id,omitempty"`
+	Description   string     `json:"description"`
+	Schedule      string     `json:"schedule,omitempty"`
+	Duration      int        `json:"duration,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	Disabled      bool       `json:"disabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (fw FreezeWindow) ValidateWithContext(ctx context.Context) error {
+	recurring := len(fw.Schedule) > 0
+	return validation.ValidateStructWithContext(ctx, &fw,
+		validation.Field(&fw.EnvironmentID, validation.Required),
+		validation.Field(&fw.Description, validation.Required),
+		validation.Field(&fw.Schedule, validation.When(recurring, validation.By(validCronSchedule))),
+		validation.Field(&fw.Duration, validation.When(recurring, validation.Required, validation.Min(1))),
+		validation.Field(&fw.StartsAt, validation.When(!recurring, validation.Required).Else(validation.Nil)),
+		validation.Field(&fw.EndsAt, validation.When(!recurring, validation.Required, validation.By(func(value interface{}) error {
+			if fw.StartsAt != nil && fw.EndsAt != nil && !fw.EndsAt.After(*fw.StartsAt) {
+				return validation.NewError("validation_ends_at", "must be after starts_at")
+			}
+			return nil
+		})).Else(validation.Nil)))
+}
+
+func validCronSchedule(value interface{}) error {
+	if _, err := cron.ParseStandard(value.(string)); err != nil {
+		return validation.NewError("validation_schedule", "must be a valid cron expression")
+	}
+	return nil
+}
+
+// Active is true when the window covers t
+func (fw FreezeWindow) Active(t time.Time) bool {
+	if fw.Disabled {
+		return false
+	}
+
+	if len(fw.Schedule) > 0 {
+		schedule, err := cron.ParseStandard(fw.Schedule)
+		if err != nil || fw.Duration <= 0 {
+			return false
+		}
+		// the first start after t - duration is the only one that can still be open at t
+		start := schedule.Next(t.Add(-time.Duration(fw.Duration) * time.Second))
+		return !start.IsZero() && !start.After(t)
+	}
+
+	if fw.StartsAt == nil || fw.EndsAt == nil {
+		return false
+	}
+	return !t.Before(*fw.StartsAt) && t.Before(*fw.EndsAt)
+}
+
+// Applies is true when the window covers the namespace, an environment level window covers all of its namespaces
+func (fw FreezeWindow) Applies(environmentID int, namespaceID int) bool {
+	return fw.EnvironmentID == environmentID && (fw.NamespaceID == 0 || fw.NamespaceID == namespaceID)
+}
+
+func ToFreezeWindow(w data.FreezeWindow) FreezeWindow {
+	window := FreezeWindow{
+		ID:            w.ID,
+		EnvironmentID: w.EnvironmentID,
+		NamespaceID:   int(w.NamespaceID.Int32),
+		Description:   w.Description,
+		Schedule:      w.Schedule.String,
+		Duration:      int(w.Duration.Int32),
+		Disabled:      w.Disabled,
+		CreatedAt:     w.CreatedAt.Time,
+		UpdatedAt:     w.UpdatedAt.Time,
+	}
+
+	if w.StartsAt.Valid {
+		window.StartsAt = &w.StartsAt.Time
+	}
+
+	if w.EndsAt.Valid {
+		window.EndsAt = &w.EndsAt.Time
+	}
+
+	return window
+}
+
+func ToFreezeWindows(windows data.FreezeWindows) []FreezeWindow {
+	var list []FreezeWindow
+	for _, x := range windows {
+		list = append(list, ToFreezeWindow(x))
+	}
+	return list
+}
@@ -0,0 +1,56 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestFreezeWindow_ActiveRecurring(t *testing.T) {
+	// weekdays 9am - 5pm UTC
+	w := eve.FreezeWindow{Schedule: "0 9 * * 1-5", Duration: int((8 * time.Hour).Seconds())}
+
+	assert.True(t, w.Active(time.Date(2021, 3, 3, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, w.Active(time.Date(2021, 3, 3, 16, 59, 0, 0, time.UTC)))
+	assert.False(t, w.Active(time.Date(2021, 3, 3, 17, 0, 0, 0, time.UTC)))
+	assert.False(t, w.Active(time.Date(2021, 3, 3, 8, 59, 0, 0, time.UTC)))
+	// saturday
+	assert.False(t, w.Active(time.Date(2021, 3, 6, 12, 0, 0, 0, time.UTC)))
+
+	w.Disabled = true
+	assert.False(t, w.Active(time.Date(2021, 3, 3, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestFreezeWindow_ActiveRange(t *testing.T) {
+	starts := time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC)
+	ends := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	w := eve.FreezeWindow{StartsAt: &starts, EndsAt: &ends}
+
+	assert.True(t, w.Active(starts))
+	assert.True(t, w.Active(time.Date(2021, 12, 25, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, w.Active(ends))
+	assert.False(t, w.Active(starts.Add(-time.Second)))
+}
+
+func TestFreezeWindow_Applies(t *testing.T) {
+	assert.True(t, eve.FreezeWindow{EnvironmentID: 1}.Applies(1, 5))
+	assert.True(t, eve.FreezeWindow{EnvironmentID: 1, NamespaceID: 5}.Applies(1, 5))
+	assert.False(t, eve.FreezeWindow{EnvironmentID: 1, NamespaceID: 6}.Applies(1, 5))
+	assert.False(t, eve.FreezeWindow{EnvironmentID: 2}.Applies(1, 5))
+}
+
+func TestFreezeWindow_Validate(t *testing.T) {
+	starts := time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC)
+	ends := starts.Add(time.Hour)
+
+	assert.NoError(t, eve.FreezeWindow{EnvironmentID: 1, Description: "business hours", Schedule: "0 9 * * 1-5", Duration: 60}.ValidateWithContext(context.TODO()))
+	assert.NoError(t, eve.FreezeWindow{EnvironmentID: 1, Description: "holidays", StartsAt: &starts, EndsAt: &ends}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.FreezeWindow{EnvironmentID: 1, Description: "bad", Schedule: "not a cron", Duration: 60}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.FreezeWindow{EnvironmentID: 1, Description: "no duration", Schedule: "0 9 * * 1-5"}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.FreezeWindow{EnvironmentID: 1, Description: "backwards", StartsAt: &ends, EndsAt: &starts}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.FreezeWindow{EnvironmentID: 1, Description: "both", Schedule: "0 9 * * 1-5", Duration: 60, StartsAt: &starts, EndsAt: &ends}.ValidateWithContext(context.TODO()))
+}
@@ -0,0 +1,89 @@
+package eve
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// MetadataProposal is a change to a metadata document that's previewed without being saved. The value replaces the
+// document's value like PUT /metadata does, or when Merge is set its top level keys are merged in like PATCH /metadata
+type MetadataProposal struct {
+	Description string        `json:"description"`
+	Value       MetadataField `json:"value"`
+	Merge       bool          `json:"merge"`
+}
+
+func (m MetadataProposal) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &m,
+		validation.Field(&m.Description, validation.Required),
+		validation.Field(&m.Value))
+}
+
+// DefinitionProposal is a change to a definition that's previewed without being saved, see MetadataProposal
+type DefinitionProposal struct {
+	Description string                 `json:"description"`
+	Data        map[string]interface{} `json:"data"`
+	Merge       bool                   `json:"merge"`
+}
+
+func (d DefinitionProposal) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &d,
+		validation.Field(&d.Description, validation.Required))
+}
+
+// Impact is what a proposed change would do to a service or job's merged metadata or definitions. A service or job
+// the change is mapped to that has no changes, e.g. because a map with a higher stacking order sets the same keys, is
+// still included
+type Impact struct {
+	ServiceID int           `json:"service_id,omitempty"`
+	JobID     int           `json:"job_id,omitempty"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace"`
+	Changes   []ValueChange `json:"changes"`
+}
+
+// ImpactAnalysis is every service and job a proposed change would affect
+type ImpactAnalysis struct {
+	Services []Impact `json:"services"`
+	Jobs     []Impact `json:"jobs"`
+}
+
+// NewImpactAnalysis returns an analysis without any impact, a change to something that isn't mapped doesn't affect
+// anything
+func NewImpactAnalysis() ImpactAnalysis {
+	return ImpactAnalysis{
+		Services: make([]Impact, 0),
+		Jobs:     make([]Impact, 0),
+	}
+}
+
+// NewMetadataImpact compares the merged metadata before and after the change
+func NewMetadataImpact(name string, before, after MetadataField) Impact {
+	return newImpact(name, before, after)
+}
+
+// NewDefinitionImpact compares the merged definition results before and after the change, the path of each change
+// starts with the key of the definition result, e.g. main.apps.v1.Deployment.spec.replicas
+func NewDefinitionImpact(name string, before, after DefinitionResults) Impact {
+	return newImpact(name, definitionResultsByKey(before), definitionResultsByKey(after))
+}
+
+func newImpact(name string, before, after map[string]interface{}) Impact {
+	changes := DiffValues(before, after)
+	if changes == nil {
+		changes = []ValueChange{}
+	}
+	return Impact{
+		Name:    name,
+		Changes: changes,
+	}
+}
+
+func definitionResultsByKey(results DefinitionResults) map[string]interface{} {
+	byKey := make(map[string]interface{}, len(results))
+	for _, x := range results {
+		byKey[x.Key()] = x.Data
+	}
+	return byKey
+}
@@ -0,0 +1,53 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestNewMetadataImpact(t *testing.T) {
+	impact := eve.NewMetadataImpact("api",
+		eve.MetadataField{"LOG_LEVEL": "info", "REGION": "us-east-1"},
+		eve.MetadataField{"LOG_LEVEL": "debug", "REGION": "us-east-1", "TIMEOUT": "30s"},
+	)
+
+	assert.Equal(t, "api", impact.Name)
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "LOG_LEVEL", Type: eve.ValueChangeChanged, From: "info", To: "debug"},
+		{Path: "TIMEOUT", Type: eve.ValueChangeAdded, To: "30s"},
+	}, impact.Changes)
+
+	// a service the change is mapped to is still listed when a later map overrides it
+	unchanged := eve.NewMetadataImpact("api", eve.MetadataField{"LOG_LEVEL": "warn"}, eve.MetadataField{"LOG_LEVEL": "warn"})
+	assert.NotNil(t, unchanged.Changes)
+	assert.Empty(t, unchanged.Changes)
+}
+
+func TestNewDefinitionImpact(t *testing.T) {
+	before := eve.DefinitionResults{
+		eve.DefaultServiceResourceDef(),
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": float64(2)},
+		}},
+	}
+	after := eve.DefinitionResults{
+		eve.DefaultServiceResourceDef(),
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": float64(3)},
+		}},
+	}
+
+	impact := eve.NewDefinitionImpact("api", before, after)
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "main.apps.v1.Deployment.spec.replicas", Type: eve.ValueChangeChanged, From: float64(2), To: float64(3)},
+	}, impact.Changes)
+}
+
+func TestMetadataProposal_ValidateWithContext(t *testing.T) {
+	assert.Error(t, eve.MetadataProposal{Value: eve.MetadataField{"LOG_LEVEL": "debug"}}.ValidateWithContext(context.Background()))
+	assert.NoError(t, eve.MetadataProposal{Description: "int", Merge: true}.ValidateWithContext(context.Background()))
+}
@@ -1,7 +1,10 @@
 package eve
 
 import (
+	"context"
 	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 type Job struct {
@@ -16,3 +19,8 @@ type Job struct {
 	UpdatedAt       time.Time `json:"updated_at"`
 	Name            string    `json:"name"`
 }
+
+func (j Job) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &j,
+		validation.Field(&j.OverrideVersion, validation.By(validVersionConstraint)))
+}
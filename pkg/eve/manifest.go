@@ -0,0 +1,192 @@
+package eve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/unanet/go/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ManifestFormatJSON = "json"
+	ManifestFormatYAML = "yaml"
+)
+
+// Manifests are the rendered documents a service or job would be deployed with at a version
+type Manifests struct {
+	Name      string                   `json:"name"`
+	Namespace string                   `json:"namespace"`
+	Version   string                   `json:"version"`
+	Image     string                   `json:"image"`
+	Documents []map[string]interface{} `json:"documents"`
+}
+
+// YAML renders the documents as a multi document stream
+func (m Manifests) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, x := range m.Documents {
+		if err := encoder.Encode(x); err != nil {
+			return nil, errors.Wrap(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ImageName is the image the scheduler deploys the artifact's available version with
+func ImageName(a *DeployArtifact) string {
+	tag := a.EvalImageTag()
+	if len(tag) == 0 {
+		tag = a.AvailableVersion
+	}
+	return fmt.Sprintf("%s:%s", a.ArtifactName, tag)
+}
+
+// RenderManifests renders each definition the way the scheduler applies it: the definition's data with its apiVersion,
+// kind, name and namespace plus the standard labels and annotations. Workloads (a Deployment or Job) also get the image
+// and the metadata as the environment of their first container
+func RenderManifests(spec DeploymentSpec, metadata MetadataField, definitions DefinitionResults, namespace string) (Manifests, error) {
+	manifests := Manifests{
+		Name:      spec.GetName(),
+		Namespace: namespace,
+		Version:   spec.GetArtifact().AvailableVersion,
+		Image:     ImageName(spec.GetArtifact()),
+	}
+
+	for _, x := range definitions {
+		doc, err := copyDocument(x.Data)
+		if err != nil {
+			return manifests, err
+		}
+
+		doc["apiVersion"] = x.APIVersion()
+		doc["kind"] = x.Kind
+
+		meta := documentPath(doc, "metadata")
+		if _, ok := meta["name"]; !ok {
+			meta["name"] = spec.GetName()
+		}
+		meta["namespace"] = namespace
+
+		if labels := x.StandardLabels(spec); len(labels) > 0 {
+			mergeValues(documentPath(doc, x.LabelKeys()...), labels)
+		}
+		if annotations := x.StandardAnnotations(spec); len(annotations) > 0 {
+			mergeValues(documentPath(doc, x.AnnotationKeys()...), annotations)
+		}
+
+		switch strings.ToLower(x.Kind) {
+		case "deployment":
+			docSpec := documentPath(doc, "spec")
+			if _, ok := docSpec["replicas"]; !ok && spec.GetDefaultCount() > 0 {
+				docSpec["replicas"] = spec.GetDefaultCount()
+			}
+			applyContainer(doc, spec, manifests.Image, metadata)
+		case "job":
+			applyContainer(doc, spec, manifests.Image, metadata)
+		}
+
+		manifests.Documents = append(manifests.Documents, doc)
+	}
+
+	return manifests, nil
+}
+
+// applyContainer sets the image and adds the metadata to the environment of the workload's first container
+func applyContainer(doc map[string]interface{}, spec DeploymentSpec, image string, metadata MetadataField) {
+	podSpec := documentPath(doc, "spec", "template", "spec")
+	containers, _ := podSpec["containers"].([]interface{})
+	if len(containers) == 0 {
+		containers = []interface{}{map[string]interface{}{}}
+	}
+
+	container, ok := containers[0].(map[string]interface{})
+	if !ok {
+		container = make(map[string]interface{})
+	}
+	if _, ok := container["name"]; !ok {
+		container["name"] = spec.GetName()
+	}
+	container["image"] = image
+
+	env, _ := container["env"].([]interface{})
+	var names []string
+	for k := range metadata {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = setEnv(env, name, envValue(metadata[name]))
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+
+	containers[0] = container
+	podSpec["containers"] = containers
+}
+
+func setEnv(env []interface{}, name string, value string) []interface{} {
+	for i, x := range env {
+		if v, ok := x.(map[string]interface{}); ok && v["name"] == name {
+			env[i] = map[string]interface{}{"name": name, "value": value}
+			return env
+		}
+	}
+	return append(env, map[string]interface{}{"name": name, "value": value})
+}
+
+func envValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// documentPath returns the object at the keys, creating (or replacing) anything along the way that isn't an object
+func documentPath(doc map[string]interface{}, keys ...string) map[string]interface{} {
+	current := doc
+	for _, k := range keys {
+		next, ok := current[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[k] = next
+		}
+		current = next
+	}
+	return current
+}
+
+func mergeValues(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+func copyDocument(data map[string]interface{}) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	if data == nil {
+		return doc, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return doc, nil
+}
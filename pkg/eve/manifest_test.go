@@ -0,0 +1,99 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func manifestService() *eve.DeployService {
+	return &eve.DeployService{
+		DeployArtifact: &eve.DeployArtifact{
+			ArtifactName:     "api",
+			AvailableVersion: "1.2.3",
+			ImageTag:         "$1.$2",
+		},
+		ServiceName: "api",
+		MetricsPort: 3001,
+		Count:       2,
+		Nuance:      "1",
+	}
+}
+
+func TestRenderManifests(t *testing.T) {
+	definitions := eve.DefinitionResults{
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name": "api",
+								"env":  []interface{}{map[string]interface{}{"name": "LOG_LEVEL", "value": "info"}},
+							},
+						},
+					},
+				},
+			},
+		}},
+		eve.DefaultServiceResourceDef(),
+	}
+	metadata := eve.MetadataField{"LOG_LEVEL": "debug", "WORKERS": float64(4)}
+
+	manifests, err := eve.RenderManifests(manifestService(), metadata, definitions, "int-app")
+	require.NoError(t, err)
+
+	assert.Equal(t, "api:1.2", manifests.Image)
+	require.Len(t, manifests.Documents, 2)
+
+	deployment := manifests.Documents[0]
+	assert.Equal(t, "apps/v1", deployment["apiVersion"])
+	assert.Equal(t, map[string]interface{}{"name": "api", "namespace": "int-app"}, deployment["metadata"])
+
+	spec := deployment["spec"].(map[string]interface{})
+	assert.Equal(t, 2, spec["replicas"])
+	template := spec["template"].(map[string]interface{})
+	templateMeta := template["metadata"].(map[string]interface{})
+	assert.Equal(t, "1.2.3", templateMeta["labels"].(map[string]interface{})["version"])
+	assert.Equal(t, "3001", templateMeta["annotations"].(map[string]interface{})["prometheus.io/port"])
+
+	container := template["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "api:1.2", container["image"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+		map[string]interface{}{"name": "WORKERS", "value": "4"},
+	}, container["env"])
+
+	svc := manifests.Documents[1]
+	assert.Equal(t, "v1", svc["apiVersion"])
+	assert.Equal(t, "Service", svc["kind"])
+	assert.NotContains(t, svc["metadata"], "labels")
+
+	// the definitions aren't changed by rendering them
+	assert.NotContains(t, definitions[0].Data, "apiVersion")
+}
+
+func TestManifests_YAML(t *testing.T) {
+	manifests, err := eve.RenderManifests(manifestService(), nil, eve.DefinitionResults{eve.DefaultServiceResourceDef(), eve.DefaultServiceResourceDef()}, "int-app")
+	require.NoError(t, err)
+
+	b, err := manifests.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  namespace: int-app
+spec: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  namespace: int-app
+spec: {}
+`, string(b))
+}
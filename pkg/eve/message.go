@@ -7,6 +7,7 @@ import (
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/unanet/eve/pkg/s3"
+	"github.com/unanet/eve/pkg/storage"
 	"github.com/unanet/go/pkg/errors"
 )
 
@@ -29,6 +30,53 @@ type CallbackMessage struct {
 	Messages []string `json:"messages"`
 }
 
+// UnMarshalNSDeploymentFromLocationBody downloads the plan from whichever backend the location envelope points to
+func UnMarshalNSDeploymentFromLocationBody(ctx context.Context, sd storage.Downloader, b []byte) (*NSDeploymentPlan, error) {
+	var location storage.Location
+	err := json.Unmarshal(b, &location)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	planText, err := sd.Download(ctx, &location)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var nsDeploymentPlan NSDeploymentPlan
+	err = json.Unmarshal(planText, &nsDeploymentPlan)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &nsDeploymentPlan, nil
+}
+
+// MarshalNSDeploymentPlanToLocationBody stores the plan and returns the location envelope used as the queue message body
+func MarshalNSDeploymentPlanToLocationBody(ctx context.Context, su storage.Uploader, plan *NSDeploymentPlan) ([]byte, error) {
+	nsDeploymentJson, err := json.Marshal(plan)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	key := fmt.Sprintf("%s.json", plan.DeploymentID)
+	// every wave of a deployment is stored until it's sent
+	if plan.Wave > 1 {
+		key = fmt.Sprintf("%s-wave-%d.json", plan.DeploymentID, plan.Wave)
+	}
+	location, err := su.Upload(ctx, key, nsDeploymentJson)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	locationJson, err := json.Marshal(location)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return locationJson, nil
+}
+
+// UnMarshalNSDeploymentFromS3LocationBody is kept for schedulers that only understand s3 locations
 func UnMarshalNSDeploymentFromS3LocationBody(ctx context.Context, cd CloudDownloader, b []byte) (*NSDeploymentPlan, error) {
 	var location s3.Location
 	err := json.Unmarshal(b, &location)
@@ -50,6 +98,7 @@ func UnMarshalNSDeploymentFromS3LocationBody(ctx context.Context, cd CloudDownlo
 	return &nsDeploymentPlan, nil
 }
 
+// MarshalNSDeploymentPlanToS3LocationBody is kept for schedulers that only understand s3 locations
 func MarshalNSDeploymentPlanToS3LocationBody(ctx context.Context, cu CloudUploader, plan *NSDeploymentPlan) ([]byte, error) {
 	nsDeploymentJson, err := json.Marshal(plan)
 	if err != nil {
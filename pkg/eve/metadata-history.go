@@ -1,10 +1,14 @@
 package eve
 
 import (
+	"context"
 	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 type MetadataHistory struct {
+	Revision    int                    `json:"revision"`
 	MetadataId  int                    `json:"metadata_id"`
 	Description string                 `json:"description"`
 	Value       map[string]interface{} `json:"value"`
@@ -13,3 +17,54 @@ type MetadataHistory struct {
 	Deleted     *time.Time             `json:"deleted"`
 	DeletedBy   *string                `json:"deleted_by"`
 }
+
+// MetadataHistoryQuery holds the filters used to search the metadata history, Metadata is an id or a description. It
+// can't be filtered by author since the history's created_by is the database role that made the change, a person's
+// changes are found with the audit log (/audit?entity=metadata&user=)
+type MetadataHistoryQuery struct {
+	Metadata string
+	From     *time.Time
+	To       *time.Time
+	Cursor   string
+	Limit    int
+}
+
+// MetadataHistoryPage is a page of the metadata history, NextCursor is empty when there are no more results. The api
+// responds with the History and returns the NextCursor in the X-Next-Cursor header
+type MetadataHistoryPage struct {
+	History    []MetadataHistory
+	NextCursor string
+}
+
+// MetadataDiff is what changed in each key of a metadata document between two revisions, without a to revision it's
+// compared with the current value
+type MetadataDiff struct {
+	MetadataID   int           `json:"metadata_id"`
+	FromRevision int           `json:"from_revision"`
+	ToRevision   int           `json:"to_revision,omitempty"`
+	Changes      []ValueChange `json:"changes"`
+}
+
+// NewMetadataDiff compares the value of the from revision with the to value
+func NewMetadataDiff(from MetadataHistory, toRevision int, to map[string]interface{}) MetadataDiff {
+	changes := DiffValues(from.Value, to)
+	if changes == nil {
+		changes = []ValueChange{}
+	}
+	return MetadataDiff{
+		MetadataID:   from.MetadataId,
+		FromRevision: from.Revision,
+		ToRevision:   toRevision,
+		Changes:      changes,
+	}
+}
+
+// MetadataRestore is the point in time a metadata document is rolled back to
+type MetadataRestore struct {
+	Time time.Time `json:"time"`
+}
+
+func (m MetadataRestore) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &m,
+		validation.Field(&m.Time, validation.Required))
+}
@@ -0,0 +1,45 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestNewMetadataDiff(t *testing.T) {
+	from := eve.MetadataHistory{
+		Revision:   10,
+		MetadataId: 4,
+		Value: map[string]interface{}{
+			"LOG_LEVEL": "info",
+			"TIMEOUT":   float64(30),
+			"REMOVED":   "x",
+		},
+	}
+
+	diff := eve.NewMetadataDiff(from, 0, map[string]interface{}{
+		"LOG_LEVEL": "debug",
+		"TIMEOUT":   float64(30),
+		"ADDED":     true,
+	})
+	assert.Equal(t, eve.MetadataDiff{
+		MetadataID:   4,
+		FromRevision: 10,
+		Changes: []eve.ValueChange{
+			{Path: "ADDED", Type: eve.ValueChangeAdded, To: true},
+			{Path: "LOG_LEVEL", Type: eve.ValueChangeChanged, From: "info", To: "debug"},
+			{Path: "REMOVED", Type: eve.ValueChangeRemoved, From: "x"},
+		},
+	}, diff)
+
+	assert.Equal(t, []eve.ValueChange{}, eve.NewMetadataDiff(from, 11, from.Value).Changes)
+}
+
+func TestMetadataRestore_ValidateWithContext(t *testing.T) {
+	assert.Error(t, eve.MetadataRestore{}.ValidateWithContext(context.Background()))
+	assert.NoError(t, eve.MetadataRestore{Time: time.Now()}.ValidateWithContext(context.Background()))
+}
@@ -1,17 +1,30 @@
 package eve
 
-import "time"
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
 
 type Namespace struct {
-	ID               int                    `json:"id"`
-	Name             string                 `json:"name"`
-	Alias            string                 `json:"alias"`
-	EnvironmentID    int                    `json:"environment_id"`
-	EnvironmentName  string                 `json:"environment_name"`
-	RequestedVersion string                 `json:"requested_version"`
-	ExplicitDeploy   bool                   `json:"explicit_deploy"`
-	ClusterID        int                    `json:"cluster_id"`
-	Metadata         map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt        time.Time              `json:"created_at"`
-	UpdatedAt        time.Time              `json:"updated_at"`
+	ID                int                    `json:"id"`
+	Name              string                 `json:"name"`
+	Alias             string                 `json:"alias"`
+	EnvironmentID     int                    `json:"environment_id"`
+	EnvironmentName   string                 `json:"environment_name"`
+	RequestedVersion  string                 `json:"requested_version"`
+	ExplicitDeploy    bool                   `json:"explicit_deploy"`
+	ClusterID         int                    `json:"cluster_id"`
+	RequiredApprovals int                    `json:"required_approvals,omitempty"`
+	DeployOrder       int                    `json:"deploy_order,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
+
+func (n Namespace) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &n,
+		validation.Field(&n.RequestedVersion, validation.By(validVersionConstraint)),
+		validation.Field(&n.DeployOrder, validation.Min(0)))
 }
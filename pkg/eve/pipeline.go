@@ -0,0 +1,163 @@
+package eve
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/json"
+
+	"github.com/unanet/eve/internal/data"
+)
+
+// PipelineStage deploys a build to an environment (or some of its namespaces), an Approval stage waits to be approved
+// before it's deployed and a stage with a Soak time (in seconds) waits that long after its deployments complete before
+// the next stage starts
+type PipelineStage struct {
+	Environment string     `json:"environment"`
+	Namespaces  StringList `json:"namespaces,omitempty"`
+	Approval    bool       `json:"approval,omitempty"`
+	Soak        int        `json:"soak,omitempty"`
+}
+
+func (ps PipelineStage) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &ps,
+		validation.Field(&ps.Environment, validation.Required),
+		validation.Field(&ps.Soak, validation.Min(0)))
+}
+
+func (ps PipelineStage) SoakDuration() time.Duration {
+	return time.Duration(ps.Soak) * time.Second
+}
+
+// Pipeline is an ordered list of environment stages that a build is promoted through, e.g. int, qa, stage, prod
+type Pipeline struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Stages      []PipelineStage `json:"stages"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (p Pipeline) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Stages, validation.Required))
+}
+
+type PipelineRunState string
+
+const (
+	PipelineRunStateRunning          PipelineRunState = "running"
+	PipelineRunStateAwaitingApproval PipelineRunState = "awaiting_approval"
+	PipelineRunStateSoaking          PipelineRunState = "soaking"
+	PipelineRunStateCompleted        PipelineRunState = "completed"
+	PipelineRunStateFailed           PipelineRunState = "failed"
+	PipelineRunStateCancelled        PipelineRunState = "cancelled"
+)
+
+type PipelineStageState string
+
+const (
+	PipelineStageStatePending          PipelineStageState = "pending"
+	PipelineStageStateAwaitingApproval PipelineStageState = "awaiting_approval"
+	PipelineStageStateRunning          PipelineStageState = "running"
+	PipelineStageStateSoaking          PipelineStageState = "soaking"
+	PipelineStageStateSucceeded        PipelineStageState = "succeeded"
+	PipelineStageStateFailed           PipelineStageState = "failed"
+	PipelineStageStateCancelled        PipelineStageState = "cancelled"
+)
+
+// PipelineRunStage is a stage's progress in a run, Artifacts are the versions the stage deployed, they're the ones
+// the next stage deploys
+type PipelineRunStage struct {
+	PipelineStage
+	State         PipelineStageState  `json:"state"`
+	Artifacts     ArtifactDefinitions `json:"artifacts,omitempty"`
+	DeploymentIDs []uuid.UUID         `json:"deployment_ids,omitempty"`
+	Releases      []Release           `json:"releases,omitempty"`
+	ApprovedBy    string              `json:"approved_by,omitempty"`
+	Messages      []string            `json:"messages,omitempty"`
+	StartedAt     *time.Time          `json:"started_at,omitempty"`
+	FinishedAt    *time.Time          `json:"finished_at,omitempty"`
+}
+
+func (prs *PipelineRunStage) Message(format string, a ...interface{}) {
+	prs.Messages = append(prs.Messages, fmt.Sprintf(format, a...))
+}
+
+// PipelineRun tracks one build from the first stage of a pipeline to the last, Stage is the index of the current stage
+type PipelineRun struct {
+	ID          uuid.UUID           `json:"id"`
+	PipelineID  int                 `json:"pipeline_id"`
+	State       PipelineRunState    `json:"state"`
+	Stage       int                 `json:"stage"`
+	Stages      []PipelineRunStage  `json:"stages"`
+	Artifacts   ArtifactDefinitions `json:"artifacts,omitempty"`
+	User        string              `json:"user"`
+	StartedBy   string              `json:"started_by,omitempty"`
+	ForceDeploy bool                `json:"force_deploy"`
+	Message     string              `json:"message,omitempty"`
+	SoakUntil   *time.Time          `json:"soak_until,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// PipelineRunOptions start a run, the artifacts are the versions requested for the first stage (all of the
+// environment's artifacts at their latest versions when none are supplied), ForceDeploy is passed to every stage's deployments
+type PipelineRunOptions struct {
+	User        string              `json:"user"`
+	Artifacts   ArtifactDefinitions `json:"artifacts,omitempty"`
+	ForceDeploy bool                `json:"force_deploy"`
+}
+
+func (pro PipelineRunOptions) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &pro,
+		validation.Field(&pro.User, validation.Required),
+		validation.Field(&pro.Artifacts))
+}
+
+func ToPipeline(p data.Pipeline) (Pipeline, error) {
+	pipeline := Pipeline{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description.String,
+		CreatedAt:   p.CreatedAt.Time,
+		UpdatedAt:   p.UpdatedAt.Time,
+	}
+	if err := p.Stages.Unmarshal(&pipeline.Stages); err != nil {
+		return pipeline, err
+	}
+	return pipeline, nil
+}
+
+func ToPipelineRun(r data.PipelineRun) (PipelineRun, error) {
+	run := PipelineRun{
+		ID:          r.ID,
+		PipelineID:  r.PipelineID,
+		State:       PipelineRunState(r.State),
+		Stage:       r.Stage,
+		User:        r.User,
+		StartedBy:   r.StartedBy,
+		ForceDeploy: r.ForceDeploy,
+		Message:     r.Message.String,
+		CreatedAt:   r.CreatedAt.Time,
+		UpdatedAt:   r.UpdatedAt.Time,
+	}
+	if r.SoakUntil.Valid {
+		run.SoakUntil = &r.SoakUntil.Time
+	}
+	if err := r.Stages.Unmarshal(&run.Stages); err != nil {
+		return run, err
+	}
+	// a run that was started without artifacts deploys the latest versions
+	if r.Artifacts.String() != string(json.EmptyJSONObject) {
+		if err := r.Artifacts.Unmarshal(&run.Artifacts); err != nil {
+			return run, err
+		}
+	}
+	return run, nil
+}
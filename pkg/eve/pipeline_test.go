@@ -0,0 +1,29 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestPipeline_Validate(t *testing.T) {
+	assert.NoError(t, eve.Pipeline{Name: "release", Stages: []eve.PipelineStage{
+		{Environment: "int"},
+		{Environment: "stage", Approval: true, Soak: 3600},
+		{Environment: "prod", Namespaces: eve.StringList{"api"}, Approval: true},
+	}}.ValidateWithContext(context.TODO()))
+
+	assert.Error(t, eve.Pipeline{Name: "empty"}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.Pipeline{Stages: []eve.PipelineStage{{Environment: "int"}}}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.Pipeline{Name: "no environment", Stages: []eve.PipelineStage{{Soak: 60}}}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.Pipeline{Name: "negative soak", Stages: []eve.PipelineStage{{Environment: "int", Soak: -1}}}.ValidateWithContext(context.TODO()))
+}
+
+func TestPipelineRunOptions_Validate(t *testing.T) {
+	assert.NoError(t, eve.PipelineRunOptions{User: "eve", Artifacts: eve.ArtifactDefinitions{{Name: "api", RequestedVersion: "1.2"}}}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.PipelineRunOptions{}.ValidateWithContext(context.TODO()))
+	assert.Error(t, eve.PipelineRunOptions{User: "eve", Artifacts: eve.ArtifactDefinitions{{Name: "api", RequestedVersion: ">>1"}}}.ValidateWithContext(context.TODO()))
+}
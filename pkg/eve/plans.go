@@ -3,10 +3,11 @@ package eve
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/eve/pkg/version"
 )
 
 type StringList []string
@@ -54,19 +55,34 @@ func (ad ArtifactDefinition) Clone(options ...ArtifactDefinitionCloneOption) *Ar
 	return a
 }
 
+func (ad ArtifactDefinition) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &ad,
+		validation.Field(&ad.RequestedVersion, validation.By(validVersionConstraint)))
+}
+
+// ArtifactoryRequestedVersion is the requested version as an Artifactory wildcard pattern, constraints that can't be
+// expressed as a pattern (>=2.0 <3) are returned as is
 func (ad ArtifactDefinition) ArtifactoryRequestedVersion() string {
-	if ad.RequestedVersion == "latest" {
-		return "*"
-	} else if ad.RequestedVersion == "" {
-		return "*"
-	} else if len(strings.Split(ad.RequestedVersion, ".")) < 4 {
-		return ad.RequestedVersion + ".*"
+	c, err := version.ParseConstraint(ad.RequestedVersion)
+	if err != nil {
+		return ad.RequestedVersion
+	}
+	if pattern, ok := c.Pattern(); ok {
+		return pattern
 	}
 	return ad.RequestedVersion
 }
 
 type ArtifactDefinitions []*ArtifactDefinition
 
+func (ad ArtifactDefinitions) Clone() ArtifactDefinitions {
+	var list ArtifactDefinitions
+	for _, x := range ad {
+		list = append(list, x.Clone())
+	}
+	return list
+}
+
 func (ad ArtifactDefinitions) ContainsVersion(name string, version string) bool {
 	for _, x := range ad {
 		if x.AvailableVersion == version && x.ArtifactName == name {
@@ -76,18 +92,14 @@ func (ad ArtifactDefinitions) ContainsVersion(name string, version string) bool
 	return false
 }
 
+// Match returns the artifact whose available version satisfies the requested version constraint
 func (ad ArtifactDefinitions) Match(artifactID int, optName string, requestedVersion string) *ArtifactDefinition {
-	versionMatch := requestedVersion
-	if requestedVersion == "latest" {
-		versionMatch = ""
-	}
-
 	for _, x := range ad {
 		if x.Name != "" {
-			if x.Name == optName && strings.HasPrefix(x.AvailableVersion, versionMatch) {
+			if x.Name == optName && MatchesVersion(x.AvailableVersion, requestedVersion) {
 				return x
 			}
-		} else if x.ID == artifactID && strings.HasPrefix(x.AvailableVersion, versionMatch) {
+		} else if x.ID == artifactID && MatchesVersion(x.AvailableVersion, requestedVersion) {
 			return x
 		}
 	}
@@ -116,6 +128,18 @@ type DeploymentPlanOptions struct {
 	Type             PlanType            `json:"type"`
 	DeploymentIDs    []uuid.UUID         `json:"deployment_ids,omitempty"`
 	Metadata         MetadataField       `json:"metadata"`
+	// FreezeOverride is the reason for deploying inside a freeze window, it's only accepted through the freeze override endpoint
+	FreezeOverride string `json:"freeze_override,omitempty"`
+	// SkipFrozen drops the namespaces that are inside a freeze window from the plan instead of rejecting it
+	SkipFrozen       bool       `json:"-"`
+	FrozenNamespaces StringList `json:"frozen_namespaces,omitempty"`
+	// Canary is required for a canary plan
+	Canary *CanaryOptions `json:"canary,omitempty"`
+	// RolloutID is the rollout a canary plan created, or the one the plan is a stage of
+	RolloutID    *uuid.UUID `json:"rollout_id,omitempty"`
+	RolloutStage string     `json:"-"`
+	// PipelineRunID is the pipeline run the plan is a stage of
+	PipelineRunID *uuid.UUID `json:"pipeline_run_id,omitempty"`
 }
 
 func (po *DeploymentPlanOptions) PlanType() string {
@@ -144,9 +168,40 @@ func (po DeploymentPlanOptions) ValidateWithContext(ctx context.Context) error {
 				DeploymentPlanTypeApplication,
 				DeploymentPlanTypeJob,
 				DeploymentPlanTypeRestart,
+				DeploymentPlanTypeCanary,
 			),
 		),
-		validation.Field(&po.User, validation.Required))
+		validation.Field(&po.User, validation.Required),
+		validation.Field(&po.Artifacts),
+		validation.Field(&po.Canary, validation.When(po.Type == DeploymentPlanTypeCanary, validation.Required).Else(validation.Nil)))
+}
+
+// RollbackOptions are supplied when rolling back a deployment to the versions that were deployed before it
+type RollbackOptions struct {
+	User        string `json:"user"`
+	CallbackURL string `json:"callback_url"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+func (ro RollbackOptions) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &ro,
+		validation.Field(&ro.User, validation.Required))
+}
+
+// CancelOptions are supplied when cancelling a deployment
+type CancelOptions struct {
+	User string `json:"user"`
+}
+
+func (co CancelOptions) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &co,
+		validation.Field(&co.User, validation.Required))
+}
+
+// ApprovalOptions is the body used to approve or reject a deployment that's pending approval, it's recorded as the
+// authenticated user's approval
+type ApprovalOptions struct {
+	Reason string `json:"reason,omitempty"`
 }
 
 type NamespacePlanOptions struct {
@@ -161,4 +216,7 @@ type NamespacePlanOptions struct {
 	EnvironmentAlias  string              `json:"environment_alias"`
 	Type              PlanType            `json:"type"`
 	Metadata          MetadataField       `json:"metadata"`
+	FreezeOverride    string              `json:"freeze_override,omitempty"`
+	// CanaryCount caps the replicas of the deployed services during the canary stage of a rollout
+	CanaryCount int `json:"canary_count,omitempty"`
 }
@@ -0,0 +1,106 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/unanet/go/pkg/errors"
+)
+
+const (
+	PreviewArtifactService = "service"
+	PreviewArtifactJob     = "job"
+)
+
+// DeploymentPlanPreview is what a plan would deploy, nothing is stored or queued to build it
+type DeploymentPlanPreview struct {
+	Environment string                 `json:"environment"`
+	Type        PlanType               `json:"type"`
+	Namespaces  []NamespacePlanPreview `json:"namespaces"`
+	Messages    []string               `json:"messages,omitempty"`
+}
+
+// NamespacePlanPreview is the plan that would be sent to the scheduler for a namespace along with what each of its
+// artifacts would change
+type NamespacePlanPreview struct {
+	Namespace string            `json:"namespace"`
+	Plan      *NSDeploymentPlan `json:"plan"`
+	Artifacts []ArtifactPreview `json:"artifacts,omitempty"`
+}
+
+// ArtifactPreview compares a service or job in the plan with its last successful deployment (the baseline), without a
+// baseline every metadata and definition value is reported as added
+type ArtifactPreview struct {
+	Type                 string            `json:"type"`
+	Name                 string            `json:"name"`
+	ArtifactName         string            `json:"artifact_name"`
+	DeployedVersion      string            `json:"deployed_version,omitempty"`
+	AvailableVersion     string            `json:"available_version,omitempty"`
+	VersionChanged       bool              `json:"version_changed"`
+	BaselineDeploymentID *uuid.UUID        `json:"baseline_deployment_id,omitempty"`
+	Metadata             MetadataField     `json:"metadata"`
+	Definition           DefinitionResults `json:"definition"`
+	MetadataChanges      []ValueChange     `json:"metadata_changes,omitempty"`
+	DefinitionChanges    []ValueChange     `json:"definition_changes,omitempty"`
+}
+
+// ArtifactBaseline is what the last successful deployment of an artifact sent to the scheduler
+type ArtifactBaseline struct {
+	DeploymentID uuid.UUID
+	Metadata     MetadataField
+	Definition   []byte
+}
+
+// NewArtifactPreview compares the artifact and its (marshalled) definition results with the baseline, which can be nil
+func NewArtifactPreview(artifactType string, name string, a *DeployArtifact, definition []byte, baseline *ArtifactBaseline) (ArtifactPreview, error) {
+	preview := ArtifactPreview{
+		Type:             artifactType,
+		Name:             name,
+		ArtifactName:     a.ArtifactName,
+		DeployedVersion:  a.DeployedVersion,
+		AvailableVersion: a.AvailableVersion,
+		VersionChanged:   a.DeployedVersion != a.AvailableVersion,
+		Metadata:         a.Metadata,
+	}
+
+	definitions, err := unmarshalDefinitionResults(definition)
+	if err != nil {
+		return preview, err
+	}
+	preview.Definition = definitions
+
+	var fromMetadata MetadataField
+	var fromDefinitions DefinitionResults
+	if baseline != nil {
+		preview.BaselineDeploymentID = &baseline.DeploymentID
+		fromMetadata = baseline.Metadata
+		if fromDefinitions, err = unmarshalDefinitionResults(baseline.Definition); err != nil {
+			return preview, err
+		}
+	}
+
+	preview.MetadataChanges = DiffValues(fromMetadata, a.Metadata)
+	preview.DefinitionChanges = DiffValues(fromDefinitions.Keyed(), definitions.Keyed())
+	return preview, nil
+}
+
+func unmarshalDefinitionResults(b []byte) (DefinitionResults, error) {
+	var definitions DefinitionResults
+	if len(b) == 0 {
+		return definitions, nil
+	}
+	if err := json.Unmarshal(b, &definitions); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return definitions, nil
+}
+
+// Keyed maps each definition's data by its order, class, version and kind so definitions can be compared
+func (drs DefinitionResults) Keyed() map[string]interface{} {
+	keyed := make(map[string]interface{})
+	for _, x := range drs {
+		keyed[fmt.Sprintf("%s/%s/%s/%s", x.Order, x.Class, x.Version, x.Kind)] = x.Data
+	}
+	return keyed
+}
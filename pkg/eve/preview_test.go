@@ -0,0 +1,59 @@
+package eve_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func marshalDefinitions(t *testing.T, replicas float64) []byte {
+	b, err := json.Marshal(eve.DefinitionResults{
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: map[string]interface{}{"replicas": replicas}},
+	})
+	require.NoError(t, err)
+	return b
+}
+
+func TestNewArtifactPreview(t *testing.T) {
+	artifact := &eve.DeployArtifact{
+		ArtifactName:     "api",
+		DeployedVersion:  "1.0.0",
+		AvailableVersion: "1.1.0",
+		Metadata:         eve.MetadataField{"LOG_LEVEL": "debug"},
+	}
+	baseline := &eve.ArtifactBaseline{
+		DeploymentID: uuid.NewV4(),
+		Metadata:     eve.MetadataField{"LOG_LEVEL": "info"},
+		Definition:   marshalDefinitions(t, 2),
+	}
+
+	preview, err := eve.NewArtifactPreview(eve.PreviewArtifactService, "api", artifact, marshalDefinitions(t, 3), baseline)
+	require.NoError(t, err)
+
+	assert.True(t, preview.VersionChanged)
+	assert.Equal(t, baseline.DeploymentID, *preview.BaselineDeploymentID)
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "LOG_LEVEL", Type: eve.ValueChangeChanged, From: "info", To: "debug"},
+	}, preview.MetadataChanges)
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "main/apps/v1/Deployment.replicas", Type: eve.ValueChangeChanged, From: float64(2), To: float64(3)},
+	}, preview.DefinitionChanges)
+}
+
+func TestNewArtifactPreview_NoBaseline(t *testing.T) {
+	artifact := &eve.DeployArtifact{ArtifactName: "api", Metadata: eve.MetadataField{"LOG_LEVEL": "debug"}}
+
+	preview, err := eve.NewArtifactPreview(eve.PreviewArtifactJob, "migrate", artifact, nil, nil)
+	require.NoError(t, err)
+
+	assert.Nil(t, preview.BaselineDeploymentID)
+	assert.Equal(t, []eve.ValueChange{
+		{Path: "LOG_LEVEL", Type: eve.ValueChangeAdded, To: "debug"},
+	}, preview.MetadataChanges)
+	assert.Empty(t, preview.DefinitionChanges)
+}
@@ -35,6 +35,7 @@ func (r Release) ValidateWithContext(ctx context.Context) error {
 
 		validation.Field(&r.Namespace, releaseNamespaceValidation.Error("namespace is required when release type is artifact")),
 		validation.Field(&r.Artifact, releaseArtifactValidation.Error("artifact is required when release type is artifact")),
+		validation.Field(&r.Version, validation.By(validVersionConstraint)),
 
 		validation.Field(&r.FromFeed, validation.Required),
 		validation.Field(&r.Environment, releaseNamespaceValidation.Error("environment is required when releasing a namespace")),
@@ -0,0 +1,103 @@
+package eve
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/unanet/eve/internal/data"
+)
+
+// CanaryOptions are the first stage of a canary plan, it's deployed to the canary namespaces (all of them when none are
+// supplied) with Count replicas, the rest of the plan is queued once the canary is verified.
+// It's verified by the metrics check when there's a MetricsURL, otherwise it waits for the rollout to be promoted
+type CanaryOptions struct {
+	Namespaces StringList `json:"namespaces,omitempty"`
+	Count      int        `json:"count,omitempty"`
+	// MetricsURL is requested after the analysis period, the canary is promoted when it returns a 2xx
+	MetricsURL string `json:"metrics_url,omitempty"`
+	// Analysis is how long (in seconds) the canary runs before the metrics check
+	Analysis int `json:"analysis,omitempty"`
+	// Timeout is how long (in seconds) to wait for the rollout to be promoted before it's aborted
+	Timeout int `json:"timeout,omitempty"`
+}
+
+const (
+	DefaultCanaryAnalysis = 5 * time.Minute
+	DefaultCanaryTimeout  = time.Hour
+)
+
+func (co CanaryOptions) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &co,
+		validation.Field(&co.Namespaces, validation.When(co.Count == 0, validation.Required.Error("namespaces or count is required"))),
+		validation.Field(&co.Count, validation.Min(0)),
+		validation.Field(&co.Analysis, validation.Min(0)),
+		validation.Field(&co.Timeout, validation.Min(0)))
+}
+
+func (co CanaryOptions) AnalysisDuration() time.Duration {
+	if co.Analysis == 0 {
+		return DefaultCanaryAnalysis
+	}
+	return time.Duration(co.Analysis) * time.Second
+}
+
+func (co CanaryOptions) TimeoutDuration() time.Duration {
+	if co.Timeout == 0 {
+		return DefaultCanaryTimeout
+	}
+	return time.Duration(co.Timeout) * time.Second
+}
+
+type RolloutState string
+
+const (
+	RolloutStateCanary    RolloutState = "canary"
+	RolloutStateVerifying RolloutState = "verifying"
+	RolloutStateAborting  RolloutState = "aborting"
+	RolloutStatePromoted  RolloutState = "promoted"
+	RolloutStateAborted   RolloutState = "aborted"
+)
+
+// Rollout is a canary plan, its deployments are the canary, promotion and rollback stages in the order they were queued
+type Rollout struct {
+	ID              uuid.UUID      `json:"id"`
+	EnvironmentID   int            `json:"environment_id"`
+	State           RolloutState   `json:"state"`
+	User            string         `json:"user"`
+	Message         string         `json:"message,omitempty"`
+	Canary          *CanaryOptions `json:"canary,omitempty"`
+	VerifyStartedAt *time.Time     `json:"verify_started_at,omitempty"`
+	Deployments     []Deployment   `json:"deployments,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+func ToRollout(r data.Rollout) Rollout {
+	rollout := Rollout{
+		ID:            r.ID,
+		EnvironmentID: r.EnvironmentID,
+		State:         RolloutState(r.State),
+		User:          r.User,
+		Message:       r.Message.String,
+		CreatedAt:     r.CreatedAt.Time,
+		UpdatedAt:     r.UpdatedAt.Time,
+	}
+	if r.VerifyStartedAt.Valid {
+		rollout.VerifyStartedAt = &r.VerifyStartedAt.Time
+	}
+	return rollout
+}
+
+// RolloutOptions are supplied when promoting or aborting a rollout
+type RolloutOptions struct {
+	User   string `json:"user"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (ro RolloutOptions) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &ro,
+		validation.Field(&ro.User, validation.Required))
+}
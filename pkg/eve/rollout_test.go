@@ -0,0 +1,38 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestCanaryOptions_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Error(t, eve.CanaryOptions{}.ValidateWithContext(ctx))
+	assert.Error(t, eve.CanaryOptions{Namespaces: eve.StringList{"canary"}, Count: -1}.ValidateWithContext(ctx))
+	assert.NoError(t, eve.CanaryOptions{Namespaces: eve.StringList{"canary"}}.ValidateWithContext(ctx))
+	assert.NoError(t, eve.CanaryOptions{Count: 1}.ValidateWithContext(ctx))
+}
+
+func TestCanaryOptions_Durations(t *testing.T) {
+	assert.Equal(t, eve.DefaultCanaryAnalysis, eve.CanaryOptions{}.AnalysisDuration())
+	assert.Equal(t, eve.DefaultCanaryTimeout, eve.CanaryOptions{}.TimeoutDuration())
+	assert.Equal(t, 90*time.Second, eve.CanaryOptions{Analysis: 90}.AnalysisDuration())
+}
+
+func TestDeploymentPlanOptions_ValidateCanary(t *testing.T) {
+	ctx := context.Background()
+	options := eve.DeploymentPlanOptions{Environment: "int", User: "test", Type: eve.DeploymentPlanTypeCanary}
+	assert.Error(t, options.ValidateWithContext(ctx))
+
+	options.Canary = &eve.CanaryOptions{Count: 1}
+	assert.NoError(t, options.ValidateWithContext(ctx))
+
+	options.Type = eve.DeploymentPlanTypeApplication
+	assert.Error(t, options.ValidateWithContext(ctx))
+}
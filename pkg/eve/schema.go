@@ -0,0 +1,678 @@
+package eve
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// maxSchemaDepth stops a $ref that refers back to itself without consuming any of the data
+const maxSchemaDepth = 64
+
+// unsupportedSchemaKeywords are the validation keywords that aren't checked, a schema that uses one is rejected rather
+// than reporting data as valid when it isn't
+var unsupportedSchemaKeywords = []string{
+	"$dynamicRef", "$recursiveRef", "additionalItems", "contains", "dependencies", "dependentRequired", "dependentSchemas",
+	"else", "if", "maxContains", "maxProperties", "minContains", "minProperties", "multipleOf", "not", "patternProperties",
+	"prefixItems", "propertyNames", "then", "unevaluatedItems", "unevaluatedProperties", "uniqueItems",
+}
+
+// schemaFormats are the formats that are checked, float and double are any number
+var schemaFormats = map[string]bool{
+	"int-or-string": true,
+	"int32":         true,
+	"int64":         true,
+	"float":         true,
+	"double":        true,
+	"byte":          true,
+	"date-time":     true,
+}
+
+var schemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"integer": true,
+	"number":  true,
+	"boolean": true,
+	"null":    true,
+}
+
+// DefinitionSchema is the JSON Schema a definition type's data is validated with, e.g. one generated from the Kubernetes
+// OpenAPI spec. The validation keywords those schemas use are supported: $ref (to #/definitions or #/$defs), type, enum,
+// const, properties, required, additionalProperties, items, min/maxItems, min/maxLength, pattern, minimum, maximum,
+// exclusiveMinimum/Maximum (as numbers), allOf, anyOf, oneOf, x-kubernetes-int-or-string and the formats in
+// schemaFormats. Check rejects a schema that uses any of the other validation keywords, annotations (description,
+// title, ...) are ignored
+type DefinitionSchema map[string]interface{}
+
+func (s DefinitionSchema) ValidateWithContext(ctx context.Context) error {
+	if err := s.Check(); err != nil {
+		return validation.NewError("400", err.Error())
+	}
+	return nil
+}
+
+// SchemaViolation is a value in a definition's data that doesn't match its schema
+type SchemaViolation struct {
+	Definition string `json:"definition,omitempty"`
+	Path       string `json:"path"`
+	Message    string `json:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if len(path) == 0 {
+		path = "(root)"
+	}
+	if len(v.Definition) > 0 {
+		return fmt.Sprintf("%s: %s: %s", v.Definition, path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// SchemaValidationError is returned when definition data doesn't match its schema, it's rendered as is by the api so
+// each violation is reported
+type SchemaValidationError struct {
+	Code       int               `json:"code"`
+	Message    string            `json:"message"`
+	Violations []SchemaViolation `json:"errors"`
+}
+
+func (e SchemaValidationError) Error() string {
+	var violations []string
+	for _, x := range e.Violations {
+		violations = append(violations, x.String())
+	}
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(violations, ", "))
+}
+
+// NewSchemaValidationError returns nil when there aren't any violations
+func NewSchemaValidationError(message string, violations []SchemaViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return SchemaValidationError{
+		Code:       400,
+		Message:    message,
+		Violations: violations,
+	}
+}
+
+// DefinitionSchemas are the definition type schemas keyed by the merged definition results they apply to
+type DefinitionSchemas map[string][]DefinitionSchema
+
+// Validate validates each of the merged definitions that has a schema
+func (s DefinitionSchemas) Validate(definitions DefinitionResults) error {
+	var violations []SchemaViolation
+	for _, x := range definitions {
+		violations = append(violations, s.validate(x, x.Data)...)
+	}
+	return NewSchemaValidationError("the definitions don't match their schemas", violations)
+}
+
+// ValidateManifests validates the documents the merged definitions were rendered to (see RenderManifests), they're what
+// the scheduler applies so the apiVersion, kind, standard labels, image and environment are validated too
+func (s DefinitionSchemas) ValidateManifests(definitions DefinitionResults, manifests Manifests) error {
+	var violations []SchemaViolation
+	for i, x := range definitions {
+		if i >= len(manifests.Documents) {
+			break
+		}
+		violations = append(violations, s.validate(x, manifests.Documents[i])...)
+	}
+	return NewSchemaValidationError("the manifests don't match their schemas", violations)
+}
+
+func (s DefinitionSchemas) validate(definition DefinitionResult, data map[string]interface{}) []SchemaViolation {
+	key := definition.Key()
+	var violations []SchemaViolation
+	for _, schema := range s[key] {
+		for _, y := range schema.Validate(data) {
+			y.Definition = key
+			violations = append(violations, y)
+		}
+	}
+	return violations
+}
+
+// Validate validates the data of a merged definition, it's what's sent to the scheduler so every keyword is checked
+func (s DefinitionSchema) Validate(data map[string]interface{}) []SchemaViolation {
+	return s.validate(data, false)
+}
+
+// ValidatePartial validates the data of a single definition, which is stacked with others before it's deployed. Required
+// properties aren't checked and templated values are skipped until they're rendered
+func (s DefinitionSchema) ValidatePartial(data map[string]interface{}) []SchemaViolation {
+	return s.validate(data, true)
+}
+
+func (s DefinitionSchema) validate(data map[string]interface{}, partial bool) []SchemaViolation {
+	if len(s) == 0 {
+		return nil
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	v := schemaValidator{root: s, partial: partial}
+	v.validate(s, "", data, 0)
+	return v.violations
+}
+
+// Check makes sure the schema can be used to validate definitions
+func (s DefinitionSchema) Check() error {
+	if len(s) == 0 {
+		return nil
+	}
+	v := schemaValidator{root: s}
+	return v.check(s, "", 0)
+}
+
+type schemaValidator struct {
+	root       map[string]interface{}
+	partial    bool
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) violation(path string, format string, a ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// matches validates the value in a separate validator, it's used for the anyOf and oneOf schemas which can fail
+func (v *schemaValidator) matches(schema interface{}, path string, value interface{}, depth int) bool {
+	sub := schemaValidator{root: v.root, partial: v.partial}
+	sub.validate(schema, path, value, depth)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) resolve(ref string) (map[string]interface{}, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+
+	var current interface{} = v.root
+	for _, x := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		x = strings.ReplaceAll(strings.ReplaceAll(x, "~1", "/"), "~0", "~")
+		if current, ok = object[x]; !ok {
+			return nil, false
+		}
+	}
+
+	resolved, ok := current.(map[string]interface{})
+	return resolved, ok
+}
+
+func (v *schemaValidator) validate(s interface{}, path string, value interface{}, depth int) {
+	schema, ok := schemaObject(s)
+	if !ok {
+		return
+	}
+
+	if depth > maxSchemaDepth {
+		v.violation(path, "the schema is nested too deeply")
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, found := v.resolve(ref)
+		if !found {
+			v.violation(path, "the schema reference: %s doesn't exist", ref)
+			return
+		}
+		v.validate(resolved, path, value, depth+1)
+		return
+	}
+
+	if v.partial && isTemplated(value) {
+		return
+	}
+
+	if isIntOrString(schema) {
+		if _, isString := value.(string); !isString && !isInteger(value) {
+			v.violation(path, "must be an integer or a string")
+		}
+		return
+	}
+
+	if types := schemaTypeList(schema["type"]); len(types) > 0 && !matchesAnyType(types, value) {
+		v.violation(path, "must be %s, not %s", strings.Join(types, " or "), valueType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		v.violation(path, "must be one of %s", formatValues(enum))
+	}
+
+	if c, ok := schema["const"]; ok && !equalValues(c, value) {
+		v.violation(path, "must be %s", formatValue(c))
+	}
+
+	switch x := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, path, x, depth)
+	case []interface{}:
+		v.validateArray(schema, path, x, depth)
+	case string:
+		v.validateString(schema, path, x)
+	default:
+		if n, ok := toNumber(value); ok {
+			v.validateNumber(schema, path, n)
+		}
+	}
+
+	for _, x := range schemaList(schema["allOf"]) {
+		v.validate(x, path, value, depth+1)
+	}
+
+	if anyOf := schemaList(schema["anyOf"]); len(anyOf) > 0 {
+		matched := false
+		for _, x := range anyOf {
+			if v.matches(x, path, value, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.violation(path, "must match at least one of the anyOf schemas")
+		}
+	}
+
+	if oneOf := schemaList(schema["oneOf"]); len(oneOf) > 0 {
+		matched := 0
+		for _, x := range oneOf {
+			if v.matches(x, path, value, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.violation(path, "must match exactly one of the oneOf schemas, it matches %d", matched)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(schema map[string]interface{}, path string, value map[string]interface{}, depth int) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if !v.partial {
+		required, _ := schema["required"].([]interface{})
+		for _, x := range required {
+			name, _ := x.(string)
+			if _, ok := value[name]; !ok {
+				v.violation(joinPath(path, name), "is required")
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(value) {
+		if property, ok := properties[k]; ok {
+			v.validate(property, joinPath(path, k), value[k], depth+1)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.violation(joinPath(path, k), "isn't an allowed property")
+			}
+		case map[string]interface{}:
+			v.validate(additional, joinPath(path, k), value[k], depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(schema map[string]interface{}, path string, value []interface{}, depth int) {
+	if n, ok := toNumber(schema["minItems"]); ok && float64(len(value)) < n {
+		v.violation(path, "must have at least %v items", n)
+	}
+	if n, ok := toNumber(schema["maxItems"]); ok && float64(len(value)) > n {
+		v.violation(path, "must have at most %v items", n)
+	}
+	if items, ok := schemaObject(schema["items"]); ok {
+		for i, x := range value {
+			v.validate(items, fmt.Sprintf("%s[%d]", path, i), x, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(schema map[string]interface{}, path string, value string) {
+	length := float64(utf8.RuneCountInString(value))
+	if n, ok := toNumber(schema["minLength"]); ok && length < n {
+		v.violation(path, "must be at least %v characters", n)
+	}
+	if n, ok := toNumber(schema["maxLength"]); ok && length > n {
+		v.violation(path, "must be at most %v characters", n)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+			v.violation(path, "must match the pattern: %s", pattern)
+		}
+	}
+	switch schema["format"] {
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			v.violation(path, "must be base64 encoded")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			v.violation(path, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(schema map[string]interface{}, path string, value float64) {
+	if n, ok := toNumber(schema["minimum"]); ok && value < n {
+		v.violation(path, "must be greater than or equal to %v", n)
+	}
+	if n, ok := toNumber(schema["maximum"]); ok && value > n {
+		v.violation(path, "must be less than or equal to %v", n)
+	}
+	if n, ok := toNumber(schema["exclusiveMinimum"]); ok && value <= n {
+		v.violation(path, "must be greater than %v", n)
+	}
+	if n, ok := toNumber(schema["exclusiveMaximum"]); ok && value >= n {
+		v.violation(path, "must be less than %v", n)
+	}
+	switch schema["format"] {
+	case "int32":
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			v.violation(path, "must fit in 32 bits")
+		}
+	case "int64":
+		if value < math.MinInt64 || value > math.MaxInt64 {
+			v.violation(path, "must fit in 64 bits")
+		}
+	}
+}
+
+func (v *schemaValidator) check(s interface{}, path string, depth int) error {
+	schema, ok := schemaObject(s)
+	if !ok {
+		return fmt.Errorf("schema: %s must be an object", schemaPath(path))
+	}
+	if depth > maxSchemaDepth {
+		return fmt.Errorf("schema: %s is nested too deeply", schemaPath(path))
+	}
+
+	for _, k := range unsupportedSchemaKeywords {
+		if _, ok := schema[k]; ok {
+			return fmt.Errorf("schema: %s, %s isn't supported", schemaPath(path), k)
+		}
+	}
+
+	for _, k := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		if exclusive, isBool := schema[k].(bool); isBool && exclusive {
+			return fmt.Errorf("schema: %s, %s must be a number", schemaPath(path), k)
+		}
+	}
+
+	if format, ok := schema["format"]; ok {
+		formatString, _ := format.(string)
+		if !schemaFormats[formatString] {
+			return fmt.Errorf("schema: %s, format: %v isn't supported", schemaPath(path), format)
+		}
+	}
+
+	if ref, ok := schema["$ref"]; ok {
+		refString, isString := ref.(string)
+		if !isString {
+			return fmt.Errorf("schema: %s, $ref must be a string", schemaPath(path))
+		}
+		if _, found := v.resolve(refString); !found {
+			return fmt.Errorf("schema: %s, the reference: %s doesn't exist", schemaPath(path), refString)
+		}
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := schemaTypeList(t)
+		if len(types) == 0 {
+			return fmt.Errorf("schema: %s, type must be a string or a list of strings", schemaPath(path))
+		}
+		for _, x := range types {
+			if !schemaTypes[x] {
+				return fmt.Errorf("schema: %s, unknown type: %s", schemaPath(path), x)
+			}
+		}
+	}
+
+	if pattern, ok := schema["pattern"]; ok {
+		patternString, isString := pattern.(string)
+		if !isString {
+			return fmt.Errorf("schema: %s, pattern must be a string", schemaPath(path))
+		}
+		if _, err := regexp.Compile(patternString); err != nil {
+			return fmt.Errorf("schema: %s, invalid pattern: %s", schemaPath(path), err)
+		}
+	}
+
+	if required, ok := schema["required"]; ok {
+		list, isList := required.([]interface{})
+		if !isList {
+			return fmt.Errorf("schema: %s, required must be a list of strings", schemaPath(path))
+		}
+		for _, x := range list {
+			if _, isString := x.(string); !isString {
+				return fmt.Errorf("schema: %s, required must be a list of strings", schemaPath(path))
+			}
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if _, isList := enum.([]interface{}); !isList {
+			return fmt.Errorf("schema: %s, enum must be a list", schemaPath(path))
+		}
+	}
+
+	for _, k := range []string{"properties", "definitions", "$defs"} {
+		children, ok := schema[k]
+		if !ok {
+			continue
+		}
+		object, isObject := children.(map[string]interface{})
+		if !isObject {
+			return fmt.Errorf("schema: %s, %s must be an object", schemaPath(path), k)
+		}
+		for _, name := range sortedKeys(object) {
+			if err := v.check(object[name], joinPath(joinPath(path, k), name), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		if err := v.check(items, joinPath(path, "items"), depth+1); err != nil {
+			return err
+		}
+	}
+
+	if additional, ok := schema["additionalProperties"]; ok {
+		if _, isBool := additional.(bool); !isBool {
+			if err := v.check(additional, joinPath(path, "additionalProperties"), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, k := range []string{"allOf", "anyOf", "oneOf"} {
+		list, ok := schema[k]
+		if !ok {
+			continue
+		}
+		schemas, isList := list.([]interface{})
+		if !isList || len(schemas) == 0 {
+			return fmt.Errorf("schema: %s, %s must be a list of schemas", schemaPath(path), k)
+		}
+		for i, x := range schemas {
+			if err := v.check(x, fmt.Sprintf("%s[%d]", joinPath(path, k), i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func schemaPath(path string) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+	return path
+}
+
+func schemaObject(s interface{}) (map[string]interface{}, bool) {
+	switch x := s.(type) {
+	case DefinitionSchema:
+		return x, true
+	case map[string]interface{}:
+		return x, true
+	default:
+		return nil, false
+	}
+}
+
+func schemaList(s interface{}) []interface{} {
+	list, _ := s.([]interface{})
+	return list
+}
+
+func schemaTypeList(t interface{}) []string {
+	switch x := t.(type) {
+	case string:
+		return []string{x}
+	case []interface{}:
+		var types []string
+		for _, y := range x {
+			s, ok := y.(string)
+			if !ok {
+				return nil
+			}
+			types = append(types, s)
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func matchesAnyType(types []string, value interface{}) bool {
+	for _, x := range types {
+		if valueType(value) == x || (x == "number" && valueType(value) == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if isInteger(value) {
+		return "integer"
+	}
+	if _, ok := toNumber(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func isInteger(value interface{}) bool {
+	n, ok := toNumber(value)
+	return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch x := value.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isIntOrString is true for the schemas of values like a port or a quantity, the Kubernetes OpenAPI spec describes
+// them with format: int-or-string (and a type of string) and its CRDs with x-kubernetes-int-or-string
+func isIntOrString(schema map[string]interface{}) bool {
+	if intOrString, _ := schema["x-kubernetes-int-or-string"].(bool); intOrString {
+		return true
+	}
+	format, _ := schema["format"].(string)
+	return format == "int-or-string"
+}
+
+func isTemplated(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.Contains(s, templateLeftDelim)
+}
+
+func equalValues(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		y, isNumber := toNumber(b)
+		return isNumber && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, x := range list {
+		if equalValues(x, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
+
+func formatValues(list []interface{}) string {
+	var values []string
+	for _, x := range list {
+		values = append(values, formatValue(x))
+	}
+	return strings.Join(values, ", ")
+}
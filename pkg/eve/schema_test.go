@@ -0,0 +1,220 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func deploymentSchema() eve.DefinitionSchema {
+	return eve.DefinitionSchema{
+		"type":     "object",
+		"required": []interface{}{"spec"},
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{"$ref": "#/definitions/DeploymentSpec"},
+		},
+		"definitions": map[string]interface{}{
+			"DeploymentSpec": map[string]interface{}{
+				"type":                 "object",
+				"required":             []interface{}{"selector"},
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"replicas": map[string]interface{}{"type": "integer", "minimum": float64(0)},
+					"selector": map[string]interface{}{"type": "object"},
+					"strategy": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"type":           map[string]interface{}{"type": "string", "enum": []interface{}{"Recreate", "RollingUpdate"}},
+							"maxUnavailable": map[string]interface{}{"x-kubernetes-int-or-string": true},
+						},
+					},
+					"args": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"type": "string", "pattern": "^--"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDefinitionSchema_Validate(t *testing.T) {
+	schema := deploymentSchema()
+
+	assert.Empty(t, schema.Validate(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"selector": map[string]interface{}{},
+			"strategy": map[string]interface{}{"type": "Recreate", "maxUnavailable": "25%"},
+			"args":     []interface{}{"--debug"},
+		},
+	}))
+
+	assert.Equal(t, []eve.SchemaViolation{
+		{Path: "spec.selector", Message: "is required"},
+		{Path: "spec.args[0]", Message: "must match the pattern: ^--"},
+		{Path: "spec.extra", Message: "isn't an allowed property"},
+		{Path: "spec.replicas", Message: "must be integer, not number"},
+		{Path: "spec.strategy.maxUnavailable", Message: "must be an integer or a string"},
+		{Path: "spec.strategy.type", Message: `must be one of "Recreate", "RollingUpdate"`},
+	}, schema.Validate(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": 1.5,
+			"extra":    true,
+			"strategy": map[string]interface{}{"type": "Blue", "maxUnavailable": true},
+			"args":     []interface{}{"debug"},
+		},
+	}))
+
+	assert.Equal(t, []eve.SchemaViolation{{Path: "spec", Message: "is required"}}, schema.Validate(nil))
+	assert.Empty(t, eve.DefinitionSchema{}.Validate(map[string]interface{}{"anything": true}))
+}
+
+func TestDefinitionSchema_ValidatePartial(t *testing.T) {
+	schema := deploymentSchema()
+
+	// a stacked definition doesn't need the required properties and its templated values are rendered later
+	assert.Empty(t, schema.ValidatePartial(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": float64(3),
+			"args":     []interface{}{"${{ .Metadata.ARGS }}"},
+		},
+	}))
+
+	assert.Equal(t, []eve.SchemaViolation{{Path: "spec.replicas", Message: "must be greater than or equal to 0"}},
+		schema.ValidatePartial(map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(-1)}}))
+}
+
+func TestDefinitionSchema_Check(t *testing.T) {
+	require.NoError(t, deploymentSchema().Check())
+	require.NoError(t, eve.DefinitionSchema{}.Check())
+
+	assert.EqualError(t, eve.DefinitionSchema{"type": "map"}.Check(), "schema: (root), unknown type: map")
+	assert.EqualError(t, eve.DefinitionSchema{"$ref": "#/definitions/Nope"}.Check(), "schema: (root), the reference: #/definitions/Nope doesn't exist")
+	assert.EqualError(t, eve.DefinitionSchema{
+		"properties": map[string]interface{}{"name": map[string]interface{}{"pattern": "("}},
+	}.Check(), "schema: properties.name, invalid pattern: error parsing regexp: missing closing ): `(`")
+
+	err := eve.DefinitionType{Schema: eve.DefinitionSchema{"type": true}}.ValidateWithContext(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "type must be a string or a list of strings")
+}
+
+func TestDefinitionSchemas_Validate(t *testing.T) {
+	definitionType := eve.DefinitionType{Class: "apps", Version: "v1", Kind: "Deployment", DefinitionOrder: "main", Schema: deploymentSchema()}
+	schemas := eve.DefinitionSchemas{definitionType.ResultKey(): {definitionType.Schema}}
+
+	definitions := eve.DefinitionResults{
+		eve.DefaultServiceResourceDef(),
+		eve.DefaultDeploymentResourceDef(),
+	}
+
+	err := schemas.Validate(definitions)
+	require.Error(t, err)
+
+	var schemaErr eve.SchemaValidationError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, 400, schemaErr.Code)
+	assert.Equal(t, []eve.SchemaViolation{
+		{Definition: "main.apps.v1.Deployment", Path: "spec.selector", Message: "is required"},
+	}, schemaErr.Violations)
+	assert.Equal(t, "the definitions don't match their schemas: main.apps.v1.Deployment: spec.selector: is required", err.Error())
+
+	definitions[1].Data["spec"] = map[string]interface{}{"selector": map[string]interface{}{}}
+	assert.NoError(t, schemas.Validate(definitions))
+}
+
+func TestDefinitionSchemas_ValidateManifests(t *testing.T) {
+	definitionType := eve.DefinitionType{Class: "apps", Version: "v1", Kind: "Deployment", DefinitionOrder: "main", Schema: eve.DefinitionSchema{
+		"type":     "object",
+		"required": []interface{}{"apiVersion", "kind", "metadata"},
+		"properties": map[string]interface{}{
+			"kind": map[string]interface{}{"const": "Deployment"},
+			"spec": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"replicas": map[string]interface{}{"type": "integer", "maximum": float64(1)},
+				},
+			},
+		},
+	}}
+	schemas := eve.DefinitionSchemas{definitionType.ResultKey(): {definitionType.Schema}}
+
+	definitions := eve.DefinitionResults{
+		{Order: "main", Class: "apps", Version: "v1", Kind: "Deployment", Data: map[string]interface{}{
+			"spec": map[string]interface{}{"selector": map[string]interface{}{}},
+		}},
+	}
+
+	// the definition's data doesn't have what's added when it's rendered
+	require.Error(t, schemas.Validate(definitions))
+
+	svc := manifestService()
+	manifests, err := eve.RenderManifests(svc, nil, definitions, "int-app")
+	require.NoError(t, err)
+
+	err = schemas.ValidateManifests(definitions, manifests)
+	var schemaErr eve.SchemaValidationError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, []eve.SchemaViolation{
+		{Definition: "main.apps.v1.Deployment", Path: "spec.replicas", Message: "must be less than or equal to 1"},
+	}, schemaErr.Violations)
+
+	svc.Count = 1
+	manifests, err = eve.RenderManifests(svc, nil, definitions, "int-app")
+	require.NoError(t, err)
+	assert.NoError(t, schemas.ValidateManifests(definitions, manifests))
+}
+
+func TestDefinitionSchema_ValidateIntOrStringFormat(t *testing.T) {
+	schema := eve.DefinitionSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"port": map[string]interface{}{"type": "string", "format": "int-or-string"},
+		},
+	}
+
+	assert.Empty(t, schema.Validate(map[string]interface{}{"port": float64(8080)}))
+	assert.Empty(t, schema.Validate(map[string]interface{}{"port": "http"}))
+	assert.Equal(t, []eve.SchemaViolation{
+		{Path: "port", Message: "must be an integer or a string"},
+	}, schema.Validate(map[string]interface{}{"port": true}))
+}
+
+func TestDefinitionSchema_CheckUnsupported(t *testing.T) {
+	assert.EqualError(t, eve.DefinitionSchema{"not": map[string]interface{}{"type": "string"}}.Check(), "schema: (root), not isn't supported")
+	assert.EqualError(t, eve.DefinitionSchema{
+		"properties": map[string]interface{}{"labels": map[string]interface{}{"type": "object", "minProperties": 1}},
+	}.Check(), "schema: properties.labels, minProperties isn't supported")
+	assert.EqualError(t, eve.DefinitionSchema{
+		"items": map[string]interface{}{"type": "array", "uniqueItems": true},
+	}.Check(), "schema: items, uniqueItems isn't supported")
+	assert.EqualError(t, eve.DefinitionSchema{"type": "number", "minimum": 0, "exclusiveMinimum": true}.Check(), "schema: (root), exclusiveMinimum must be a number")
+	assert.EqualError(t, eve.DefinitionSchema{"type": "string", "format": "email"}.Check(), "schema: (root), format: email isn't supported")
+
+	require.NoError(t, eve.DefinitionSchema{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": false}.Check())
+	require.NoError(t, eve.DefinitionSchema{"type": "integer", "format": "int32", "description": "replicas"}.Check())
+}
+
+func TestDefinitionSchema_ValidateFormats(t *testing.T) {
+	schema := eve.DefinitionSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": "integer", "format": "int32"},
+			"data":     map[string]interface{}{"type": "string", "format": "byte"},
+			"since":    map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+	}
+	require.NoError(t, schema.Check())
+
+	assert.Empty(t, schema.Validate(map[string]interface{}{"replicas": float64(3), "data": "ZXZl", "since": "2021-04-01T10:00:00Z"}))
+	assert.Equal(t, []eve.SchemaViolation{
+		{Path: "data", Message: "must be base64 encoded"},
+		{Path: "replicas", Message: "must fit in 32 bits"},
+		{Path: "since", Message: "must be an RFC 3339 date-time"},
+	}, schema.Validate(map[string]interface{}{"replicas": float64(1 << 40), "data": "not base64!", "since": "yesterday"}))
+}
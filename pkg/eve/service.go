@@ -1,6 +1,11 @@
 package eve
 
-import "time"
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
 
 type Service struct {
 	ID              int       `json:"id"`
@@ -16,3 +21,8 @@ type Service struct {
 	Count           int       `json:"count"`
 	ExplicitDeploy  bool      `json:"explicit_deploy"`
 }
+
+func (s Service) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &s,
+		validation.Field(&s.OverrideVersion, validation.By(validVersionConstraint)))
+}
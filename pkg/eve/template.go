@@ -0,0 +1,152 @@
+package eve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// templateLeftDelim and templateRightDelim mark the templated parts of a value. They're distinct from the plain "{{ }}"
+// so the values that are templates for something else, e.g. helm charts or prometheus rules, are left as they are
+const (
+	templateLeftDelim  = "${{"
+	templateRightDelim = "}}"
+)
+
+// TemplateValues are the variables a metadata or definition value can use, e.g. "${{ .Name }}:${{ .Version }}" or
+// "${{ .Metadata.LOG_LEVEL }}". Metadata is the service or job's metadata before it's rendered, a key that doesn't exist
+// is an error
+type TemplateValues struct {
+	Name        string
+	Artifact    string
+	Namespace   string
+	Environment string
+	Cluster     string
+	Version     string
+	ImageTag    string
+	Metadata    map[string]interface{}
+}
+
+// TemplateError is returned when a value can't be rendered, Path is the dot separated keys leading to it
+type TemplateError struct {
+	Path string
+	Err  error
+}
+
+func (e TemplateError) Error() string {
+	return fmt.Sprintf("template: %s: %s", e.Path, e.Err)
+}
+
+// NewTemplateValues returns the variables for the artifact, the version is the one being deployed
+func NewTemplateValues(name string, a *DeployArtifact, ns *NamespaceRequest, environment string, metadata MetadataField) TemplateValues {
+	return TemplateValues{
+		Name:        name,
+		Artifact:    a.ArtifactName,
+		Namespace:   ns.Name,
+		Environment: environment,
+		Cluster:     ns.ClusterName,
+		Version:     a.AvailableVersion,
+		ImageTag:    a.EvalImageTag(),
+		Metadata:    metadata,
+	}
+}
+
+// RenderMetadata returns a copy of the metadata with its templated values rendered
+func (v TemplateValues) RenderMetadata(metadata MetadataField) (MetadataField, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	rendered, err := v.render("", map[string]interface{}(metadata))
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]interface{}), nil
+}
+
+// RenderDefinitions returns a copy of the definitions with the templated values in their data rendered
+func (v TemplateValues) RenderDefinitions(definitions DefinitionResults) (DefinitionResults, error) {
+	var list DefinitionResults
+	for _, x := range definitions {
+		data, err := v.render(x.Key(), x.Data)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			x.Data = data.(map[string]interface{})
+		}
+		list = append(list, x)
+	}
+	return list, nil
+}
+
+// RenderDefinitionBytes renders marshalled definition results the way they're sent to the scheduler
+func (v TemplateValues) RenderDefinitionBytes(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, nil
+	}
+
+	var definitions DefinitionResults
+	if err := json.Unmarshal(b, &definitions); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	rendered, err := v.RenderDefinitions(definitions)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(rendered)
+}
+
+func (v TemplateValues) render(path string, value interface{}) (interface{}, error) {
+	switch x := value.(type) {
+	case map[string]interface{}:
+		if x == nil {
+			return x, nil
+		}
+		rendered := make(map[string]interface{}, len(x))
+		for k, y := range x {
+			r, err := v.render(joinPath(path, k), y)
+			if err != nil {
+				return nil, err
+			}
+			rendered[k] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(x))
+		for i, y := range x {
+			r, err := v.render(fmt.Sprintf("%s[%d]", path, i), y)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	case string:
+		return v.renderString(path, x)
+	default:
+		return value, nil
+	}
+}
+
+func (v TemplateValues) renderString(path string, value string) (string, error) {
+	if !strings.Contains(value, templateLeftDelim) {
+		return value, nil
+	}
+
+	t, err := template.New(path).Delims(templateLeftDelim, templateRightDelim).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", TemplateError{Path: path, Err: err}
+	}
+
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, v); err != nil {
+		return "", TemplateError{Path: path, Err: err}
+	}
+	return buf.String(), nil
+}
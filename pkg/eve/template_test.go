@@ -0,0 +1,100 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func templateValues() eve.TemplateValues {
+	return eve.NewTemplateValues("api",
+		&eve.DeployArtifact{ArtifactName: "unanet-api", AvailableVersion: "1.2.3"},
+		&eve.NamespaceRequest{Name: "int-current", ClusterName: "int-cluster"},
+		"int",
+		eve.MetadataField{"LOG_LEVEL": "debug", "URL": "https://${{ .Name }}.${{ .Environment }}.example.com"},
+	)
+}
+
+func TestTemplateValues_RenderMetadata(t *testing.T) {
+	values := templateValues()
+
+	metadata, err := values.RenderMetadata(values.Metadata)
+	require.NoError(t, err)
+	assert.Equal(t, eve.MetadataField{"LOG_LEVEL": "debug", "URL": "https://api.int.example.com"}, metadata)
+
+	_, err = values.RenderMetadata(eve.MetadataField{"MISSING": "${{ .Metadata.NOPE }}"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template: MISSING:")
+}
+
+func TestTemplateValues_RenderDefinitions(t *testing.T) {
+	values := templateValues()
+
+	definitions := eve.DefinitionResults{
+		{
+			Class:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+			Order:   "main",
+			Data: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"app":     "${{ .Name }}",
+						"cluster": "${{ .Cluster }}",
+					},
+				},
+				"spec": map[string]interface{}{
+					"replicas": float64(2),
+					"args":     []interface{}{"--log-level=${{ .Metadata.LOG_LEVEL }}", "--version=${{ .Version }}"},
+				},
+			},
+		},
+	}
+
+	rendered, err := values.RenderDefinitions(definitions)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				"app":     "api",
+				"cluster": "int-cluster",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"args":     []interface{}{"--log-level=debug", "--version=1.2.3"},
+		},
+	}, rendered[0].Data)
+
+	// the definitions passed in aren't changed
+	assert.Equal(t, "${{ .Name }}", definitions[0].Data["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["app"])
+
+	definitions[0].Data["spec"] = map[string]interface{}{"args": []interface{}{"${{ .Nope }}"}}
+	_, err = values.RenderDefinitions(definitions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.args[0]")
+}
+
+func TestTemplateValues_RenderLiteralTemplates(t *testing.T) {
+	values := templateValues()
+
+	// values that are templates for something else aren't rendered
+	metadata := eve.MetadataField{
+		"ALERT":   "{{ $labels.instance }} is down",
+		"VALUES":  "{{ .Values.image.tag }}",
+		"MIXED":   "${{ .Name }}: {{ $labels.instance }}",
+		"COMMAND": "echo ${HOME} {{",
+	}
+
+	rendered, err := values.RenderMetadata(metadata)
+	require.NoError(t, err)
+	assert.Equal(t, eve.MetadataField{
+		"ALERT":   "{{ $labels.instance }} is down",
+		"VALUES":  "{{ .Values.image.tag }}",
+		"MIXED":   "api: {{ $labels.instance }}",
+		"COMMAND": "echo ${HOME} {{",
+	}, rendered)
+}
@@ -0,0 +1,47 @@
+package eve
+
+import (
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"github.com/unanet/eve/pkg/version"
+)
+
+// MatchesVersion is true when the available version satisfies the requested version constraint. Versions that
+// can't be parsed, like a docker tag, fall back to a prefix match
+func MatchesVersion(available string, requested string) bool {
+	c, err := version.ParseConstraint(requested)
+	if err != nil {
+		return strings.HasPrefix(available, requested)
+	}
+
+	if c.Any() {
+		return true
+	}
+
+	v, err := version.Parse(available)
+	if err != nil {
+		return strings.HasPrefix(available, requested)
+	}
+
+	return c.Check(*v)
+}
+
+// constraintOperators are what make a requested version a constraint, anything else (like a branch or a tag prefix)
+// that can't be parsed is matched by its prefix
+var constraintOperators = regexp.MustCompile(`[<>=!~^|,*\s]`)
+
+// validVersionConstraint only rejects the requested versions that use the constraint operators and can't be parsed
+func validVersionConstraint(value interface{}) error {
+	requested := value.(string)
+	if !constraintOperators.MatchString(requested) {
+		return nil
+	}
+
+	if _, err := version.ParseConstraint(requested); err != nil {
+		return validation.NewError("validation_version", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package eve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/unanet/eve/pkg/eve"
+)
+
+func TestArtifactDefinition_ValidateRequestedVersion(t *testing.T) {
+	for _, requested := range []string{"", "1.2", "^1.2.3", ">=1.0 <2.0", "feature-login", "release/2021.04", "v1.2"} {
+		assert.NoError(t, eve.ArtifactDefinition{Name: "api", RequestedVersion: requested}.ValidateWithContext(context.TODO()), requested)
+	}
+
+	for _, requested := range []string{">>1", "^feature", "1.2 || "} {
+		assert.Error(t, eve.ArtifactDefinition{Name: "api", RequestedVersion: requested}.ValidateWithContext(context.TODO()), requested)
+	}
+}
+
+func TestMatchesVersion_Prefix(t *testing.T) {
+	assert.True(t, eve.MatchesVersion("feature-login-42", "feature-login"))
+	assert.True(t, eve.MatchesVersion("1.2.3", "1.2"))
+	assert.False(t, eve.MatchesVersion("1.20.0", "1.2"))
+	assert.False(t, eve.MatchesVersion("release-1", "feature-login"))
+}
@@ -0,0 +1,83 @@
+package eve
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// WebhookSignatureHeader is "sha256=" followed by the hex encoded HMAC-SHA256 of "{timestamp}.{body}" using the webhook's secret
+	WebhookSignatureHeader = "X-Eve-Signature"
+	WebhookTimestampHeader = "X-Eve-Timestamp"
+	WebhookDeliveryHeader  = "X-Eve-Delivery"
+	WebhookEventHeader     = "X-Eve-Event"
+
+	// WebhookEventCallback is the event for deliveries to a plan's callback_url
+	WebhookEventCallback = "callback"
+)
+
+type Webhook struct {
+	ID            int    `json:"id"`
+	EnvironmentID int    `json:"environment_id"`
+	NamespaceID   int    `json:"namespace_id,omitempty"`
+	URL           string `json:"url"`
+	// Secret is only returned when the webhook is created
+	Secret    string    `json:"secret,omitempty"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w Webhook) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &w,
+		validation.Field(&w.EnvironmentID, validation.Required),
+		validation.Field(&w.URL, validation.Required, is.URL))
+}
+
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryStatePending   WebhookDeliveryState = "pending"
+	WebhookDeliveryStateDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryStateFailed    WebhookDeliveryState = "failed"
+)
+
+// WebhookDelivery is a single event sent to a webhook or callback url, the Payload is only returned
+// when a single delivery is requested
+type WebhookDelivery struct {
+	ID               uuid.UUID                `json:"id"`
+	WebhookID        int                      `json:"webhook_id,omitempty"`
+	DeploymentID     *uuid.UUID               `json:"deployment_id,omitempty"`
+	URL              string                   `json:"url"`
+	Event            string                   `json:"event"`
+	Payload          map[string]interface{}   `json:"payload,omitempty"`
+	State            WebhookDeliveryState     `json:"state"`
+	Attempts         int                      `json:"attempts"`
+	NextAttemptAt    *time.Time               `json:"next_attempt_at,omitempty"`
+	LastResponseCode int                      `json:"last_response_code,omitempty"`
+	LastError        string                   `json:"last_error,omitempty"`
+	DeliveredAt      *time.Time               `json:"delivered_at,omitempty"`
+	AttemptLog       []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+	CreatedAt        time.Time                `json:"created_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	Attempt      int       `json:"attempt"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int       `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryQuery holds the filters used to search webhook deliveries
+type WebhookDeliveryQuery struct {
+	DeploymentID string
+	WebhookID    string
+	State        string
+	Limit        int
+}
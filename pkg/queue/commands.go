@@ -5,6 +5,7 @@ const (
 	CommandScheduleDeployment string = "api-schedule-deployment"
 	CommandUpdateDeployment   string = "api-update-deployment"
 	CommandCallbackMessage    string = "api-callback-message"
+	CommandCancelDeployment   string = "api-cancel-deployment"
 )
 
 // Scheduler Queue Commands
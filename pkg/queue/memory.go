@@ -0,0 +1,234 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/log"
+)
+
+const (
+	// dedupeInterval matches the SQS FIFO deduplication interval
+	dedupeInterval = 5 * time.Minute
+)
+
+type memMessage struct {
+	M
+	reqID        string
+	visibleAt    time.Time
+	receiveCount int
+}
+
+type memDedupe struct {
+	messageID string
+	sentAt    time.Time
+}
+
+// MemBroker holds all of the in-process queues by url so that messages sent from one worker can be received by another
+type MemBroker struct {
+	mutex  sync.Mutex
+	queues map[string]*MemQ
+}
+
+func NewMemBroker() *MemBroker {
+	return &MemBroker{
+		queues: make(map[string]*MemQ),
+	}
+}
+
+// Queue returns the in-process queue for the url, creating it with the supplied config if it doesn't exist
+func (b *MemBroker) Queue(config Config) *MemQ {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if q, ok := b.queues[config.QueueURL]; ok {
+		return q
+	}
+	q := NewMemQ(config)
+	b.queues[config.QueueURL] = q
+	return q
+}
+
+// MemProvider returns a Provider that resolves queues from the broker
+func MemProvider(b *MemBroker) Provider {
+	return func(qUrl string) Queue {
+		return b.Queue(Config{
+			QueueURL: qUrl,
+		})
+	}
+}
+
+// MemQ is an in-process Queue implementation, it's intended for integration tests and small installs that don't
+// have access to SQS. Messages are not persisted and are lost when the process exits.
+type MemQ struct {
+	c        Config
+	log      *zap.Logger
+	id       uint64
+	mutex    sync.Mutex
+	messages []*memMessage
+	dedupe   map[string]memDedupe
+	notify   chan struct{}
+}
+
+func NewMemQ(config Config) *MemQ {
+	qID := atomic.AddUint64(&queueID, 1)
+	return &MemQ{
+		id:     qID,
+		c:      config,
+		dedupe: make(map[string]memDedupe),
+		notify: make(chan struct{}),
+		log:    log.Logger.With(zap.String("queue_url", config.QueueURL), zap.Uint64("internal_queue_id", qID)),
+	}
+}
+
+func (q *MemQ) logWith(ctx context.Context) *zap.Logger {
+	return q.log.With(zap.String("req_id", log.GetReqID(ctx)))
+}
+
+// signal wakes up any receivers waiting on a message, it must be called with the lock held
+func (q *MemQ) signal() {
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+func (q *MemQ) Message(ctx context.Context, m *M) error {
+	if len(m.Command) == 0 {
+		m.Command = "empty"
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now().UTC()
+	if len(m.DedupeID) > 0 {
+		if d, ok := q.dedupe[m.DedupeID]; ok && now.Sub(d.sentAt) < dedupeInterval {
+			m.MessageID = d.messageID
+			q.logWith(ctx).Info("in-process message deduplicated",
+				zap.Any("id", m.ID),
+				zap.String("dedupe_id", m.DedupeID),
+				zap.String("message_id", m.MessageID),
+			)
+			return nil
+		}
+	}
+
+	m.MessageID = uuid.NewV4().String()
+	qm := memMessage{
+		M:         *m,
+		reqID:     log.GetReqID(ctx),
+		visibleAt: now,
+	}
+	if len(qm.Body) == 0 {
+		qm.Body = []byte(m.ID.String())
+	}
+	q.messages = append(q.messages, &qm)
+	if len(m.DedupeID) > 0 {
+		q.dedupe[m.DedupeID] = memDedupe{messageID: m.MessageID, sentAt: now}
+	}
+	q.signal()
+
+	q.logWith(ctx).Info("in-process message sent",
+		zap.Any("id", m.ID),
+		zap.String("message_id", m.MessageID),
+	)
+	return nil
+}
+
+// receive returns the messages that are currently available, only the oldest message in a group is eligible and
+// the whole group is blocked while that message is in flight
+func (q *MemQ) receive(ctx context.Context) []*MContext {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now().UTC()
+	for k, v := range q.dedupe {
+		if now.Sub(v.sentAt) >= dedupeInterval {
+			delete(q.dedupe, k)
+		}
+	}
+
+	max := int(q.c.MaxNumberOfMessage)
+	if max <= 0 {
+		max = 1
+	}
+
+	var returnMs []*MContext
+	groups := make(map[string]bool)
+	for _, x := range q.messages {
+		if len(returnMs) >= max {
+			break
+		}
+		if groups[x.GroupID] {
+			continue
+		}
+		groups[x.GroupID] = true
+		if x.visibleAt.After(now) {
+			continue
+		}
+
+		x.receiveCount++
+		x.ReceiptHandle = uuid.NewV4().String()
+		x.visibleAt = now.Add(time.Duration(q.c.VisibilityTimeout) * time.Second)
+
+		mctx := context.WithValue(ctx, log.RequestIDKey, x.reqID)
+		returnMs = append(returnMs, &MContext{
+			M:   x.M,
+			Ctx: mctx,
+		})
+		q.logWith(mctx).Info("in-process message received",
+			zap.Any("id", x.ID),
+			zap.String("message_id", x.MessageID),
+		)
+	}
+
+	return returnMs
+}
+
+func (q *MemQ) Receive(ctx context.Context) ([]*MContext, error) {
+	wait := time.NewTimer(time.Duration(q.c.WaitTimeSecond) * time.Second)
+	defer wait.Stop()
+	for {
+		q.mutex.Lock()
+		notify := q.notify
+		q.mutex.Unlock()
+
+		if ms := q.receive(ctx); len(ms) > 0 {
+			return ms, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-wait.C:
+			return nil, nil
+		case <-notify:
+		case <-time.After(time.Second):
+			// in flight messages can become visible again once the visibility timeout expires
+		}
+	}
+}
+
+func (q *MemQ) Delete(ctx context.Context, m *M) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, x := range q.messages {
+		if len(m.ReceiptHandle) == 0 || x.ReceiptHandle != m.ReceiptHandle {
+			continue
+		}
+		q.messages = append(q.messages[:i], q.messages[i+1:]...)
+		q.signal()
+		q.logWith(ctx).Info("in-process message deleted",
+			zap.Any("id", m.ID),
+			zap.String("message_id", x.MessageID),
+		)
+		return nil
+	}
+
+	return errors.Wrapf("the receipt handle: %s was not found in queue: %s", m.ReceiptHandle, q.c.QueueURL)
+}
@@ -0,0 +1,107 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/queue"
+)
+
+func getMemQueue() *queue.MemQ {
+	return queue.NewMemBroker().Queue(queue.Config{
+		MaxNumberOfMessage: 10,
+		QueueURL:           "eve-api-test",
+		WaitTimeSecond:     0,
+		VisibilityTimeout:  3600,
+	})
+}
+
+func send(t *testing.T, q queue.Queue, groupID string, dedupeID string) *queue.M {
+	m := queue.M{
+		ID:       uuid.NewV4(),
+		GroupID:  groupID,
+		DedupeID: dedupeID,
+		Command:  queue.CommandScheduleDeployment,
+	}
+	require.NoError(t, q.Message(context.TODO(), &m))
+	return &m
+}
+
+func TestMemQ_FIFOPerGroup(t *testing.T) {
+	q := getMemQueue()
+	a1 := send(t, q, "a", "")
+	a2 := send(t, q, "a", "")
+	b1 := send(t, q, "b", "")
+
+	ms, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+	assert.Equal(t, a1.ID, ms[0].ID)
+	assert.Equal(t, b1.ID, ms[1].ID)
+
+	// both groups are in flight so nothing else should be delivered
+	ms2, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	assert.Len(t, ms2, 0)
+
+	require.NoError(t, q.Delete(context.TODO(), &ms[0].M))
+	ms3, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms3, 1)
+	assert.Equal(t, a2.ID, ms3[0].ID)
+	assert.Equal(t, queue.CommandScheduleDeployment, ms3[0].Command)
+}
+
+func TestMemQ_Dedupe(t *testing.T) {
+	q := getMemQueue()
+	m1 := send(t, q, "a", "dedupe")
+	m2 := send(t, q, "a", "dedupe")
+	assert.Equal(t, m1.MessageID, m2.MessageID)
+
+	ms, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	require.NoError(t, q.Delete(context.TODO(), &ms[0].M))
+
+	ms, err = q.Receive(context.TODO())
+	require.NoError(t, err)
+	assert.Len(t, ms, 0)
+}
+
+func TestMemQ_VisibilityTimeout(t *testing.T) {
+	q := queue.NewMemQ(queue.Config{
+		MaxNumberOfMessage: 10,
+		QueueURL:           "eve-api-test",
+		VisibilityTimeout:  0,
+	})
+	m := send(t, q, "a", "")
+
+	ms, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+
+	// the visibility timeout has already expired so the message is delivered again with a new receipt handle
+	ms2, err := q.Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms2, 1)
+	assert.Equal(t, m.ID, ms2[0].ID)
+	assert.NotEqual(t, ms[0].ReceiptHandle, ms2[0].ReceiptHandle)
+
+	assert.Error(t, q.Delete(context.TODO(), &ms[0].M))
+	assert.NoError(t, q.Delete(context.TODO(), &ms2[0].M))
+}
+
+func TestMemProvider(t *testing.T) {
+	b := queue.NewMemBroker()
+	p := queue.MemProvider(b)
+	m := send(t, p("eve-sch"), "a", "")
+
+	ms, err := b.Queue(queue.Config{QueueURL: "eve-sch"}).Receive(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.Equal(t, m.ID, ms[0].ID)
+}
@@ -0,0 +1,217 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/zap"
+
+	"github.com/unanet/go/pkg/errors"
+	"github.com/unanet/go/pkg/log"
+)
+
+const (
+	pgPollInterval = time.Second
+)
+
+// PgQ is a Queue implementation backed by the queue_message table in Postgres
+// Deleted messages are kept around for the deduplication interval so duplicate sends are still detected
+type PgQ struct {
+	db  *sqlx.DB
+	c   Config
+	log *zap.Logger
+	id  uint64
+}
+
+func NewPgQ(db *sqlx.DB, config Config) *PgQ {
+	qID := atomic.AddUint64(&queueID, 1)
+	return &PgQ{
+		id:  qID,
+		c:   config,
+		db:  db,
+		log: log.Logger.With(zap.String("queue_url", config.QueueURL), zap.Uint64("internal_queue_id", qID)),
+	}
+}
+
+// PgProvider returns a Provider that creates Postgres queues using the supplied db
+func PgProvider(db *sqlx.DB) Provider {
+	return func(qUrl string) Queue {
+		return NewPgQ(db, Config{
+			QueueURL: qUrl,
+		})
+	}
+}
+
+type pgMessage struct {
+	MessageID     string         `db:"message_id"`
+	GroupID       string         `db:"group_id"`
+	EveID         uuid.UUID      `db:"eve_id"`
+	Command       string         `db:"command"`
+	ReqID         sql.NullString `db:"req_id"`
+	Body          string         `db:"body"`
+	ReceiptHandle string         `db:"receipt_handle"`
+}
+
+func (q *PgQ) logWith(ctx context.Context) *zap.Logger {
+	return q.log.With(zap.String("req_id", log.GetReqID(ctx)))
+}
+
+func (q *PgQ) Message(ctx context.Context, m *M) error {
+	if len(m.Command) == 0 {
+		m.Command = "empty"
+	}
+
+	body := m.ID.String()
+	if len(m.Body) > 0 {
+		body = m.Body.String()
+	}
+
+	now := time.Now().UTC()
+	err := q.db.QueryRowxContext(ctx, `
+		with existing as (
+			select message_id from queue_message
+			where queue_url = $1 and dedupe_id <> '' and dedupe_id = $2 and created_at > $3
+			limit 1
+		), inserted as (
+			insert into queue_message(queue_url, group_id, dedupe_id, eve_id, command, req_id, body, visible_at, created_at)
+			select $1, $4, $2, $5, $6, $7, $8, $9, $9
+			where not exists (select 1 from existing)
+			returning message_id
+		)
+		select message_id from inserted
+		union all
+		select message_id from existing
+	`, q.c.QueueURL, m.DedupeID, now.Add(-dedupeInterval), m.GroupID, m.ID, m.Command, log.GetReqID(ctx), body, now).
+		Scan(&m.MessageID)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	q.logWith(ctx).Info("postgres queue message sent",
+		zap.Float64("elapsed_ms", float64(time.Since(now).Nanoseconds())/1000000.0),
+		zap.Any("id", m.ID),
+		zap.String("message_id", m.MessageID),
+	)
+	return nil
+}
+
+// receive claims the oldest message of each group that isn't currently in flight
+func (q *PgQ) receive(ctx context.Context) ([]*MContext, error) {
+	now := time.Now().UTC()
+
+	_, err := q.db.ExecContext(ctx, `
+		delete from queue_message where queue_url = $1 and deleted_at < $2
+	`, q.c.QueueURL, now.Add(-dedupeInterval))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	max := q.c.MaxNumberOfMessage
+	if max <= 0 {
+		max = 1
+	}
+
+	rows, err := q.db.QueryxContext(ctx, `
+		with heads as (
+			select distinct on (group_id) id, visible_at
+			from queue_message
+			where queue_url = $1 and deleted_at is null
+			order by group_id, id
+		), ready as (
+			select qm.id from queue_message qm
+				join heads h on h.id = qm.id
+			where h.visible_at <= $2
+			order by qm.id
+			limit $3
+			for update of qm skip locked
+		)
+		update queue_message as qm
+		set receipt_handle = uuid_generate_v4()::text,
+		    visible_at = $4,
+		    receive_count = qm.receive_count + 1
+		from ready where qm.id = ready.id
+		returning qm.message_id, qm.group_id, qm.eve_id, qm.command, qm.req_id, qm.body, qm.receipt_handle
+	`, q.c.QueueURL, now, max, now.Add(time.Duration(q.c.VisibilityTimeout)*time.Second))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer rows.Close()
+
+	var returnMs []*MContext
+	for rows.Next() {
+		var x pgMessage
+		err = rows.StructScan(&x)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		m := M{
+			ID:            x.EveID,
+			GroupID:       x.GroupID,
+			Command:       x.Command,
+			Body:          []byte(x.Body),
+			ReceiptHandle: x.ReceiptHandle,
+			MessageID:     x.MessageID,
+		}
+		mctx := context.WithValue(ctx, log.RequestIDKey, x.ReqID.String)
+		returnMs = append(returnMs, &MContext{
+			M:   m,
+			Ctx: mctx,
+		})
+		q.logWith(mctx).Info("postgres queue message received",
+			zap.Any("id", m.ID),
+			zap.String("message_id", m.MessageID),
+		)
+	}
+
+	return returnMs, nil
+}
+
+func (q *PgQ) Receive(ctx context.Context) ([]*MContext, error) {
+	deadline := time.Now().Add(time.Duration(q.c.WaitTimeSecond) * time.Second)
+	for {
+		ms, err := q.receive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(ms) > 0 || !time.Now().Add(pgPollInterval).Before(deadline) {
+			return ms, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(pgPollInterval):
+		}
+	}
+}
+
+func (q *PgQ) Delete(ctx context.Context, m *M) error {
+	now := time.Now().UTC()
+	result, err := q.db.ExecContext(ctx, `
+		update queue_message set deleted_at = $1
+		where queue_url = $2 and receipt_handle = $3 and deleted_at is null
+	`, now, q.c.QueueURL, m.ReceiptHandle)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+
+	if affected == 0 {
+		return errors.Wrapf("the receipt handle: %s was not found in queue: %s", m.ReceiptHandle, q.c.QueueURL)
+	}
+
+	q.logWith(ctx).Info("postgres queue message deleted",
+		zap.Float64("elapsed_ms", float64(time.Since(now).Nanoseconds())/1000000.0),
+		zap.Any("id", m.ID),
+		zap.String("message_id", m.MessageID),
+	)
+	return nil
+}
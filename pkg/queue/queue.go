@@ -29,6 +29,28 @@ var (
 	queueID uint64
 )
 
+// Queue is implemented by each of the queue backends (SQS, in-process memory and Postgres)
+// All of the backends are expected to keep the FIFO semantics of an SQS FIFO queue, messages are delivered
+// in order per GroupID and a group is blocked while one of its messages is in flight (received but not deleted)
+type Queue interface {
+	Message(ctx context.Context, m *M) error
+	Receive(ctx context.Context) ([]*MContext, error)
+	Delete(ctx context.Context, m *M) error
+}
+
+// Provider returns the Queue for a given queue url, it's used to send messages to queues other than the one being worked
+type Provider func(qUrl string) Queue
+
+// Backend is the type of queue implementation used
+type Backend string
+
+const (
+	BackendSQS      Backend = "sqs"
+	BackendMemory   Backend = "memory"
+	BackendPostgres Backend = "postgres"
+)
+
+// Q is the AWS SQS Queue implementation
 type Q struct {
 	aws  *sqs.SQS
 	c    Config
@@ -57,6 +79,15 @@ func NewQ(sess *session.Session, config Config) *Q {
 	}
 }
 
+// SQSProvider returns a Provider that creates SQS queues using the supplied session
+func SQSProvider(sess *session.Session) Provider {
+	return func(qUrl string) Queue {
+		return NewQ(sess, Config{
+			QueueURL: qUrl,
+		})
+	}
+}
+
 type M struct {
 	ID            uuid.UUID
 	GroupID       string
@@ -67,9 +98,11 @@ type M struct {
 	DedupeID      string
 }
 
-type mContext struct {
+// MContext is a received message and the context it's handled with, the context has the request id the message was
+// sent with
+type MContext struct {
 	M
-	ctx context.Context
+	Ctx context.Context
 }
 
 func (q *Q) logWith(ctx context.Context) *zap.Logger {
@@ -129,7 +162,7 @@ func (q *Q) Message(ctx context.Context, m *M) error {
 	return nil
 }
 
-func (q *Q) Receive(ctx context.Context) ([]*mContext, error) {
+func (q *Q) Receive(ctx context.Context) ([]*MContext, error) {
 	awsM := sqs.ReceiveMessageInput{
 		AttributeNames: []*string{
 			aws.String(sqs.MessageSystemAttributeNameMessageGroupId),
@@ -150,7 +183,7 @@ func (q *Q) Receive(ctx context.Context) ([]*mContext, error) {
 		return nil, errors.Wrap(err)
 	}
 
-	var returnMs []*mContext
+	var returnMs []*MContext
 	for _, x := range result.Messages {
 		if x == nil {
 			q.log.Warn("nil message received")
@@ -166,9 +199,9 @@ func (q *Q) Receive(ctx context.Context) ([]*mContext, error) {
 			MessageID:     *x.MessageId,
 		}
 		mctx := context.WithValue(ctx, log.RequestIDKey, *x.MessageAttributes[MessageAttributeReqID].StringValue)
-		returnMs = append(returnMs, &mContext{
+		returnMs = append(returnMs, &MContext{
 			M:   m,
-			ctx: mctx,
+			Ctx: mctx,
 		})
 		q.logWith(mctx).Info("AWS SQS message received",
 			zap.Any("id", m.ID),
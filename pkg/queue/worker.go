@@ -5,7 +5,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws/session"
 	"go.uber.org/zap"
 
 	"github.com/unanet/go/pkg/log"
@@ -24,30 +23,32 @@ type Handler interface {
 }
 
 type Worker struct {
-	q       *Q
-	log     *zap.Logger
-	name    string
-	timeout time.Duration
-	ctx     context.Context
-	cancel  context.CancelFunc
-	done    chan bool
-	wqs     map[string]*Q
-	mutex   sync.Mutex
-	sess    *session.Session
+	q        Queue
+	log      *zap.Logger
+	name     string
+	timeout  time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan bool
+	wqs      map[string]Queue
+	mutex    sync.Mutex
+	provider Provider
 }
 
-func NewWorker(name string, q *Q, timeout time.Duration) *Worker {
+// NewWorker creates a worker for the supplied queue, the provider is used to resolve any other queue
+// that the worker needs to send messages to (e.g. the scheduler queue for a cluster)
+func NewWorker(name string, q Queue, provider Provider, timeout time.Duration) *Worker {
 	ctx, cancel := context.WithCancel(context.Background())
 	w := Worker{
-		name:    name,
-		q:       q,
-		log:     log.Logger.With(zap.Uint64("internal_queue_id", q.id), zap.String("worker", name)),
-		timeout: timeout,
-		ctx:     ctx,
-		cancel:  cancel,
-		sess:    q.sess,
-		done:    make(chan bool),
-		wqs:     make(map[string]*Q),
+		name:     name,
+		q:        q,
+		log:      log.Logger.With(zap.String("worker", name)),
+		timeout:  timeout,
+		ctx:      ctx,
+		cancel:   cancel,
+		provider: provider,
+		done:     make(chan bool),
+		wqs:      make(map[string]Queue),
 	}
 
 	return &w
@@ -84,15 +85,13 @@ func (worker *Worker) DeleteMessage(ctx context.Context, m *M) error {
 	return worker.q.Delete(ctx, m)
 }
 
-func (worker *Worker) getQueue(qUrl string) *Q {
+func (worker *Worker) getQueue(qUrl string) Queue {
 	worker.mutex.Lock()
 	defer worker.mutex.Unlock()
 	if val, ok := worker.wqs[qUrl]; ok {
 		return val
 	}
-	q := NewQ(worker.sess, Config{
-		QueueURL: qUrl,
-	})
+	q := worker.provider(qUrl)
 	worker.wqs[qUrl] = q
 	return q
 }
@@ -102,13 +101,13 @@ func (worker *Worker) Message(ctx context.Context, qUrl string, m *M) error {
 	return q.Message(ctx, m)
 }
 
-func (worker *Worker) run(h Handler, mCtx []*mContext) {
+func (worker *Worker) run(h Handler, mCtx []*MContext) {
 	numMessages := len(mCtx)
 	var wg sync.WaitGroup
 	wg.Add(numMessages)
 	for _, mc := range mCtx {
-		go func(m *mContext) {
-			ctx, cancel := context.WithTimeout(m.ctx, worker.timeout)
+		go func(m *MContext) {
+			ctx, cancel := context.WithTimeout(m.Ctx, worker.timeout)
 			defer cancel()
 			defer wg.Done()
 			if err := h.HandleMessage(ctx, &m.M); err != nil {
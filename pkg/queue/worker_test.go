@@ -0,0 +1,63 @@
+package queue_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unanet/go/pkg/log"
+
+	"github.com/unanet/eve/pkg/queue"
+)
+
+// stubQueue is a Queue implemented outside of the package, it delivers its messages once
+type stubQueue struct {
+	mutex    sync.Mutex
+	messages []*queue.MContext
+}
+
+func (q *stubQueue) Message(ctx context.Context, m *queue.M) error {
+	return nil
+}
+
+func (q *stubQueue) Receive(ctx context.Context) ([]*queue.MContext, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.messages) == 0 {
+		time.Sleep(10 * time.Millisecond)
+		return nil, nil
+	}
+	messages := q.messages
+	q.messages = nil
+	return messages, nil
+}
+
+func (q *stubQueue) Delete(ctx context.Context, m *queue.M) error {
+	return nil
+}
+
+func TestWorker_StartWithExternalQueue(t *testing.T) {
+	id := uuid.NewV4()
+	ctx := context.WithValue(context.Background(), log.RequestIDKey, "req-1")
+	q := &stubQueue{messages: []*queue.MContext{{M: queue.M{ID: id, Command: "test"}, Ctx: ctx}}}
+
+	handled := make(chan string, 1)
+	worker := queue.NewWorker("test", q, nil, time.Second)
+	go worker.Start(queue.HandlerFunc(func(ctx context.Context, m *queue.M) error {
+		assert.Equal(t, id, m.ID)
+		handled <- log.GetReqID(ctx)
+		return nil
+	}))
+	defer worker.Stop()
+
+	select {
+	case reqID := <-handled:
+		require.Equal(t, "req-1", reqID)
+	case <-time.After(time.Second):
+		t.Fatal("the message wasn't handled")
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// FileSystem stores the data as files under a root directory, it's meant for local dev and single node installs
+type FileSystem struct {
+	Dir string
+}
+
+func NewFileSystem(dir string) *FileSystem {
+	return &FileSystem{
+		Dir: dir,
+	}
+}
+
+func (fs FileSystem) path(key string) (string, error) {
+	p := filepath.Join(fs.Dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, filepath.Clean(fs.Dir)+string(os.PathSeparator)) {
+		return "", errors.Wrapf("invalid storage key: %s", key)
+	}
+	return p, nil
+}
+
+func (fs FileSystem) Upload(ctx context.Context, key string, body []byte) (*Location, error) {
+	p, err := fs.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0755)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	err = ioutil.WriteFile(p, body, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &Location{
+		Backend: BackendFileSystem,
+		Key:     key,
+		Url:     fmt.Sprintf("file://%s", filepath.ToSlash(p)),
+	}, nil
+}
+
+func (fs FileSystem) Download(ctx context.Context, location *Location) ([]byte, error) {
+	p, err := fs.path(location.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return body, nil
+}
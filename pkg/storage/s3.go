@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/unanet/eve/pkg/s3"
+	"github.com/unanet/go/pkg/errors"
+)
+
+// S3 adapts the s3 Uploader and Downloader to the storage interfaces
+type S3 struct {
+	uploader   *s3.Uploader
+	downloader *s3.Downloader
+}
+
+func NewS3(uploader *s3.Uploader, downloader *s3.Downloader) *S3 {
+	return &S3{
+		uploader:   uploader,
+		downloader: downloader,
+	}
+}
+
+func (s S3) Upload(ctx context.Context, key string, body []byte) (*Location, error) {
+	location, err := s.uploader.Upload(ctx, key, body)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	return &Location{
+		Backend: BackendS3,
+		Bucket:  location.Bucket,
+		Key:     location.Key,
+		Url:     location.Url,
+	}, nil
+}
+
+func (s S3) Download(ctx context.Context, location *Location) ([]byte, error) {
+	return s.downloader.Download(ctx, &s3.Location{
+		Bucket: location.Bucket,
+		Key:    location.Key,
+		Url:    location.Url,
+	})
+}
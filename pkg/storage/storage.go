@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/unanet/go/pkg/errors"
+)
+
+// Backend is the type of storage that holds a plan
+type Backend string
+
+const (
+	BackendS3         Backend = "s3"
+	BackendFileSystem Backend = "filesystem"
+	BackendPostgres   Backend = "postgres"
+	// BackendInline means the plan travels in the location (queue message body) itself
+	BackendInline Backend = "inline"
+)
+
+// Location is the envelope that's sent in the queue message body, it's a superset of the s3.Location
+// so a location without a backend is treated as an S3 location
+type Location struct {
+	Backend Backend         `json:"backend,omitempty"`
+	Bucket  string          `json:"bucket,omitempty"`
+	Key     string          `json:"key"`
+	Url     string          `json:"url,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// GetBackend returns the backend that holds the data, defaulting to S3 for locations that predate the envelope
+func (l Location) GetBackend() Backend {
+	if len(l.Backend) == 0 {
+		return BackendS3
+	}
+	return l.Backend
+}
+
+type Uploader interface {
+	Upload(ctx context.Context, key string, body []byte) (*Location, error)
+}
+
+type Downloader interface {
+	Download(ctx context.Context, location *Location) ([]byte, error)
+}
+
+// Store uploads to the configured backend (or inline when the body is small enough)
+// and downloads from whichever backend the location says holds the data
+type Store struct {
+	uploader    Uploader
+	downloaders map[Backend]Downloader
+	inlineLimit int
+}
+
+// NewStore creates a Store, an inlineLimit of 0 disables inline locations
+func NewStore(uploader Uploader, inlineLimit int, downloaders map[Backend]Downloader) *Store {
+	return &Store{
+		uploader:    uploader,
+		downloaders: downloaders,
+		inlineLimit: inlineLimit,
+	}
+}
+
+func (s *Store) Upload(ctx context.Context, key string, body []byte) (*Location, error) {
+	if s.inlineLimit > 0 && len(body) <= s.inlineLimit && json.Valid(body) {
+		return &Location{
+			Backend: BackendInline,
+			Key:     key,
+			Body:    body,
+		}, nil
+	}
+
+	if s.uploader == nil {
+		return nil, errors.Wrapf("no storage uploader configured for key: %s", key)
+	}
+
+	return s.uploader.Upload(ctx, key, body)
+}
+
+func (s *Store) Download(ctx context.Context, location *Location) ([]byte, error) {
+	backend := location.GetBackend()
+	if backend == BackendInline {
+		return location.Body, nil
+	}
+
+	d, ok := s.downloaders[backend]
+	if !ok {
+		return nil, errors.Wrapf("no storage downloader configured for backend: %s", backend)
+	}
+
+	return d.Download(ctx, location)
+}
@@ -0,0 +1,56 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/storage"
+)
+
+func TestStore_FileSystem(t *testing.T) {
+	fs := storage.NewFileSystem(t.TempDir())
+	s := storage.NewStore(fs, 0, map[storage.Backend]storage.Downloader{
+		storage.BackendFileSystem: fs,
+	})
+
+	location, err := s.Upload(context.TODO(), "dev/ns/plan.json", []byte(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, storage.BackendFileSystem, location.Backend)
+
+	body, err := s.Download(context.TODO(), location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(body))
+}
+
+func TestStore_Inline(t *testing.T) {
+	s := storage.NewStore(nil, 100, nil)
+
+	location, err := s.Upload(context.TODO(), "plan.json", []byte(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, storage.BackendInline, location.Backend)
+
+	body, err := s.Download(context.TODO(), location)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(body))
+
+	// too big to inline and there's no uploader
+	_, err = s.Upload(context.TODO(), "plan.json", make([]byte, 101))
+	assert.Error(t, err)
+}
+
+func TestStore_LegacyLocationIsS3(t *testing.T) {
+	s := storage.NewStore(nil, 0, map[storage.Backend]storage.Downloader{})
+
+	_, err := s.Download(context.TODO(), &storage.Location{Bucket: "bucket", Key: "plan.json"})
+	assert.Error(t, err)
+	assert.Equal(t, storage.BackendS3, storage.Location{Key: "plan.json"}.GetBackend())
+}
+
+func TestFileSystem_InvalidKey(t *testing.T) {
+	fs := storage.NewFileSystem(t.TempDir())
+	_, err := fs.Upload(context.TODO(), "../plan.json", []byte(`{}`))
+	assert.Error(t, err)
+}
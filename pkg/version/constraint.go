@@ -0,0 +1,253 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type operator string
+
+const (
+	opAny            operator = ""
+	opPrefix         operator = "="
+	opNot            operator = "!="
+	opGreater        operator = ">"
+	opGreaterOrEqual operator = ">="
+	opLess           operator = "<"
+	opLessOrEqual    operator = "<="
+)
+
+type comparator struct {
+	op operator
+	v  *Version
+}
+
+func (c comparator) check(v Version) bool {
+	switch c.op {
+	case opAny:
+		return true
+	case opPrefix:
+		return v.HasPrefix(*c.v)
+	case opNot:
+		return !v.HasPrefix(*c.v)
+	case opGreater:
+		return v.Compare(*c.v) > 0
+	case opGreaterOrEqual:
+		return v.Compare(*c.v) >= 0
+	case opLess:
+		return v.Compare(*c.v) < 0
+	case opLessOrEqual:
+		return v.Compare(*c.v) <= 0
+	default:
+		return false
+	}
+}
+
+// Constraint is a version requirement, its terms are AND'd when they're separated by spaces or commas and
+// groups of terms are OR'd with "||". The terms are:
+//
+//	"", "*", "latest"    any version
+//	1.2, 1.2.*, =1.2     a prefix, 1.2 matches 1.2.0 and 1.2.3.456 but not 1.20.0
+//	!=1.4.3              anything that doesn't have the prefix
+//	>, >=, <, <=         comparisons, missing parts are 0
+//	~1.2, ~1.2.3         >=1.2.0 <1.3.0, the minor version (or major when that's all there is) can't change
+//	^1.2.3, ^0.2.3       >=1.2.3 <2.0.0 and >=0.2.3 <0.3.0, the first non zero part can't change
+type Constraint struct {
+	groups   [][]comparator
+	original string
+}
+
+var operatorSpace = regexp.MustCompile(`(>=|<=|!=|>|<|=|~|\^)\s+`)
+
+// ParseConstraint parses a constraint expression
+func ParseConstraint(s string) (*Constraint, error) {
+	c := Constraint{original: s}
+	normalized := operatorSpace.ReplaceAllString(strings.TrimSpace(s), "$1")
+	for _, group := range strings.Split(normalized, "||") {
+		var comparators []comparator
+		for _, term := range strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' }) {
+			terms, err := parseTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint: %q, %s", s, err)
+			}
+			comparators = append(comparators, terms...)
+		}
+		if len(comparators) == 0 {
+			if len(normalized) > 0 {
+				return nil, fmt.Errorf("invalid version constraint: %q, empty group", s)
+			}
+			comparators = append(comparators, comparator{op: opAny})
+		}
+		c.groups = append(c.groups, comparators)
+	}
+	return &c, nil
+}
+
+// MustParseConstraint is ParseConstraint but it panics on an invalid constraint
+func MustParseConstraint(s string) *Constraint {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func parseTerm(term string) ([]comparator, error) {
+	if term == "*" || strings.EqualFold(term, "latest") {
+		return []comparator{{op: opAny}}, nil
+	}
+
+	var op string
+	for _, x := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, x) {
+			op = x
+			term = term[len(x):]
+			break
+		}
+	}
+
+	wildcard := false
+	if trimmed := trimWildcard(term); trimmed != term {
+		wildcard = true
+		term = trimmed
+		if len(term) == 0 {
+			if op == "" || op == "=" {
+				return []comparator{{op: opAny}}, nil
+			}
+			return nil, fmt.Errorf("%s* isn't supported", op)
+		}
+	}
+
+	v, err := Parse(term)
+	if err != nil {
+		return nil, err
+	}
+
+	if wildcard && op != "" && op != "=" && op != "!=" {
+		return nil, fmt.Errorf("wildcards can't be used with %s", op)
+	}
+
+	switch op {
+	case "", "=":
+		return []comparator{{op: opPrefix, v: v}}, nil
+	case "!=":
+		return []comparator{{op: opNot, v: v}}, nil
+	case ">":
+		return []comparator{{op: opGreater, v: v}}, nil
+	case ">=":
+		return []comparator{{op: opGreaterOrEqual, v: v}}, nil
+	case "<":
+		return []comparator{{op: opLess, v: v}}, nil
+	case "<=":
+		return []comparator{{op: opLessOrEqual, v: v}}, nil
+	case "~":
+		i := 1
+		if len(v.Parts) == 1 {
+			i = 0
+		}
+		return []comparator{{op: opGreaterOrEqual, v: v}, {op: opLess, v: bump(v, i)}}, nil
+	case "^":
+		i := len(v.Parts) - 1
+		for x, part := range v.Parts {
+			if part != 0 {
+				i = x
+				break
+			}
+		}
+		return []comparator{{op: opGreaterOrEqual, v: v}, {op: opLess, v: bump(v, i)}}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// trimWildcard removes the trailing wildcard parts, 1.2.* and 1.2.x are 1.2
+func trimWildcard(term string) string {
+	for {
+		switch {
+		case term == "*" || term == "x" || term == "X":
+			return ""
+		case strings.HasSuffix(term, ".*") || strings.HasSuffix(term, ".x") || strings.HasSuffix(term, ".X"):
+			term = term[:len(term)-2]
+		default:
+			return term
+		}
+	}
+}
+
+// bump returns the lowest version that's above every version with the same parts up to i
+func bump(v *Version, i int) *Version {
+	parts := make([]int, i+1)
+	copy(parts, v.Parts[:i+1])
+	parts[i]++
+	return &Version{Parts: parts}
+}
+
+// Check is true when the version satisfies the constraint
+func (c Constraint) Check(v Version) bool {
+	for _, group := range c.groups {
+		satisfied := true
+		for _, x := range group {
+			if !x.check(v) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// Any is true when every version satisfies the constraint
+func (c Constraint) Any() bool {
+	for _, group := range c.groups {
+		if len(group) == 1 && group[0].op == opAny {
+			return true
+		}
+	}
+	return false
+}
+
+// Pattern returns the Artifactory wildcard pattern for a constraint that's a single prefix (or any version), ok is false
+// when the constraint can't be expressed as a pattern. A prefix with fewer than four parts has ".*" appended
+func (c Constraint) Pattern() (pattern string, ok bool) {
+	if c.Any() {
+		return "*", true
+	}
+
+	if len(c.groups) != 1 || len(c.groups[0]) != 1 || c.groups[0][0].op != opPrefix {
+		return "", false
+	}
+
+	v := c.groups[0][0].v
+	if len(v.Prerelease) > 0 || len(v.Metadata) > 0 {
+		return "", false
+	}
+
+	pattern = Version{Parts: v.Parts}.String()
+	if len(v.Parts) < 4 {
+		pattern += ".*"
+	}
+	return pattern, true
+}
+
+func (c Constraint) String() string {
+	return c.original
+}
+
+// Satisfies is true when the version parses and satisfies the constraint
+func Satisfies(version string, constraint string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return false
+	}
+
+	return c.Check(*v)
+}
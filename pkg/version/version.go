@@ -0,0 +1,204 @@
+// Package version parses and compares the versions eve deploys, semver (1.2.3, 1.2.3-rc.1+build.5)
+// and four part build numbers (1.2.3.456), and the constraint expressions used to request them
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed version, it keeps the parts that were specified so a partial version (1.2) can be used as a prefix
+type Version struct {
+	Parts      []int
+	Prerelease string
+	Metadata   string
+	original   string
+}
+
+// Parse parses a version with one or more numeric parts, an optional leading "v", prerelease and build metadata
+func Parse(s string) (*Version, error) {
+	original := s
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if len(s) == 0 {
+		return nil, fmt.Errorf("invalid version: %q", original)
+	}
+
+	v := Version{original: original}
+	if i := strings.Index(s, "+"); i >= 0 {
+		v.Metadata = s[i+1:]
+		s = s[:i]
+	}
+
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+		if len(v.Prerelease) == 0 {
+			return nil, fmt.Errorf("invalid version: %q, empty prerelease", original)
+		}
+	}
+
+	for _, x := range strings.Split(s, ".") {
+		part, err := strconv.Atoi(x)
+		if err != nil || part < 0 {
+			return nil, fmt.Errorf("invalid version: %q, %q isn't a number", original, x)
+		}
+		v.Parts = append(v.Parts, part)
+	}
+
+	return &v, nil
+}
+
+// MustParse is Parse but it panics on an invalid version
+func MustParse(s string) *Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v Version) String() string {
+	if len(v.original) > 0 {
+		return v.original
+	}
+
+	var parts []string
+	for _, x := range v.Parts {
+		parts = append(parts, strconv.Itoa(x))
+	}
+	s := strings.Join(parts, ".")
+	if len(v.Prerelease) > 0 {
+		s += "-" + v.Prerelease
+	}
+	if len(v.Metadata) > 0 {
+		s += "+" + v.Metadata
+	}
+	return s
+}
+
+func (v Version) part(i int) int {
+	if i < len(v.Parts) {
+		return v.Parts[i]
+	}
+	return 0
+}
+
+// Compare returns -1, 0 or 1, missing parts are treated as 0 (1.2 == 1.2.0.0) and a prerelease is lower than its release.
+// Build metadata is ignored
+func (v Version) Compare(o Version) int {
+	n := len(v.Parts)
+	if len(o.Parts) > n {
+		n = len(o.Parts)
+	}
+
+	for i := 0; i < n; i++ {
+		if a, b := v.part(i), o.part(i); a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// HasPrefix is true when v starts with the parts of prefix, 1.10.2 doesn't have the prefix 1.1
+func (v Version) HasPrefix(prefix Version) bool {
+	if len(prefix.Parts) > len(v.Parts) {
+		return false
+	}
+
+	for i, x := range prefix.Parts {
+		if v.Parts[i] != x {
+			return false
+		}
+	}
+
+	if len(prefix.Prerelease) > 0 {
+		return len(prefix.Parts) == len(v.Parts) && prefix.Prerelease == v.Prerelease
+	}
+
+	return true
+}
+
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if ai != bi {
+				if ai < bi {
+					return -1
+				}
+				return 1
+			}
+		// numeric identifiers are lower than alphanumeric ones
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Less compares two version strings, versions that can't be parsed are lower than ones that can
+func Less(a, b string) bool {
+	va, aErr := Parse(a)
+	vb, bErr := Parse(b)
+	switch {
+	case aErr != nil && bErr != nil:
+		return a < b
+	case aErr != nil:
+		return true
+	case bErr != nil:
+		return false
+	default:
+		return va.LessThan(*vb)
+	}
+}
+
+// Latest returns the highest version that satisfies the constraint, ok is false when none of them do
+func Latest(versions []string, c *Constraint) (latest string, ok bool) {
+	var best *Version
+	for _, x := range versions {
+		v, err := Parse(x)
+		if err != nil || !c.Check(*v) {
+			continue
+		}
+		if best == nil || best.LessThan(*v) {
+			best = v
+			latest = x
+		}
+	}
+	return latest, best != nil
+}
@@ -0,0 +1,133 @@
+package version_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unanet/eve/pkg/version"
+)
+
+func TestParse(t *testing.T) {
+	v, err := version.Parse("v1.2.3-rc.1+build.5")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, v.Parts)
+	assert.Equal(t, "rc.1", v.Prerelease)
+	assert.Equal(t, "build.5", v.Metadata)
+
+	v, err = version.Parse("1.2.3.456")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 456}, v.Parts)
+
+	for _, x := range []string{"", "latest", "1..2", "1.2.a", "1.2-"} {
+		_, err := version.Parse(x)
+		assert.Error(t, err, x)
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.2", "1.2.0.0", 0},
+		{"1.2.3.456", "1.2.3.99", 1},
+		{"1.2.3-rc.1", "1.2.3", -1},
+		{"1.2.3-rc.2", "1.2.3-rc.10", -1},
+		{"1.2.3-alpha", "1.2.3-beta", -1},
+		{"1.2.3-1", "1.2.3-alpha", -1},
+		{"1.2.3+a", "1.2.3+b", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, version.MustParse(tt.a).Compare(*version.MustParse(tt.b)), "%s <=> %s", tt.a, tt.b)
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+	}{
+		{"1.2.3", "", true},
+		{"1.2.3", "latest", true},
+		{"1.2.3", "*", true},
+		{"1.2.3.456", "1.2", true},
+		{"1.20.0", "1.2", false},
+		{"1.2.3.456", "1.2.3", true},
+		{"1.2.3", "1.2.*", true},
+		{"1.2.3", "=1.2", true},
+		{"1.4.3", "!=1.4.3", false},
+		{"1.4.4", "!=1.4.3", true},
+		{"1.2.9", "~1.2", true},
+		{"1.3.0", "~1.2", false},
+		{"1.2.3", "~1.2.4", false},
+		{"1.9.0", "~1", true},
+		{"2.0.0", "~1", false},
+		{"1.9.0", "^1.2.3", true},
+		{"2.0.0", "^1.2.3", false},
+		{"0.2.9", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"2.5.0", ">=2.0 <3", true},
+		{"3.0.0", ">=2.0 <3", false},
+		{"3.0.0-rc.1", ">=2.0 <3", true},
+		{"2.5.0", ">= 2.0, < 3", true},
+		{"1.0.0", "<1.0.0 || >=2", false},
+		{"2.1.0", "<1.0.0 || >=2", true},
+		{"1.2.3", "<=1.2.3", true},
+		{"1.2.3", ">1.2.3", false},
+		{"not-a-version", "*", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, version.Satisfies(tt.version, tt.constraint), "%s %s", tt.version, tt.constraint)
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, x := range []string{">=", ">=1.*", "~x", "1.2 ||", "abc", ">>1"} {
+		_, err := version.ParseConstraint(x)
+		assert.Error(t, err, x)
+	}
+}
+
+func TestConstraint_Pattern(t *testing.T) {
+	tests := []struct {
+		constraint, pattern string
+		ok                  bool
+	}{
+		{"", "*", true},
+		{"latest", "*", true},
+		{"1.2", "1.2.*", true},
+		{"1.2.*", "1.2.*", true},
+		{"1.2.3.4", "1.2.3.4", true},
+		{"~1.2", "", false},
+		{">=2.0 <3", "", false},
+	}
+	for _, tt := range tests {
+		pattern, ok := version.MustParseConstraint(tt.constraint).Pattern()
+		assert.Equal(t, tt.ok, ok, tt.constraint)
+		assert.Equal(t, tt.pattern, pattern, tt.constraint)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	versions := []string{"1.9.0", "1.10.0", "2.0.0", "1.10.1-rc.1", "abc"}
+
+	latest, ok := version.Latest(versions, version.MustParseConstraint("<2"))
+	assert.True(t, ok)
+	assert.Equal(t, "1.10.1-rc.1", latest)
+
+	latest, ok = version.Latest(versions, version.MustParseConstraint("~1.9"))
+	assert.True(t, ok)
+	assert.Equal(t, "1.9.0", latest)
+
+	_, ok = version.Latest(versions, version.MustParseConstraint(">3"))
+	assert.False(t, ok)
+}
+
+func TestLess(t *testing.T) {
+	assert.True(t, version.Less("1.9.0", "1.10.0"))
+	assert.True(t, version.Less("abc", "0.0.1"))
+	assert.False(t, version.Less("1.10.0", "1.9.0"))
+}